@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns/multiplexer"
+	operatorconfig "github.com/openshift/cluster-ingress-operator/pkg/operator/config"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// dnsBackendsConfigKey is the key in the DNS backends configmap whose value
+// is the YAML encoded dnsBackendsConfig.
+const dnsBackendsConfigKey = "backends.yaml"
+
+// dnsBackendProvider is the kind of DNS manager that a backend uses.
+type dnsBackendProvider string
+
+const (
+	// platformDNSBackendProvider uses the DNS manager of the cluster's
+	// platform with the cloud credentials secret.
+	platformDNSBackendProvider dnsBackendProvider = "Platform"
+	// webhookDNSBackendProvider uses a webhook configured by the backend's
+	// secret, which has the same keys as the dns-webhook secret.
+	webhookDNSBackendProvider dnsBackendProvider = "Webhook"
+	// rfc2136DNSBackendProvider uses RFC 2136 dynamic updates configured by
+	// the backend's secret, which has the same keys as the dns-rfc2136
+	// secret.
+	rfc2136DNSBackendProvider dnsBackendProvider = "RFC2136"
+)
+
+// dnsBackendsConfig is the declarative configuration of the DNS backends, for
+// example:
+//
+//	backends:
+//	- name: route53
+//	  provider: Platform
+//	  zone:
+//	    tags:
+//	      kubernetes.io/cluster/mycluster-abcde: owned
+//	- name: external
+//	  provider: Webhook
+//	  secretName: dns-external
+//
+// Each record is published by the first backend whose zone matches the
+// record's zone. A backend without a zone matches every zone.
+type dnsBackendsConfig struct {
+	Backends []dnsBackendConfig `json:"backends"`
+}
+
+// dnsBackendConfig configures a single DNS backend.
+type dnsBackendConfig struct {
+	// Name identifies the backend in logs and status conditions.
+	Name string `json:"name"`
+	// Provider is the kind of DNS manager that the backend uses.
+	Provider dnsBackendProvider `json:"provider"`
+	// SecretName is the name of the secret in the operator's namespace that
+	// configures the provider. It is required for the Webhook and RFC2136
+	// providers.
+	SecretName string `json:"secretName,omitempty"`
+	// TTL, if positive, is the TTL in seconds of the records that the
+	// Platform provider publishes on Azure, overriding the operator's
+	// DNS_RECORD_TTL.
+	TTL int64 `json:"ttl,omitempty"`
+	// Zone selects the zones whose records the backend manages.
+	Zone struct {
+		// ID, if not empty, selects the zone with this ID.
+		ID string `json:"id,omitempty"`
+		// Tags, if not empty, selects the zones with all of these tags.
+		Tags map[string]string `json:"tags,omitempty"`
+	} `json:"zone,omitempty"`
+}
+
+// dnsBackendSecretNames returns the names of the secrets of the backends
+// configured by the given DNS backends configmap. An invalid configuration has
+// no secrets; building the DNS manager from it fails anyway.
+func dnsBackendSecretNames(configMap *corev1.ConfigMap) []string {
+	var config dnsBackendsConfig
+	if err := yaml.Unmarshal([]byte(configMap.Data[dnsBackendsConfigKey]), &config); err != nil {
+		return nil
+	}
+	var names []string
+	for _, backendConfig := range config.Backends {
+		if len(backendConfig.SecretName) != 0 {
+			names = append(names, backendConfig.SecretName)
+		}
+	}
+	return names
+}
+
+// createMultiplexedDNSManager creates a DNS manager that dispatches records to
+// the backends configured by the given configmap.
+func createMultiplexedDNSManager(cl client.Client, configMap *corev1.ConfigMap, operatorConfig operatorconfig.Config, infraConfig *configv1.Infrastructure, dnsConfig *configv1.DNS, installConfig *installConfig) (dns.Manager, error) {
+	data, ok := configMap.Data[dnsBackendsConfigKey]
+	if !ok {
+		return nil, fmt.Errorf("missing %s in configmap %s/%s", dnsBackendsConfigKey, configMap.Namespace, configMap.Name)
+	}
+	var config dnsBackendsConfig
+	if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+		return nil, fmt.Errorf("invalid dns backends config in configmap %s/%s: %v", configMap.Namespace, configMap.Name, err)
+	}
+	if len(config.Backends) == 0 {
+		return nil, fmt.Errorf("no dns backends in configmap %s/%s", configMap.Namespace, configMap.Name)
+	}
+
+	var backends []multiplexer.Backend
+	for _, backendConfig := range config.Backends {
+		manager, err := createDNSBackendManager(cl, backendConfig, operatorConfig, infraConfig, dnsConfig, installConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create dns backend %q: %v", backendConfig.Name, err)
+		}
+		log.Info("using dns backend", "name", backendConfig.Name, "provider", backendConfig.Provider, "zone", backendConfig.Zone)
+		backends = append(backends, multiplexer.Backend{
+			Name:     backendConfig.Name,
+			ZoneID:   backendConfig.Zone.ID,
+			ZoneTags: backendConfig.Zone.Tags,
+			Manager:  manager,
+		})
+	}
+	return multiplexer.NewManager(multiplexer.Config{Backends: backends})
+}
+
+// createDNSBackendManager creates the DNS manager of a single backend.
+func createDNSBackendManager(cl client.Client, backendConfig dnsBackendConfig, operatorConfig operatorconfig.Config, infraConfig *configv1.Infrastructure, dnsConfig *configv1.DNS, installConfig *installConfig) (dns.Manager, error) {
+	switch backendConfig.Provider {
+	case platformDNSBackendProvider:
+		recordTTL := backendConfig.TTL
+		if recordTTL <= 0 {
+			recordTTL = operatorConfig.DNSRecordTTL
+		}
+		return createPlatformDNSManager(cl, operatorConfig, infraConfig, dnsConfig, installConfig, recordTTL)
+	case webhookDNSBackendProvider, rfc2136DNSBackendProvider:
+		if len(backendConfig.SecretName) == 0 {
+			return nil, fmt.Errorf("secretName is required for provider %s", backendConfig.Provider)
+		}
+		secret := &corev1.Secret{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: operatorConfig.Namespace, Name: backendConfig.SecretName}, secret); err != nil {
+			return nil, fmt.Errorf("failed to get secret %s/%s: %v", operatorConfig.Namespace, backendConfig.SecretName, err)
+		}
+		if backendConfig.Provider == webhookDNSBackendProvider {
+			return createWebhookDNSManager(secret, operatorConfig, dnsConfig)
+		}
+		return createRFC2136DNSManager(secret, dnsConfig)
+	default:
+		return nil, fmt.Errorf("unsupported provider %q", backendConfig.Provider)
+	}
+}
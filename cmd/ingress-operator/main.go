@@ -4,12 +4,18 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/ghodss/yaml"
 
 	"github.com/openshift/cluster-ingress-operator/pkg/dns"
 	awsdns "github.com/openshift/cluster-ingress-operator/pkg/dns/aws"
 	azuredns "github.com/openshift/cluster-ingress-operator/pkg/dns/azure"
+	gcpdns "github.com/openshift/cluster-ingress-operator/pkg/dns/gcp"
+	dnsmetrics "github.com/openshift/cluster-ingress-operator/pkg/dns/metrics"
+	rfc2136dns "github.com/openshift/cluster-ingress-operator/pkg/dns/rfc2136"
+	webhookdns "github.com/openshift/cluster-ingress-operator/pkg/dns/webhook"
 	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
 	"github.com/openshift/cluster-ingress-operator/pkg/operator"
 	operatorclient "github.com/openshift/cluster-ingress-operator/pkg/operator/client"
@@ -20,6 +26,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -33,6 +40,23 @@ const (
 	// operator's namespace that will hold the credentials that the operator
 	// will use to authenticate with the cloud API.
 	cloudCredentialsSecretName = "cloud-credentials"
+
+	// rfc2136SecretName is the name of the optional secret in the operator's
+	// namespace that configures DNS management using RFC 2136 dynamic
+	// updates on platforms without a cloud DNS service.
+	rfc2136SecretName = "dns-rfc2136"
+
+	// webhookSecretName is the name of the optional secret in the operator's
+	// namespace that configures DNS management using a webhook. If the
+	// secret exists, the webhook is used on every platform.
+	webhookSecretName = "dns-webhook"
+
+	// dnsBackendsConfigMapName is the name of the optional configmap in the
+	// operator's namespace that configures DNS management using several
+	// backends, each of which manages the records in some of the zones. If
+	// the configmap exists, it takes precedence over the webhook secret and
+	// the platform.
+	dnsBackendsConfigMapName = "dns-backends"
 )
 
 var log = logf.Logger.WithName("entrypoint")
@@ -70,32 +94,29 @@ func main() {
 		log.Info("RELEASE_VERSION environment variable missing", "release version", controller.UnknownVersionValue)
 	}
 
-	// Retrieve the cluster infrastructure config.
-	infraConfig := &configv1.Infrastructure{}
-	err = kubeClient.Get(context.TODO(), types.NamespacedName{Name: "cluster"}, infraConfig)
-	if err != nil {
-		log.Error(err, "failed to get infrastructure 'config'")
-		os.Exit(1)
+	var dnsRecordTTL int64
+	if v := os.Getenv("DNS_RECORD_TTL"); len(v) != 0 {
+		dnsRecordTTL, err = strconv.ParseInt(v, 10, 32)
+		if err != nil || dnsRecordTTL <= 0 {
+			log.Error(fmt.Errorf("invalid environment variable"), "'DNS_RECORD_TTL' environment variable must be a positive number of seconds", "value", v)
+			os.Exit(1)
+		}
 	}
 
-	dnsConfig := &configv1.DNS{}
-	err = kubeClient.Get(context.TODO(), types.NamespacedName{Name: "cluster"}, dnsConfig)
-	if err != nil {
-		log.Error(err, "failed to get dns 'cluster'")
-		os.Exit(1)
+	var verifyDNSResolution bool
+	if v := os.Getenv("VERIFY_DNS_RESOLUTION"); len(v) != 0 {
+		verifyDNSResolution, err = strconv.ParseBool(v)
+		if err != nil {
+			log.Error(fmt.Errorf("invalid environment variable"), "'VERIFY_DNS_RESOLUTION' environment variable must be a boolean", "value", v)
+			os.Exit(1)
+		}
 	}
 
-	// TODO: This can be replaced by cluster API when
-	// https://github.com/openshift/installer/pull/1725 is available.
-	clusterConfig := &corev1.ConfigMap{}
-	err = kubeClient.Get(context.TODO(), types.NamespacedName{Namespace: "kube-system", Name: "cluster-config-v1"}, clusterConfig)
-	if err != nil {
-		log.Error(err, "failed to get configmap 'kube-system/cluster-config-v1'")
-		os.Exit(1)
-	}
-	installConfig, err := newInstallConfig(clusterConfig)
+	// Retrieve the cluster infrastructure config.
+	infraConfig := &configv1.Infrastructure{}
+	err = kubeClient.Get(context.TODO(), types.NamespacedName{Name: "cluster"}, infraConfig)
 	if err != nil {
-		log.Error(err, "failed to extract install config from cluster config")
+		log.Error(err, "failed to get infrastructure 'config'")
 		os.Exit(1)
 	}
 
@@ -103,17 +124,25 @@ func main() {
 		OperatorReleaseVersion: releaseVersion,
 		Namespace:              operatorNamespace,
 		IngressControllerImage: ingressControllerImage,
+		InfrastructureName:     infraConfig.Status.InfrastructureName,
+		VerifyDNSResolution:    verifyDNSResolution,
+		DNSResolver:            os.Getenv("DNS_RESOLVER"),
+		DNSRecordTTL:           dnsRecordTTL,
 	}
 
-	// Set up the DNS manager.
-	dnsManager, err := createDNSManager(kubeClient, operatorConfig, infraConfig, dnsConfig, installConfig)
-	if err != nil {
-		log.Error(err, "failed to create DNS manager")
-		os.Exit(1)
+	// Set up the DNS manager, which the operator rebuilds whenever the
+	// credentials or the cluster config from which it is built change.
+	dnsConfig := operator.DNSConfig{
+		NewManager: func() (dns.Manager, error) {
+			return loadDNSManager(kubeClient, operatorConfig)
+		},
+		SecretNames:              []string{cloudCredentialsSecretName, rfc2136SecretName, webhookSecretName},
+		ConfigMapNames:           []string{dnsBackendsConfigMapName},
+		SecretNamesFromConfigMap: dnsBackendSecretNames,
 	}
 
 	// Set up and start the operator.
-	op, err := operator.New(operatorConfig, dnsManager, kubeConfig)
+	op, err := operator.New(operatorConfig, dnsConfig, kubeConfig)
 	if err != nil {
 		log.Error(err, "failed to create operator")
 		os.Exit(1)
@@ -124,9 +153,67 @@ func main() {
 	}
 }
 
+// loadDNSManager retrieves the current cluster infrastructure, DNS, and install
+// configs and creates a DNS manager compatible with them.
+func loadDNSManager(cl client.Client, operatorConfig operatorconfig.Config) (dns.Manager, error) {
+	infraConfig := &configv1.Infrastructure{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "cluster"}, infraConfig); err != nil {
+		return nil, fmt.Errorf("failed to get infrastructure 'cluster': %v", err)
+	}
+
+	dnsConfig := &configv1.DNS{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "cluster"}, dnsConfig); err != nil {
+		return nil, fmt.Errorf("failed to get dns 'cluster': %v", err)
+	}
+
+	// TODO: This can be replaced by cluster API when
+	// https://github.com/openshift/installer/pull/1725 is available.
+	clusterConfig := &corev1.ConfigMap{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Namespace: "kube-system", Name: "cluster-config-v1"}, clusterConfig); err != nil {
+		return nil, fmt.Errorf("failed to get configmap 'kube-system/cluster-config-v1': %v", err)
+	}
+	installConfig, err := newInstallConfig(clusterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract install config from cluster config: %v", err)
+	}
+
+	return createDNSManager(cl, operatorConfig, infraConfig, dnsConfig, installConfig)
+}
+
 // createDNSManager creates a DNS manager compatible with the given cluster
-// configuration.
+// configuration. If the DNS backends configmap exists, the manager dispatches
+// records to the backends that it configures. Otherwise, if the webhook secret
+// exists, the webhook is used. Otherwise, the platform's DNS manager is used.
 func createDNSManager(cl client.Client, operatorConfig operatorconfig.Config, infraConfig *configv1.Infrastructure, dnsConfig *configv1.DNS, installConfig *installConfig) (dns.Manager, error) {
+	backendsConfig := &corev1.ConfigMap{}
+	err := cl.Get(context.TODO(), types.NamespacedName{Namespace: operatorConfig.Namespace, Name: dnsBackendsConfigMapName}, backendsConfig)
+	switch {
+	case err == nil:
+		log.Info("using dns backends config from configmap", "namespace", backendsConfig.Namespace, "name", backendsConfig.Name)
+		return createMultiplexedDNSManager(cl, backendsConfig, operatorConfig, infraConfig, dnsConfig, installConfig)
+	case !errors.IsNotFound(err):
+		return nil, fmt.Errorf("failed to get dns backends config from configmap %s/%s: %v", operatorConfig.Namespace, dnsBackendsConfigMapName, err)
+	}
+
+	webhookConfig := &corev1.Secret{}
+	err = cl.Get(context.TODO(), types.NamespacedName{Namespace: operatorConfig.Namespace, Name: webhookSecretName}, webhookConfig)
+	switch {
+	case err == nil:
+		log.Info("using dns webhook config from secret", "namespace", webhookConfig.Namespace, "name", webhookConfig.Name)
+		return createWebhookDNSManager(webhookConfig, operatorConfig, dnsConfig)
+	case !errors.IsNotFound(err):
+		return nil, fmt.Errorf("failed to get dns webhook config from secret %s/%s: %v", operatorConfig.Namespace, webhookSecretName, err)
+	}
+
+	return createPlatformDNSManager(cl, operatorConfig, infraConfig, dnsConfig, installConfig, operatorConfig.DNSRecordTTL)
+}
+
+// createPlatformDNSManager creates the DNS manager for the cluster's platform.
+// On platforms without a cloud DNS service, RFC 2136 dynamic updates are used
+// if configured, and DNS is not managed otherwise. If recordTTL is positive, it
+// is the TTL in seconds of the records on platforms whose DNS manager allows
+// the TTL to be configured.
+func createPlatformDNSManager(cl client.Client, operatorConfig operatorconfig.Config, infraConfig *configv1.Infrastructure, dnsConfig *configv1.DNS, installConfig *installConfig, recordTTL int64) (dns.Manager, error) {
 	var dnsManager dns.Manager
 	switch infraConfig.Status.Platform {
 	case configv1.AWSPlatformType:
@@ -137,15 +224,19 @@ func createDNSManager(cl client.Client, operatorConfig operatorconfig.Config, in
 		}
 		log.Info("using aws creds from secret", "namespace", awsCreds.Namespace, "name", awsCreds.Name)
 		manager, err := awsdns.NewManager(awsdns.Config{
-			AccessID:  string(awsCreds.Data["aws_access_key_id"]),
-			AccessKey: string(awsCreds.Data["aws_secret_access_key"]),
-			DNS:       dnsConfig,
-			Region:    installConfig.Platform.AWS.Region,
+			AccessID:              string(awsCreds.Data["aws_access_key_id"]),
+			AccessKey:             string(awsCreds.Data["aws_secret_access_key"]),
+			SessionToken:          string(awsCreds.Data["aws_session_token"]),
+			RoleARN:               string(awsCreds.Data["role_arn"]),
+			WebIdentityTokenFile:  string(awsCreds.Data["web_identity_token_file"]),
+			SharedCredentialsFile: awsCreds.Data["credentials"],
+			DNS:                   dnsConfig,
+			Region:                installConfig.Platform.AWS.Region,
 		}, operatorConfig.OperatorReleaseVersion)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create AWS DNS manager: %v", err)
 		}
-		dnsManager = manager
+		dnsManager = dnsmetrics.NewManager("aws", manager)
 	case configv1.AzurePlatformType:
 		azureCreds := &corev1.Secret{}
 		err := cl.Get(context.TODO(), types.NamespacedName{Namespace: operatorConfig.Namespace, Name: cloudCredentialsSecretName}, azureCreds)
@@ -154,23 +245,110 @@ func createDNSManager(cl client.Client, operatorConfig operatorconfig.Config, in
 		}
 		log.Info("using azure creds from secret", "namespace", azureCreds.Namespace, "name", azureCreds.Name)
 		manager, err := azuredns.NewManager(azuredns.Config{
-			Environment:    "AzurePublicCloud",
-			ClientID:       string(azureCreds.Data["azure_client_id"]),
-			ClientSecret:   string(azureCreds.Data["azure_client_secret"]),
-			TenantID:       string(azureCreds.Data["azure_tenant_id"]),
-			SubscriptionID: string(azureCreds.Data["azure_subscription_id"]),
-			DNS:            dnsConfig,
+			Environment:        installConfig.azureEnvironment(),
+			ClientID:           string(azureCreds.Data["azure_client_id"]),
+			ClientSecret:       string(azureCreds.Data["azure_client_secret"]),
+			TenantID:           string(azureCreds.Data["azure_tenant_id"]),
+			SubscriptionID:     string(azureCreds.Data["azure_subscription_id"]),
+			FederatedTokenFile: string(azureCreds.Data["azure_federated_token_file"]),
+			DNS:                dnsConfig,
+			TTL:                recordTTL,
 		}, operatorConfig.OperatorReleaseVersion)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create Azure DNS manager: %v", err)
 		}
-		dnsManager = manager
+		dnsManager = dnsmetrics.NewManager("azure", manager)
+	case configv1.GCPPlatformType:
+		gcpCreds := &corev1.Secret{}
+		err := cl.Get(context.TODO(), types.NamespacedName{Namespace: operatorConfig.Namespace, Name: cloudCredentialsSecretName}, gcpCreds)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get gcp creds from secret %s/%s: %v", gcpCreds.Namespace, gcpCreds.Name, err)
+		}
+		log.Info("using gcp creds from secret", "namespace", gcpCreds.Namespace, "name", gcpCreds.Name)
+		manager, err := gcpdns.NewManager(gcpdns.Config{
+			Project:         installConfig.Platform.GCP.ProjectID,
+			CredentialsJSON: gcpCreds.Data["service_account.json"],
+			DNS:             dnsConfig,
+		}, operatorConfig.OperatorReleaseVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCP DNS manager: %v", err)
+		}
+		dnsManager = dnsmetrics.NewManager("gcp", manager)
 	default:
-		dnsManager = &dns.NoopManager{}
+		rfc2136Config := &corev1.Secret{}
+		err := cl.Get(context.TODO(), types.NamespacedName{Namespace: operatorConfig.Namespace, Name: rfc2136SecretName}, rfc2136Config)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				dnsManager = &dns.NoopManager{}
+				break
+			}
+			return nil, fmt.Errorf("failed to get rfc2136 config from secret %s/%s: %v", operatorConfig.Namespace, rfc2136SecretName, err)
+		}
+		log.Info("using rfc2136 config from secret", "namespace", rfc2136Config.Namespace, "name", rfc2136Config.Name)
+		manager, err := createRFC2136DNSManager(rfc2136Config, dnsConfig)
+		if err != nil {
+			return nil, err
+		}
+		dnsManager = manager
 	}
 	return dnsManager, nil
 }
 
+// createWebhookDNSManager creates a webhook DNS manager from the given secret.
+func createWebhookDNSManager(secret *corev1.Secret, operatorConfig operatorconfig.Config, dnsConfig *configv1.DNS) (dns.Manager, error) {
+	manager, err := webhookdns.NewManager(webhookdns.Config{
+		URL:               string(secret.Data["url"]),
+		BearerToken:       string(secret.Data["token"]),
+		ClientCertificate: secret.Data["tls.crt"],
+		ClientKey:         secret.Data["tls.key"],
+		CABundle:          secret.Data["ca.crt"],
+		RecordTypes:       parseRecordTypes(string(secret.Data["record_types"])),
+		DNS:               dnsConfig,
+	}, operatorConfig.OperatorReleaseVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook DNS manager: %v", err)
+	}
+	return dnsmetrics.NewManager("webhook", manager), nil
+}
+
+// parseRecordTypes parses a comma-separated list of record types, such as
+// "A,CNAME,TXT".
+func parseRecordTypes(value string) []dns.RecordType {
+	var recordTypes []dns.RecordType
+	for _, t := range strings.Split(value, ",") {
+		if t = strings.ToUpper(strings.TrimSpace(t)); len(t) > 0 {
+			recordTypes = append(recordTypes, dns.RecordType(t))
+		}
+	}
+	return recordTypes
+}
+
+// createRFC2136DNSManager creates an RFC 2136 DNS manager from the given
+// secret.
+func createRFC2136DNSManager(secret *corev1.Secret, dnsConfig *configv1.DNS) (dns.Manager, error) {
+	var ttl uint64
+	if v, ok := secret.Data["ttl"]; ok {
+		var err error
+		ttl, err = strconv.ParseUint(string(v), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ttl in secret %s/%s: %v", secret.Namespace, secret.Name, err)
+		}
+	}
+	manager, err := rfc2136dns.NewManager(rfc2136dns.Config{
+		Nameserver:    string(secret.Data["nameserver"]),
+		Net:           string(secret.Data["net"]),
+		TSIGKeyName:   string(secret.Data["tsig_key_name"]),
+		TSIGSecret:    string(secret.Data["tsig_secret"]),
+		TSIGAlgorithm: string(secret.Data["tsig_algorithm"]),
+		TTL:           uint32(ttl),
+		DNS:           dnsConfig,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create RFC 2136 DNS manager: %v", err)
+	}
+	return dnsmetrics.NewManager("rfc2136", manager), nil
+}
+
 // TODO: This can be replaced by cluster API when
 // https://github.com/openshift/installer/pull/1725 is available.
 type installConfig struct {
@@ -178,9 +356,25 @@ type installConfig struct {
 		AWS struct {
 			Region string `json:"region"`
 		} `json:"aws"`
+		Azure struct {
+			CloudName string `json:"cloudName"`
+		} `json:"azure"`
+		GCP struct {
+			ProjectID string `json:"projectID"`
+		} `json:"gcp"`
 	} `json:"platform"`
 }
 
+// azureEnvironment returns the name of the cluster's azure cloud environment,
+// which is the public cloud unless the install config specifies another cloud,
+// such as "AzureUSGovernmentCloud".
+func (ic *installConfig) azureEnvironment() string {
+	if len(ic.Platform.Azure.CloudName) == 0 {
+		return "AzurePublicCloud"
+	}
+	return ic.Platform.Azure.CloudName
+}
+
 func newInstallConfig(clusterConfig *corev1.ConfigMap) (*installConfig, error) {
 	data, ok := clusterConfig.Data["install-config"]
 	if !ok {
@@ -10,6 +10,7 @@ oc scale --replicas 0 -n openshift-cluster-version deployments/cluster-version-o
 oc delete -n openshift-ingress-operator deployments/ingress-operator
 oc patch -n openshift-ingress-operator ingresscontroller/default --patch '{"metadata":{"finalizers": []}}' --type=merge
 # TODO: this leaves DNS dangling
+oc patch -n openshift-ingress-operator dnsrecords/default-wildcard --patch '{"metadata":{"finalizers": []}}' --type=merge
 oc patch -n openshift-ingress services/router-default --patch '{"metadata":{"finalizers": []}}' --type=merge
 oc delete clusteroperator.config.openshift.io/ingress
 oc delete --force --grace-period=0 -n openshift-ingress-operator ingresscontroller/default
@@ -31,6 +32,7 @@ if [ "$WHAT" == "all" ]; then
   oc delete clusterrolebindings/openshift-ingress-router
   oc delete clusterrolebindings/router-monitoring
   oc delete customresourcedefinition.apiextensions.k8s.io/ingresscontrollers.operator.openshift.io
+  oc delete customresourcedefinition.apiextensions.k8s.io/dnsrecords.ingress.operator.openshift.io
 fi
 
 oc delete -n openshift-config-managed configmaps/router-ca
@@ -0,0 +1,7 @@
+// Package v1 contains API types that are internal to the ingress operator.
+//
+// +k8s:deepcopy-gen=package,register
+// +k8s:openapi-gen=true
+//
+// +groupName=ingress.operator.openshift.io
+package v1
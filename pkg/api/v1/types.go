@@ -0,0 +1,224 @@
+package v1
+
+import (
+	configv1 "github.com/openshift/api/config/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=dnsrecords
+
+// DNSRecord is the set of DNS records that the operator publishes on behalf of
+// an IngressController.
+//
+// The operator creates one DNSRecord for each IngressController whose endpoint
+// publishing strategy calls for DNS records, plus several for the hosts of its
+// routes if it publishes a record per route, and the DNSRecords are owned by
+// that IngressController. The DNS controller publishes the records in spec to
+// their zones, reports the outcome for each zone in status, and deletes every
+// record that it has published when the records are removed from spec or when
+// the DNSRecord is deleted.
+type DNSRecord struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// spec is the specification of the desired behavior of the DNSRecord.
+	Spec DNSRecordSpec `json:"spec,omitempty"`
+	// status is the most recently observed status of the DNSRecord.
+	Status DNSRecordStatus `json:"status,omitempty"`
+}
+
+// DNSRecordSpec contains the details of the desired DNS records.
+type DNSRecordSpec struct {
+	// records is the desired set of DNS records.
+	//
+	// +optional
+	Records []Record `json:"records,omitempty"`
+}
+
+// Record is a DNS record in a single zone.
+type Record struct {
+	// zone is the DNS zone in which the record is published.
+	Zone configv1.DNSZone `json:"zone"`
+
+	// type is the DNS record type.
+	Type RecordType `json:"type"`
+
+	// domain is the record name.
+	Domain string `json:"domain"`
+
+	// target is the mapped destination of domain. For an ALIAS or CNAME
+	// record, this is a hostname; for an A record, this is an IPv4 address;
+	// for an AAAA record, this is an IPv6 address.
+	Target string `json:"target"`
+
+	// routingPolicy, if set, publishes the record as one of several records
+	// with the same domain and type, such as the records that several
+	// clusters publish for a shared domain, and determines how the DNS
+	// provider chooses among them. Routing policies are only supported on
+	// AWS.
+	//
+	// +optional
+	RoutingPolicy *RoutingPolicy `json:"routingPolicy,omitempty"`
+}
+
+// RoutingPolicy determines how the DNS provider answers queries for a domain
+// that has several records of the same type.
+type RoutingPolicy struct {
+	// type is the kind of routing policy.
+	Type RoutingPolicyType `json:"type"`
+
+	// setIdentifier distinguishes the record from the other records with
+	// the same domain and type.
+	SetIdentifier string `json:"setIdentifier"`
+
+	// weight is the relative weight of a Weighted record, from 0 to 255.
+	//
+	// +optional
+	Weight int64 `json:"weight,omitempty"`
+
+	// failover is the role of a Failover record, which is Primary or
+	// Secondary.
+	//
+	// +optional
+	Failover FailoverRole `json:"failover,omitempty"`
+
+	// region is the region of the target of a Latency record.
+	//
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// healthCheck, if set, is a health check of the record's target. The
+	// record is only used to answer queries while the target is healthy.
+	//
+	// +optional
+	HealthCheck *HealthCheck `json:"healthCheck,omitempty"`
+}
+
+// RoutingPolicyType is a kind of routing policy.
+type RoutingPolicyType string
+
+const (
+	// WeightedRoutingPolicy answers queries with each record in
+	// proportion to its weight.
+	WeightedRoutingPolicy RoutingPolicyType = "Weighted"
+
+	// FailoverRoutingPolicy answers queries with the Primary record while
+	// it is healthy, and with the Secondary record otherwise.
+	FailoverRoutingPolicy RoutingPolicyType = "Failover"
+
+	// LatencyRoutingPolicy answers queries with the record whose region
+	// has the lowest latency to the client.
+	LatencyRoutingPolicy RoutingPolicyType = "Latency"
+)
+
+// FailoverRole is the role of a record with the Failover routing policy.
+type FailoverRole string
+
+const (
+	// PrimaryFailoverRole is the role of the record that answers queries
+	// while it is healthy.
+	PrimaryFailoverRole FailoverRole = "Primary"
+
+	// SecondaryFailoverRole is the role of the record that answers
+	// queries while the primary record is unhealthy.
+	SecondaryFailoverRole FailoverRole = "Secondary"
+)
+
+// HealthCheck is a health check of a record's target.
+type HealthCheck struct {
+	// port is the port on the target to which the health check connects.
+	Port int64 `json:"port"`
+
+	// path, if set, is the path that an HTTP health check requests. If
+	// empty, the health check only opens a TCP connection to the port.
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// RecordType is a DNS record type.
+type RecordType string
+
+const (
+	// ALIASRecordType is a DNS ALIAS record.
+	ALIASRecordType RecordType = "ALIAS"
+
+	// ARecordType is a DNS A record.
+	ARecordType RecordType = "A"
+
+	// AAAARecordType is a DNS AAAA record.
+	AAAARecordType RecordType = "AAAA"
+
+	// CNAMERecordType is a DNS CNAME record.
+	CNAMERecordType RecordType = "CNAME"
+)
+
+// DNSRecordStatus is the most recently observed status of each record.
+type DNSRecordStatus struct {
+	// observedGeneration is the most recently observed generation of the
+	// DNSRecord.
+	//
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// publishedRecords is the set of records that the operator has published
+	// and not yet deleted. When a record is removed from spec, or when the
+	// DNSRecord is deleted, the operator deletes the corresponding published
+	// record.
+	//
+	// +optional
+	PublishedRecords []Record `json:"publishedRecords,omitempty"`
+
+	// zones is the status of the records in each zone.
+	//
+	// +optional
+	Zones []DNSZoneStatus `json:"zones,omitempty"`
+}
+
+// DNSZoneStatus is the status of the records in a single zone.
+type DNSZoneStatus struct {
+	// dnsZone is the zone to which the status applies.
+	DNSZone configv1.DNSZone `json:"dnsZone"`
+
+	// conditions are any conditions associated with the records in the
+	// zone.
+	//
+	// +optional
+	Conditions []DNSZoneCondition `json:"conditions,omitempty"`
+}
+
+var (
+	// DNSRecordFailedConditionType indicates that publishing one or more
+	// records to a zone failed.
+	DNSRecordFailedConditionType = "Failed"
+
+	// DNSRecordPendingConditionType indicates that one or more records
+	// have not yet propagated to all of a zone's name servers. The
+	// condition is only reported for zones whose DNS provider tracks
+	// propagation.
+	DNSRecordPendingConditionType = "Pending"
+)
+
+// DNSZoneCondition is just the standard condition fields.
+type DNSZoneCondition struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DNSRecordList contains a list of DNSRecord.
+type DNSRecordList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DNSRecord `json:"items"`
+}
@@ -0,0 +1,157 @@
+package aws
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+const (
+	// roleSessionName identifies the operator in the sessions of the roles
+	// that it assumes.
+	roleSessionName = "openshift-ingress-operator"
+
+	// credentialsExpiryWindow is how long before temporary credentials
+	// expire that they are refreshed.
+	credentialsExpiryWindow = 5 * time.Minute
+
+	// defaultProfile is the profile of a shared credentials or config file
+	// that the manager uses.
+	defaultProfile = "default"
+)
+
+// newCredentials returns the credentials described by config for clients
+// created from sess. Temporary credentials obtained from STS are refreshed
+// automatically before they expire. If config describes no credentials, the
+// SDK's default credential chain is used.
+func newCredentials(config Config, sess *session.Session, region string) (*credentials.Credentials, error) {
+	if len(config.SharedCredentialsFile) > 0 {
+		profile, err := parseSharedCredentialsFile(config.SharedCredentialsFile, defaultProfile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse shared credentials file: %v", err)
+		}
+		// Explicitly configured values take precedence over the file.
+		if len(config.AccessID) == 0 && len(config.AccessKey) == 0 {
+			config.AccessID = profile["aws_access_key_id"]
+			config.AccessKey = profile["aws_secret_access_key"]
+			config.SessionToken = profile["aws_session_token"]
+		}
+		if len(config.RoleARN) == 0 {
+			config.RoleARN = profile["role_arn"]
+		}
+		if len(config.WebIdentityTokenFile) == 0 {
+			config.WebIdentityTokenFile = profile["web_identity_token_file"]
+		}
+	}
+
+	stsConfig := aws.NewConfig().WithRegion(region)
+	if len(config.WebIdentityTokenFile) > 0 {
+		if len(config.RoleARN) == 0 {
+			return nil, fmt.Errorf("a role ARN is required with a web identity token file")
+		}
+		log.Info("using web identity credentials", "role", config.RoleARN, "token file", config.WebIdentityTokenFile)
+		return credentials.NewCredentials(&webIdentityProvider{
+			client:    sts.New(sess, stsConfig),
+			roleARN:   config.RoleARN,
+			tokenFile: config.WebIdentityTokenFile,
+		}), nil
+	}
+
+	var creds *credentials.Credentials
+	if len(config.AccessID) > 0 || len(config.AccessKey) > 0 {
+		creds = credentials.NewStaticCredentials(config.AccessID, config.AccessKey, config.SessionToken)
+	}
+	if len(config.RoleARN) == 0 {
+		return creds, nil
+	}
+	log.Info("using assumed role credentials", "role", config.RoleARN)
+	if creds != nil {
+		stsConfig = stsConfig.WithCredentials(creds)
+	}
+	return stscreds.NewCredentialsWithClient(sts.New(sess, stsConfig), config.RoleARN, func(p *stscreds.AssumeRoleProvider) {
+		p.RoleSessionName = roleSessionName
+		p.ExpiryWindow = credentialsExpiryWindow
+	}), nil
+}
+
+// webIdentityRoleAssumer is the part of the STS API that webIdentityProvider
+// uses.
+type webIdentityRoleAssumer interface {
+	AssumeRoleWithWebIdentity(*sts.AssumeRoleWithWebIdentityInput) (*sts.AssumeRoleWithWebIdentityOutput, error)
+}
+
+// webIdentityProvider is a credentials.Provider that assumes a role with a web
+// identity token, such as a projected service account token, which it reads
+// from a file every time it retrieves credentials so that rotated tokens are
+// picked up.
+type webIdentityProvider struct {
+	credentials.Expiry
+
+	client    webIdentityRoleAssumer
+	roleARN   string
+	tokenFile string
+}
+
+func (p *webIdentityProvider) Retrieve() (credentials.Value, error) {
+	token, err := ioutil.ReadFile(p.tokenFile)
+	if err != nil {
+		return credentials.Value{}, fmt.Errorf("failed to read web identity token file %s: %v", p.tokenFile, err)
+	}
+	resp, err := p.client.AssumeRoleWithWebIdentity(&sts.AssumeRoleWithWebIdentityInput{
+		RoleArn:          aws.String(p.roleARN),
+		RoleSessionName:  aws.String(roleSessionName),
+		WebIdentityToken: aws.String(strings.TrimSpace(string(token))),
+	})
+	if err != nil {
+		return credentials.Value{}, fmt.Errorf("failed to assume role %s with web identity: %v", p.roleARN, err)
+	}
+	p.SetExpiration(aws.TimeValue(resp.Credentials.Expiration), credentialsExpiryWindow)
+	return credentials.Value{
+		AccessKeyID:     aws.StringValue(resp.Credentials.AccessKeyId),
+		SecretAccessKey: aws.StringValue(resp.Credentials.SecretAccessKey),
+		SessionToken:    aws.StringValue(resp.Credentials.SessionToken),
+		ProviderName:    "WebIdentityProvider",
+	}, nil
+}
+
+// parseSharedCredentialsFile returns the keys and values of the given profile
+// in an AWS shared credentials or config file. A profile is a section named
+// after the profile, or "profile " followed by the profile name as in a config
+// file. Returns an error if the file has no such profile.
+func parseSharedCredentialsFile(data []byte, profile string) (map[string]string, error) {
+	values := map[string]string{}
+	found, inProfile := false, false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case len(line) == 0 || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			name := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line[1:len(line)-1]), "profile "))
+			inProfile = name == profile
+			found = found || inProfile
+		case inProfile:
+			kv := strings.SplitN(line, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("invalid line %d in profile %s", n, profile)
+			}
+			values[strings.ToLower(strings.TrimSpace(kv[0]))] = strings.TrimSpace(kv[1])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("profile %s not found", profile)
+	}
+	return values, nil
+}
@@ -0,0 +1,194 @@
+package aws
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+func TestParseSharedCredentialsFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		expect map[string]string
+		err    bool
+	}{
+		{
+			name: "credentials file",
+			data: `# comment
+[other]
+aws_access_key_id = other
+
+[default]
+aws_access_key_id = id
+AWS_Secret_Access_Key=key
+`,
+			expect: map[string]string{"aws_access_key_id": "id", "aws_secret_access_key": "key"},
+		},
+		{
+			name: "config file",
+			data: `[profile default]
+role_arn = arn:aws:iam::123456789012:role/ingress
+web_identity_token_file = /var/run/secrets/openshift/serviceaccount/token
+`,
+			expect: map[string]string{
+				"role_arn":                "arn:aws:iam::123456789012:role/ingress",
+				"web_identity_token_file": "/var/run/secrets/openshift/serviceaccount/token",
+			},
+		},
+		{
+			name: "missing profile",
+			data: "[other]\naws_access_key_id = id\n",
+			err:  true,
+		},
+		{
+			name: "invalid line",
+			data: "[default]\naws_access_key_id\n",
+			err:  true,
+		},
+	}
+	for _, test := range tests {
+		values, err := parseSharedCredentialsFile([]byte(test.data), defaultProfile)
+		switch {
+		case test.err && err == nil:
+			t.Errorf("%s: expected an error", test.name)
+		case !test.err && err != nil:
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		case !test.err && !reflect.DeepEqual(values, test.expect):
+			t.Errorf("%s: expected %v, got %v", test.name, test.expect, values)
+		}
+	}
+}
+
+func TestNewCredentials(t *testing.T) {
+	sess, err := session.NewSession(aws.NewConfig().WithRegion("us-east-1"))
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		config   Config
+		provider string
+		err      bool
+	}{
+		{
+			name:     "no credentials",
+			config:   Config{},
+			provider: "",
+		},
+		{
+			name:     "static credentials",
+			config:   Config{AccessID: "id", AccessKey: "key"},
+			provider: credentials.StaticProviderName,
+		},
+		{
+			name:     "static credentials from shared credentials file",
+			config:   Config{SharedCredentialsFile: []byte("[default]\naws_access_key_id = id\naws_secret_access_key = key\n")},
+			provider: credentials.StaticProviderName,
+		},
+		{
+			name:   "web identity without role",
+			config: Config{WebIdentityTokenFile: "/token"},
+			err:    true,
+		},
+		{
+			name:   "invalid shared credentials file",
+			config: Config{SharedCredentialsFile: []byte("[other]\n")},
+			err:    true,
+		},
+	}
+	for _, test := range tests {
+		creds, err := newCredentials(test.config, sess, "us-east-1")
+		switch {
+		case test.err:
+			if err == nil {
+				t.Errorf("%s: expected an error", test.name)
+			}
+			continue
+		case err != nil:
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if len(test.provider) == 0 {
+			if creds != nil {
+				t.Errorf("%s: expected the default credential chain, got %v", test.name, creds)
+			}
+			continue
+		}
+		value, err := creds.Get()
+		if err != nil {
+			t.Errorf("%s: failed to get credentials: %v", test.name, err)
+			continue
+		}
+		if value.ProviderName != test.provider {
+			t.Errorf("%s: expected provider %s, got %s", test.name, test.provider, value.ProviderName)
+		}
+	}
+}
+
+// fakeRoleAssumer records the web identity tokens with which it is asked to
+// assume a role.
+type fakeRoleAssumer struct {
+	tokens []string
+}
+
+func (f *fakeRoleAssumer) AssumeRoleWithWebIdentity(input *sts.AssumeRoleWithWebIdentityInput) (*sts.AssumeRoleWithWebIdentityOutput, error) {
+	f.tokens = append(f.tokens, aws.StringValue(input.WebIdentityToken))
+	return &sts.AssumeRoleWithWebIdentityOutput{
+		Credentials: &sts.Credentials{
+			AccessKeyId:     aws.String("id"),
+			SecretAccessKey: aws.String("key"),
+			SessionToken:    aws.String("session"),
+			// The credentials expire within the expiry window, so
+			// they are refreshed on every use.
+			Expiration: aws.Time(time.Now().Add(time.Minute)),
+		},
+	}, nil
+}
+
+func TestWebIdentityProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "web-identity")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+
+	client := &fakeRoleAssumer{}
+	creds := credentials.NewCredentials(&webIdentityProvider{
+		client:    client,
+		roleARN:   "arn:aws:iam::123456789012:role/ingress",
+		tokenFile: tokenFile,
+	})
+
+	if _, err := creds.Get(); err == nil {
+		t.Errorf("expected an error for a missing token file")
+	}
+
+	// The token file is read again when the credentials are refreshed, so
+	// that rotated tokens are used.
+	for _, token := range []string{"token-1", "token-2"} {
+		if err := ioutil.WriteFile(tokenFile, []byte(token+"\n"), 0600); err != nil {
+			t.Fatalf("failed to write token file: %v", err)
+		}
+		value, err := creds.Get()
+		if err != nil {
+			t.Fatalf("failed to get credentials: %v", err)
+		}
+		if value.SessionToken != "session" {
+			t.Errorf("expected session token %q, got %q", "session", value.SessionToken)
+		}
+	}
+	if expect := []string{"token-1", "token-2"}; !reflect.DeepEqual(client.tokens, expect) {
+		t.Errorf("expected tokens %v, got %v", expect, client.tokens)
+	}
+}
@@ -2,9 +2,12 @@ package aws
 
 import (
 	"fmt"
+	"net"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/openshift/cluster-ingress-operator/pkg/dns"
 	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
@@ -12,35 +15,72 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/arn"
 	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
 	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
 	"github.com/aws/aws-sdk-go/service/route53"
 
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
-	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/client-go/util/flowcontrol"
 
 	configv1 "github.com/openshift/api/config/v1"
 )
 
 var (
-	_   dns.Manager = &Manager{}
-	log             = logf.Logger.WithName("dns")
+	_   dns.BatchManager         = &Manager{}
+	_   dns.PropagationTracker   = &Manager{}
+	_   dns.CredentialsValidator = &Manager{}
+	log                          = logf.Logger.WithName("dns")
+)
+
+// updatedRecordTTL is how long a record is considered up to date after it has
+// been created or updated.
+const updatedRecordTTL = 30 * time.Minute
+
+// recordTTL is the TTL, in seconds, of the AAAA and TXT records created by the
+// manager.
+const recordTTL int64 = 300
+
+// route53QPS and route53Burst limit the rate of the operator's requests to
+// Route53, which throttles more than five requests per second from an account.
+// The limit is shared by every manager, and it leaves some of the account's
+// quota for others, such as the installer and other clusters.
+const (
+	route53QPS   = 3
+	route53Burst = 5
 )
 
+// route53RateLimiter limits the rate of requests to Route53 of every manager.
+var route53RateLimiter = flowcontrol.NewTokenBucketRateLimiter(route53QPS, route53Burst)
+
+// maxResourceRecordsPerChangeSet is the largest number of resource records that
+// a batch submits in one change set. Route53 accepts at most 1000 resource
+// records in a change set and counts those of an update twice, so larger
+// batches, such as the records for every route of a large cluster, are split.
+const maxResourceRecordsPerChangeSet = 1000
+
 // Manager provides AWS DNS record management. In this implementation, calling
 // Ensure will create records in any zone specified in the DNS configuration.
-//
-// TODO: Records are considered owned by the manager if they exist in a managed
-// zone and if their names match expectations. This is relatively dangerous
-// compared to storing additional metadata (like tags or TXT records).
+// Alias records, AAAA records, and the TXT records that identify their owners
+// are supported, and AAAA records with the same name are published as one
+// resource record set with all of their addresses. Weighted, failover, and
+// latency routing policies are supported, with a Route53 health check of the
+// target for each record that asks for one. A batch created by NewBatch submits
+// its changes to a zone in as few change sets as Route53 allows, and Pending
+// reports whether the change set that last updated a record has propagated.
 type Manager struct {
 	elb     *elb.ELB
+	elbv2   *elbv2.ELBV2
 	route53 *route53.Route53
 	tags    *resourcegroupstaggingapi.ResourceGroupsTaggingAPI
 
+	// partition is the ID of the AWS partition of the configured region.
+	partition string
+
 	config Config
 
 	// lock protects access to everything below.
@@ -51,14 +91,23 @@ type Manager struct {
 	// equal if their maps are reflect.DeepEqual.
 	idsToTags map[string]map[string]string
 
-	// lbZones is a cache of load balancer DNS names to LB hosted zone IDs.
+	// lbZones is a cache of load balancer DNS names to LB hosted zone IDs,
+	// for both classic load balancers and network load balancers.
 	lbZones map[string]string
 
 	// updatedRecords is a cache of records which have been created or updated
-	// during the life of this manager. The key is zoneID+domain+target. This is a
-	// quick hack to minimize AWS API calls, and also prevent changes to existing
-	// records (something not yet supported).
-	updatedRecords sets.String
+	// recently, mapped to the time of the update. The key is returned by
+	// recordKey. Neither Ensure nor Get calls AWS for a record with an
+	// entry, so drift is only detected once the entry expires after
+	// updatedRecordTTL. An entry is removed as soon as Get finds that the
+	// record has drifted, so that the next Ensure repairs it. This
+	// minimizes AWS API calls.
+	updatedRecords map[string]time.Time
+
+	// pendingChanges maps the key of a record that has been upserted, in
+	// the same form as the keys of updatedRecords, to the ID of the change
+	// set that upserted it, until the change set has propagated.
+	pendingChanges map[string]string
 }
 
 // Config is the necessary input to configure the manager.
@@ -67,18 +116,33 @@ type Config struct {
 	AccessID string
 	// AccessKey is an AWS credential.
 	AccessKey string
+	// SessionToken is the session token of temporary AccessID and
+	// AccessKey credentials.
+	SessionToken string
+	// RoleARN, if not empty, is the ARN of a role that the manager assumes
+	// through STS, either with the other credentials or with the token in
+	// WebIdentityTokenFile.
+	RoleARN string
+	// WebIdentityTokenFile, if not empty, is the path to a file with a web
+	// identity token, such as a projected service account token, with which
+	// the manager assumes RoleARN.
+	WebIdentityTokenFile string
+	// SharedCredentialsFile, if not empty, is the content of an AWS shared
+	// credentials or config file whose default profile provides any
+	// credentials, role ARN, or web identity token file that are not set
+	// in the other fields.
+	SharedCredentialsFile []byte
 	// Region is the AWS region ELBs are created in.
 	Region string
 	// DNS is public and private DNS zone configuration for the cluster.
 	DNS *configv1.DNS
 }
 
+// NewManager returns a new AWS DNS manager. The manager uses the credentials in
+// config, if any, and the SDK's default credential chain otherwise. Temporary
+// credentials are refreshed automatically.
 func NewManager(config Config, operatorReleaseVersion string) (*Manager, error) {
-	creds := credentials.NewStaticCredentials(config.AccessID, config.AccessKey, "")
 	sess, err := session.NewSessionWithOptions(session.Options{
-		Config: aws.Config{
-			Credentials: creds,
-		},
 		SharedConfigState: session.SharedConfigEnable,
 	})
 	if err != nil {
@@ -100,19 +164,99 @@ func NewManager(config Config, operatorReleaseVersion string) (*Manager, error)
 		return nil, fmt.Errorf("region is required")
 	}
 
+	partition, err := partitionFor(region)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := newCredentials(config, sess, region)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create AWS credentials: %v", err)
+	}
+	if creds != nil {
+		sess = sess.Copy(aws.NewConfig().WithCredentials(creds))
+	}
+	log.Info("using partition", "partition", partition.id, "route53 region", partition.route53Region)
+	route53Config := aws.NewConfig().WithRegion(partition.route53Region)
+	if len(partition.route53Endpoint) > 0 {
+		route53Config = route53Config.WithEndpoint(partition.route53Endpoint)
+	}
+
+	route53Client := route53.New(sess, route53Config)
+	route53Client.Handlers.Send.PushFront(func(*request.Request) {
+		route53RateLimiter.Accept()
+	})
+
 	return &Manager{
 		elb:     elb.New(sess, aws.NewConfig().WithRegion(region)),
-		route53: route53.New(sess),
-		// TODO: This API will only return hostedzone resources (which are global)
-		// when the region is forced to us-east-1. We don't yet understand why.
-		tags:           resourcegroupstaggingapi.New(sess, aws.NewConfig().WithRegion("us-east-1")),
+		elbv2:   elbv2.New(sess, aws.NewConfig().WithRegion(region)),
+		route53: route53Client,
+		// Hosted zones are global resources, which the tagging API only
+		// returns in the region where Route53 keeps them.
+		tags:           resourcegroupstaggingapi.New(sess, aws.NewConfig().WithRegion(partition.route53Region)),
+		partition:      partition.id,
 		config:         config,
 		idsToTags:      map[string]map[string]string{},
 		lbZones:        map[string]string{},
-		updatedRecords: sets.NewString(),
+		updatedRecords: map[string]time.Time{},
+		pendingChanges: map[string]string{},
 	}, nil
 }
 
+// ValidateCredentials lists at most one hosted zone to check that Route53
+// accepts the manager's credentials.
+func (m *Manager) ValidateCredentials() error {
+	if _, err := m.route53.ListHostedZones(&route53.ListHostedZonesInput{MaxItems: aws.String("1")}); err != nil {
+		return fmt.Errorf("failed to list hosted zones: %v", err)
+	}
+	return nil
+}
+
+// partition describes where the global Route53 service of an AWS partition
+// lives.
+type partition struct {
+	// id is the ID of the partition, such as "aws" or "aws-cn".
+	id string
+	// route53Region is the region in which Route53 requests are signed and
+	// in which the tagging API returns hosted zones.
+	route53Region string
+	// route53Endpoint, if not empty, overrides the Route53 endpoint that
+	// the SDK resolves for the partition.
+	route53Endpoint string
+}
+
+// partitions maps the ID of each supported partition to its Route53 details.
+// The SDK doesn't know the Route53 endpoints outside of the commercial
+// partition, so they are set explicitly.
+var partitions = map[string]partition{
+	endpoints.AwsPartitionID: {
+		id:            endpoints.AwsPartitionID,
+		route53Region: endpoints.UsEast1RegionID,
+	},
+	endpoints.AwsUsGovPartitionID: {
+		id:              endpoints.AwsUsGovPartitionID,
+		route53Region:   endpoints.UsGovWest1RegionID,
+		route53Endpoint: "https://route53.us-gov.amazonaws.com",
+	},
+	endpoints.AwsCnPartitionID: {
+		id:              endpoints.AwsCnPartitionID,
+		route53Region:   endpoints.CnNorthwest1RegionID,
+		route53Endpoint: "https://route53.amazonaws.com.cn",
+	},
+}
+
+// partitionFor returns the partition of the given region. Returns an error if
+// the region doesn't belong to a supported partition.
+func partitionFor(region string) (partition, error) {
+	p, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), region)
+	if !ok {
+		return partition{}, fmt.Errorf("no AWS partition found for region %q", region)
+	}
+	if supported, ok := partitions[p.ID()]; ok {
+		return supported, nil
+	}
+	return partition{}, fmt.Errorf("unsupported AWS partition %q for region %q", p.ID(), region)
+}
+
 // getZoneID finds the ID of given zoneConfig in Route53. If an ID is already
 // known, return that; otherwise, use tags to search for the zone. Returns an
 // error if the zone can't be found.
@@ -148,6 +292,10 @@ func (m *Manager) getZoneID(zoneConfig configv1.DNSZone) (string, error) {
 				innerError = fmt.Errorf("failed to parse hostedzone ARN %q: %v", aws.StringValue(zone.ResourceARN), err)
 				return false
 			}
+			if zoneARN.Partition != m.partition || zoneARN.Service != "route53" {
+				innerError = fmt.Errorf("got unexpected resource ARN: %v", zoneARN)
+				return false
+			}
 			elems := strings.Split(zoneARN.Resource, "/")
 			if len(elems) != 2 || elems[0] != "hostedzone" {
 				innerError = fmt.Errorf("got unexpected resource ARN: %v", zoneARN)
@@ -183,8 +331,9 @@ func (m *Manager) getZoneID(zoneConfig configv1.DNSZone) (string, error) {
 	return id, nil
 }
 
-// getLBHostedZone finds the hosted zone ID of an ELB whose DNS name matches the
-// name parameter. Results are cached.
+// getLBHostedZone finds the hosted zone ID of a classic ELB or of an ELBv2
+// load balancer, such as an NLB, whose DNS name matches the name parameter.
+// Results are cached.
 func (m *Manager) getLBHostedZone(name string) (string, error) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -193,6 +342,27 @@ func (m *Manager) getLBHostedZone(name string) (string, error) {
 		return id, nil
 	}
 
+	id, err := m.getClassicLBHostedZone(name)
+	if err != nil {
+		return "", err
+	}
+	if len(id) == 0 {
+		id, err = m.getV2LBHostedZone(name)
+		if err != nil {
+			return "", err
+		}
+	}
+	if len(id) == 0 {
+		return "", fmt.Errorf("couldn't find hosted zone ID of ELB %s", name)
+	}
+	log.Info("associating load balancer with hosted zone", "dns name", name, "zone", id)
+	m.lbZones[name] = id
+	return id, nil
+}
+
+// getClassicLBHostedZone returns the hosted zone ID of the classic ELB whose
+// DNS name is name, or the empty string if there is no such ELB.
+func (m *Manager) getClassicLBHostedZone(name string) (string, error) {
 	var id string
 	fn := func(resp *elb.DescribeLoadBalancersOutput, lastPage bool) (shouldContinue bool) {
 		for _, lb := range resp.LoadBalancerDescriptions {
@@ -208,11 +378,28 @@ func (m *Manager) getLBHostedZone(name string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to describe load balancers: %v", err)
 	}
-	if len(id) == 0 {
-		return "", fmt.Errorf("couldn't find hosted zone ID of ELB %s", name)
+	return id, nil
+}
+
+// getV2LBHostedZone returns the hosted zone ID of the ELBv2 load balancer
+// whose DNS name is name, or the empty string if there is no such load
+// balancer.
+func (m *Manager) getV2LBHostedZone(name string) (string, error) {
+	var id string
+	fn := func(resp *elbv2.DescribeLoadBalancersOutput, lastPage bool) (shouldContinue bool) {
+		for _, lb := range resp.LoadBalancers {
+			log.V(0).Info("found load balancer", "name", aws.StringValue(lb.LoadBalancerName), "type", aws.StringValue(lb.Type), "dns name", aws.StringValue(lb.DNSName), "hosted zone ID", aws.StringValue(lb.CanonicalHostedZoneId))
+			if strings.EqualFold(aws.StringValue(lb.DNSName), name) {
+				id = aws.StringValue(lb.CanonicalHostedZoneId)
+				return false
+			}
+		}
+		return true
+	}
+	err := m.elbv2.DescribeLoadBalancersPages(&elbv2.DescribeLoadBalancersInput{}, fn)
+	if err != nil {
+		return "", fmt.Errorf("failed to describe v2 load balancers: %v", err)
 	}
-	log.Info("associating load balancer with hosted zone", "dns name", name, "zone", id)
-	m.lbZones[name] = id
 	return id, nil
 }
 
@@ -223,101 +410,917 @@ const (
 	deleteAction action = "DELETE"
 )
 
+// change is a change to a resource record set in a hosted zone.
+type change struct {
+	// record is the record that is changed.
+	record *dns.Record
+	// records are the records whose changes the change makes, which
+	// include those of the changes that it replaced in a batch.
+	records []*dns.Record
+	// zoneID is the ID of the hosted zone of the record.
+	zoneID string
+	// key identifies the record in the caches of updated records and
+	// pending changes.
+	key string
+	// action is what to do with the resource record set.
+	action action
+	// rrset is the resource record set that represents the record.
+	rrset *route53.ResourceRecordSet
+	// createdHealthCheckID is the ID of the health check that was created
+	// for the change, which is deleted if the change fails.
+	createdHealthCheckID string
+	// obsoleteHealthCheckID is the ID of the health check that the
+	// resource record set no longer uses once the change succeeds, which is
+	// deleted then.
+	obsoleteHealthCheckID string
+	// removesRecord is true if the change updates a resource record set to
+	// remove the record's address while keeping the other addresses.
+	removesRecord bool
+}
+
+// Ensure publishes record through a batch, so that the address of an AAAA
+// record is added to the addresses of the other AAAA records with the same
+// name.
 func (m *Manager) Ensure(record *dns.Record) error {
-	return m.change(record, upsertAction)
+	b := m.NewBatch()
+	if err := b.Ensure(record); err != nil {
+		return err
+	}
+	if errs := b.Commit(); len(errs) != 0 {
+		return errs[0].Err
+	}
+	return nil
 }
 
+// Delete deletes record through a batch, so that the resource record set is
+// deleted as it currently is, along with its health check.
 func (m *Manager) Delete(record *dns.Record) error {
-	return m.change(record, deleteAction)
+	b := m.NewBatch()
+	if err := b.Delete(record); err != nil {
+		return err
+	}
+	if errs := b.Commit(); len(errs) != 0 {
+		return errs[0].Err
+	}
+	return nil
 }
 
-// change will perform an action on a record. The target must correspond to the
-// hostname of an ELB which will be automatically discovered.
-func (m *Manager) change(record *dns.Record, action action) error {
-	if record.Type != dns.ALIASRecord {
-		return fmt.Errorf("unsupported record type %s", record.Type)
+// SupportsRecordType returns true for the record types that newChange
+// supports.
+func (m *Manager) SupportsRecordType(zone configv1.DNSZone, recordType dns.RecordType) bool {
+	switch recordType {
+	case dns.ALIASRecord, dns.AAAARecordType, dns.TXTRecordType:
+		return true
 	}
-	alias := record.Alias
-	if alias == nil {
-		return fmt.Errorf("missing alias record")
+	return false
+}
+
+// recordTarget returns the domain and target of record, where the target is
+// the hostname of an alias record, the address of an AAAA record, or the text
+// of a TXT record.
+func recordTarget(record *dns.Record) (string, string, error) {
+	switch record.Type {
+	case dns.ALIASRecord:
+		if record.Alias == nil {
+			return "", "", fmt.Errorf("missing alias record")
+		}
+		return record.Alias.Domain, record.Alias.Target, nil
+	case dns.AAAARecordType:
+		if record.AAAARecord == nil {
+			return "", "", fmt.Errorf("missing AAAA record")
+		}
+		return record.AAAARecord.Domain, record.AAAARecord.Address, nil
+	case dns.TXTRecordType:
+		if record.TXTRecord == nil {
+			return "", "", fmt.Errorf("missing TXT record")
+		}
+		return record.TXTRecord.Domain, record.TXTRecord.Text, nil
+	}
+	return "", "", &dns.UnsupportedRecordTypeError{Type: record.Type}
+}
+
+// newChange returns the change that performs an action on a record, or nil if
+// the action is an update of a record that was recently updated. The target of
+// an alias record must correspond to the hostname of an ELB which will be
+// automatically discovered. If the record has a routing policy with a health
+// check, an update reuses the health check of the current resource record set
+// if it has the same configuration, and creates one otherwise.
+func (m *Manager) newChange(record *dns.Record, action action) (*change, error) {
+	domain, target, err := recordTarget(record)
+	if err != nil {
+		return nil, err
 	}
-	domain, target := alias.Domain, alias.Target
 	if len(domain) == 0 {
-		return fmt.Errorf("domain is required")
+		return nil, fmt.Errorf("domain is required")
 	}
 	if len(target) == 0 {
-		return fmt.Errorf("target is required")
+		return nil, fmt.Errorf("target is required")
 	}
 
 	zoneID, err := m.getZoneID(record.Zone)
 	if err != nil {
-		return fmt.Errorf("failed to find hosted zone for record %v: %v", record, err)
+		return nil, fmt.Errorf("failed to find hosted zone for record %v: %v", record, err)
 	}
 
-	// Find the target hosted zone of the load balancer attached to the service.
-	targetHostedZoneID, err := m.getLBHostedZone(target)
-	if err != nil {
-		return fmt.Errorf("failed to get hosted zone for load balancer target %q: %v", target, err)
+	rrset := &route53.ResourceRecordSet{Name: aws.String(domain)}
+	switch record.Type {
+	case dns.ALIASRecord:
+		// Find the target hosted zone of the load balancer attached to
+		// the service.
+		targetHostedZoneID, err := m.getLBHostedZone(target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hosted zone for load balancer target %q: %v", target, err)
+		}
+		rrset.Type = aws.String("A")
+		rrset.AliasTarget = &route53.AliasTarget{
+			HostedZoneId:         aws.String(targetHostedZoneID),
+			DNSName:              aws.String(target),
+			EvaluateTargetHealth: aws.Bool(false),
+		}
+	case dns.AAAARecordType:
+		rrset.Type = aws.String("AAAA")
+		rrset.TTL = aws.Int64(recordTTL)
+		rrset.ResourceRecords = []*route53.ResourceRecord{{Value: aws.String(target)}}
+	case dns.TXTRecordType:
+		rrset.Type = aws.String("TXT")
+		rrset.TTL = aws.Int64(recordTTL)
+		rrset.ResourceRecords = []*route53.ResourceRecord{{Value: aws.String(quoteTXT(target))}}
+	}
+	if record.RoutingPolicy != nil {
+		if err := applyRoutingPolicy(rrset, record.RoutingPolicy); err != nil {
+			return nil, fmt.Errorf("invalid routing policy for record %v: %v", record, err)
+		}
 	}
 
-	// Configure records and cache updates.
-	// TODO: handle the caching/diff detection in a better way.
-	m.lock.Lock()
-	defer m.lock.Unlock()
-	key := zoneID + domain + target
-	// Only process updates once for now because we're not diffing.
-	if m.updatedRecords.Has(key) && action == upsertAction {
+	key := recordKey(zoneID, domain, target, record.RoutingPolicy)
+	c := &change{record: record, records: []*dns.Record{record}, zoneID: zoneID, key: key, action: action, rrset: rrset}
+	if action != upsertAction {
+		return c, nil
+	}
+
+	// Skip updates of records that were recently updated.
+	m.lock.RLock()
+	updated, ok := m.updatedRecords[key]
+	m.lock.RUnlock()
+	if ok && time.Since(updated) < updatedRecordTTL {
 		log.Info("skipping DNS record update", "record", record)
-		return nil
+		return nil, nil
 	}
-	err = m.updateAlias(domain, zoneID, target, targetHostedZoneID, string(action))
-	if err != nil {
-		return fmt.Errorf("failed to update alias in zone %s: %v", zoneID, err)
+
+	if record.RoutingPolicy != nil {
+		current, err := m.getResourceRecordSet(domain, zoneID, aws.StringValue(rrset.Type), record.RoutingPolicy.SetIdentifier)
+		if err != nil {
+			return nil, err
+		}
+		currentHealthCheckID := ""
+		if current != nil {
+			currentHealthCheckID = aws.StringValue(current.HealthCheckId)
+		}
+		if hc := record.RoutingPolicy.HealthCheck; hc != nil {
+			config := healthCheckConfig(target, hc)
+			id, err := m.findHealthCheck(currentHealthCheckID, config)
+			if err != nil {
+				return nil, err
+			}
+			if len(id) == 0 {
+				id, err = m.createHealthCheck(config)
+				if err != nil {
+					return nil, err
+				}
+				c.createdHealthCheckID = id
+			}
+			rrset.HealthCheckId = aws.String(id)
+		}
+		if currentHealthCheckID != aws.StringValue(rrset.HealthCheckId) {
+			c.obsoleteHealthCheckID = currentHealthCheckID
+		}
+	}
+
+	return c, nil
+}
+
+// recordKey returns the key that identifies a record in the caches of updated
+// records and pending changes.
+func recordKey(zoneID, domain, target string, policy *dns.RoutingPolicy) string {
+	key := zoneID + domain + target
+	if policy != nil {
+		key += "/" + policy.String()
 	}
-	switch action {
-	case upsertAction:
-		m.updatedRecords.Insert(key)
-		log.Info("upserted DNS record", "record", record)
-	case deleteAction:
-		m.updatedRecords.Delete(key)
-		log.Info("deleted DNS record", "record", record)
+	return key
+}
+
+// applyRoutingPolicy sets the set identifier and routing policy of rrset. An
+// alias with a routing policy evaluates the health of its target, so that an
+// unhealthy load balancer isn't used to answer queries.
+func applyRoutingPolicy(rrset *route53.ResourceRecordSet, policy *dns.RoutingPolicy) error {
+	if len(policy.SetIdentifier) == 0 {
+		return fmt.Errorf("set identifier is required")
+	}
+	rrset.SetIdentifier = aws.String(policy.SetIdentifier)
+	switch policy.Type {
+	case dns.WeightedRoutingPolicy:
+		if policy.Weight < 0 || policy.Weight > 255 {
+			return fmt.Errorf("weight %d is not between 0 and 255", policy.Weight)
+		}
+		rrset.Weight = aws.Int64(policy.Weight)
+	case dns.FailoverRoutingPolicy:
+		switch policy.Failover {
+		case dns.PrimaryFailoverRole:
+			rrset.Failover = aws.String(route53.ResourceRecordSetFailoverPrimary)
+		case dns.SecondaryFailoverRole:
+			rrset.Failover = aws.String(route53.ResourceRecordSetFailoverSecondary)
+		default:
+			return fmt.Errorf("unsupported failover role %q", policy.Failover)
+		}
+	case dns.LatencyRoutingPolicy:
+		if len(policy.Region) == 0 {
+			return fmt.Errorf("region is required")
+		}
+		rrset.Region = aws.String(policy.Region)
+	default:
+		return fmt.Errorf("unsupported routing policy type %q", policy.Type)
+	}
+	if rrset.AliasTarget != nil {
+		rrset.AliasTarget.EvaluateTargetHealth = aws.Bool(true)
 	}
 	return nil
 }
 
-// updateAlias creates or updates an alias for domain in zoneID pointed at
-// target in targetHostedZoneID.
-func (m *Manager) updateAlias(domain, zoneID, target, targetHostedZoneID, action string) error {
-	resp, err := m.route53.ChangeResourceRecordSets(&route53.ChangeResourceRecordSetsInput{
-		HostedZoneId: aws.String(zoneID),
-		ChangeBatch: &route53.ChangeBatch{
-			Changes: []*route53.Change{
-				{
-					Action: aws.String(action),
-					ResourceRecordSet: &route53.ResourceRecordSet{
-						Name: aws.String(domain),
-						Type: aws.String("A"),
-						AliasTarget: &route53.AliasTarget{
-							HostedZoneId:         aws.String(targetHostedZoneID),
-							DNSName:              aws.String(target),
-							EvaluateTargetHealth: aws.Bool(false),
-						},
-					},
-				},
-			},
-		},
+// healthCheckConfig returns the configuration of a health check of target,
+// which is a hostname or an IP address. The health check is an HTTP check if
+// hc has a path and a TCP check otherwise.
+func healthCheckConfig(target string, hc *dns.HealthCheck) *route53.HealthCheckConfig {
+	config := &route53.HealthCheckConfig{
+		Type: aws.String(route53.HealthCheckTypeTcp),
+		Port: aws.Int64(hc.Port),
+	}
+	if len(hc.Path) > 0 {
+		config.Type = aws.String(route53.HealthCheckTypeHttp)
+		config.ResourcePath = aws.String(hc.Path)
+	}
+	if net.ParseIP(target) != nil {
+		config.IPAddress = aws.String(target)
+	} else {
+		config.FullyQualifiedDomainName = aws.String(target)
+	}
+	return config
+}
+
+// findHealthCheck returns id if it is the ID of a health check with the given
+// configuration, or the empty string otherwise.
+func (m *Manager) findHealthCheck(id string, config *route53.HealthCheckConfig) (string, error) {
+	if len(id) == 0 {
+		return "", nil
+	}
+	resp, err := m.route53.GetHealthCheck(&route53.GetHealthCheckInput{HealthCheckId: aws.String(id)})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == route53.ErrCodeNoSuchHealthCheck {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get health check %s: %v", id, err)
+	}
+	if !sameHealthCheckConfig(resp.HealthCheck.HealthCheckConfig, config) {
+		return "", nil
+	}
+	return id, nil
+}
+
+// sameHealthCheckConfig returns true if a and b check the same target in the
+// same way.
+func sameHealthCheckConfig(a, b *route53.HealthCheckConfig) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return aws.StringValue(a.Type) == aws.StringValue(b.Type) &&
+		aws.Int64Value(a.Port) == aws.Int64Value(b.Port) &&
+		aws.StringValue(a.ResourcePath) == aws.StringValue(b.ResourcePath) &&
+		aws.StringValue(a.IPAddress) == aws.StringValue(b.IPAddress) &&
+		strings.EqualFold(strings.TrimSuffix(aws.StringValue(a.FullyQualifiedDomainName), "."), strings.TrimSuffix(aws.StringValue(b.FullyQualifiedDomainName), "."))
+}
+
+// createHealthCheck creates a health check with the given configuration and
+// returns its ID. Every health check belongs to a single resource record set,
+// so that it can be deleted along with the resource record set.
+func (m *Manager) createHealthCheck(config *route53.HealthCheckConfig) (string, error) {
+	resp, err := m.route53.CreateHealthCheck(&route53.CreateHealthCheckInput{
+		CallerReference:   aws.String(fmt.Sprintf("openshift-ingress-%d", time.Now().UnixNano())),
+		HealthCheckConfig: config,
 	})
 	if err != nil {
-		if action == string(deleteAction) {
+		return "", fmt.Errorf("failed to create health check: %v", err)
+	}
+	id := aws.StringValue(resp.HealthCheck.Id)
+	log.Info("created health check", "id", id, "config", config)
+	return id, nil
+}
+
+// deleteHealthCheck deletes the health check with the given ID. A failure is
+// only logged, as the resource record sets have already been changed.
+func (m *Manager) deleteHealthCheck(id string) {
+	if _, err := m.route53.DeleteHealthCheck(&route53.DeleteHealthCheckInput{HealthCheckId: aws.String(id)}); err != nil {
+		if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != route53.ErrCodeNoSuchHealthCheck {
+			log.Error(err, "failed to delete health check", "id", id)
+			return
+		}
+	}
+	log.Info("deleted health check", "id", id)
+}
+
+// submit submits the given changes to the hosted zone with the given ID in a
+// single change set, updates the cache of updated records, and keeps track of
+// the change set until it has propagated.
+func (m *Manager) submit(zoneID string, changes []*change) error {
+	input := &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(zoneID),
+		ChangeBatch:  &route53.ChangeBatch{},
+	}
+	for _, c := range changes {
+		input.ChangeBatch.Changes = append(input.ChangeBatch.Changes, &route53.Change{
+			Action:            aws.String(string(c.action)),
+			ResourceRecordSet: c.rrset,
+		})
+	}
+	resp, err := m.route53.ChangeResourceRecordSets(input)
+	if err != nil {
+		for _, c := range changes {
+			if len(c.createdHealthCheckID) > 0 {
+				m.deleteHealthCheck(c.createdHealthCheckID)
+			}
+		}
+		if len(changes) == 1 && changes[0].action == deleteAction {
 			if aerr, ok := err.(awserr.Error); ok {
 				if strings.Contains(aerr.Message(), "not found") {
-					log.Info("record not found", "zone id", zoneID, "domain", domain, "target", target)
+					log.Info("record not found", "zone id", zoneID, "record", changes[0].record)
 					return nil
 				}
 			}
 		}
-		return fmt.Errorf("couldn't update DNS record in zone %s: %v", zoneID, err)
+		return fmt.Errorf("couldn't update DNS records in zone %s: %v", zoneID, err)
+	}
+	changeID, status := aws.StringValue(resp.ChangeInfo.Id), aws.StringValue(resp.ChangeInfo.Status)
+	log.Info("submitted DNS changes", "zone id", zoneID, "changes", len(changes), "change id", changeID, "status", status)
+	for _, c := range changes {
+		if len(c.obsoleteHealthCheckID) > 0 {
+			m.deleteHealthCheck(c.obsoleteHealthCheckID)
+		}
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, c := range changes {
+		switch {
+		case c.removesRecord:
+			delete(m.updatedRecords, c.key)
+			delete(m.pendingChanges, c.key)
+			log.Info("deleted DNS record", "record", c.record)
+		case c.action == upsertAction:
+			m.updatedRecords[c.key] = time.Now()
+			if status == route53.ChangeStatusInsync {
+				delete(m.pendingChanges, c.key)
+			} else {
+				m.pendingChanges[c.key] = changeID
+			}
+			log.Info("upserted DNS record", "record", c.record)
+		case c.action == deleteAction:
+			delete(m.updatedRecords, c.key)
+			delete(m.pendingChanges, c.key)
+			log.Info("deleted DNS record", "record", c.record)
+		}
+	}
+	return nil
+}
+
+// Pending returns true if the change set that most recently updated record
+// has not yet propagated to all of the Route53 DNS servers.
+func (m *Manager) Pending(record *dns.Record) (bool, error) {
+	domain, target, err := recordTarget(record)
+	if err != nil {
+		return false, err
+	}
+	zoneID, err := m.getZoneID(record.Zone)
+	if err != nil {
+		return false, fmt.Errorf("failed to find hosted zone for record %v: %v", record, err)
+	}
+
+	m.lock.RLock()
+	changeID, ok := m.pendingChanges[recordKey(zoneID, domain, target, record.RoutingPolicy)]
+	m.lock.RUnlock()
+	if !ok {
+		return false, nil
+	}
+
+	resp, err := m.route53.GetChange(&route53.GetChangeInput{Id: aws.String(changeID)})
+	if err != nil {
+		return false, fmt.Errorf("failed to get status of change %s: %v", changeID, err)
+	}
+	if aws.StringValue(resp.ChangeInfo.Status) != route53.ChangeStatusInsync {
+		return true, nil
+	}
+
+	log.Info("DNS changes have propagated", "zone id", zoneID, "change id", changeID)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for key, id := range m.pendingChanges {
+		if id == changeID {
+			delete(m.pendingChanges, key)
+		}
+	}
+	return false, nil
+}
+
+// NewBatch returns a batch that submits the queued changes to each hosted
+// zone in a single change set.
+func (m *Manager) NewBatch() dns.Batch {
+	return &batch{
+		Manager:   m,
+		zones:     map[string]configv1.DNSZone{},
+		changes:   map[string][]*change{},
+		originals: map[string]*route53.ResourceRecordSet{},
+	}
+}
+
+// batch queues changes to records and submits them in one change set per
+// hosted zone when it is committed.
+type batch struct {
+	*Manager
+
+	// zoneIDs are the IDs of the hosted zones with queued changes, in the
+	// order in which the first change to each zone was queued.
+	zoneIDs []string
+	// zones maps a hosted zone ID to the zone configuration.
+	zones map[string]configv1.DNSZone
+	// changes maps a hosted zone ID to the queued changes to the zone.
+	changes map[string][]*change
+	// originals maps the key of an AAAA resource record set whose
+	// addresses the batch changes to the resource record set as it was
+	// before the batch, or nil if it didn't exist.
+	originals map[string]*route53.ResourceRecordSet
+}
+
+func (b *batch) Ensure(record *dns.Record) error {
+	c, err := b.newChange(record, upsertAction)
+	if err != nil || c == nil {
+		return err
+	}
+	if record.Type == dns.AAAARecordType {
+		return b.changeAddress(c, true)
+	}
+	b.add(c)
+	return nil
+}
+
+// Delete queues the deletion of record if it exists. The address of an AAAA
+// record is removed from its resource record set, which is only deleted along
+// with its last address. Route53 rejects a change
+// set that deletes a resource record set that doesn't exist or that has
+// different values, so the deletion of such a record is skipped rather than
+// queued. Only the resource record set with the record's set identifier is
+// deleted, so the records that other clusters publish for the same domain
+// with a routing policy are left alone.
+func (b *batch) Delete(record *dns.Record) error {
+	c, err := b.newChange(record, deleteAction)
+	if err != nil {
+		return err
+	}
+	if record.Type == dns.AAAARecordType {
+		return b.changeAddress(c, false)
+	}
+	current, err := b.getResourceRecordSet(aws.StringValue(c.rrset.Name), c.zoneID, aws.StringValue(c.rrset.Type), aws.StringValue(c.rrset.SetIdentifier))
+	if err != nil {
+		return err
+	}
+	if current == nil || !sameValues(current, c.rrset) {
+		log.Info("record not found", "zone id", c.zoneID, "record", record)
+		return nil
+	}
+	// Delete the resource record set as it is, in case someone changed a
+	// property such as the TTL, and then its health check.
+	current.Name = c.rrset.Name
+	c.rrset = current
+	c.obsoleteHealthCheckID = aws.StringValue(current.HealthCheckId)
+	b.add(c)
+	return nil
+}
+
+// changeAddress queues the change that adds the address of the AAAA record of
+// c to its resource record set if add is true, or removes the address
+// otherwise. The resource record set keeps the addresses of the other AAAA
+// records with the same name, including the changes to them that are queued
+// in the batch, so that the addresses of several AAAA records with the same
+// name make up one resource record set. The resource record set is deleted
+// when its last address is removed.
+func (b *batch) changeAddress(c *change, add bool) error {
+	name, setIdentifier := aws.StringValue(c.rrset.Name), aws.StringValue(c.rrset.SetIdentifier)
+	key := strings.Join([]string{c.zoneID, strings.ToLower(strings.TrimSuffix(name, ".")), aws.StringValue(c.rrset.Type), setIdentifier}, "/")
+	original, ok := b.originals[key]
+	if !ok {
+		current, err := b.getResourceRecordSet(name, c.zoneID, aws.StringValue(c.rrset.Type), setIdentifier)
+		if err != nil {
+			return err
+		}
+		b.originals[key] = current
+		original = current
+	}
+
+	// base is the resource record set as the batch leaves it so far.
+	base := original
+	queued := b.queued(c)
+	if queued != nil {
+		base = queued.rrset
+		if queued.action == deleteAction {
+			base = nil
+		}
+	}
+	address := net.ParseIP(aws.StringValue(c.rrset.ResourceRecords[0].Value))
+	values := []*route53.ResourceRecord{}
+	if base != nil {
+		for _, rr := range base.ResourceRecords {
+			if !net.ParseIP(aws.StringValue(rr.Value)).Equal(address) {
+				values = append(values, rr)
+			}
+		}
 	}
-	log.Info("updated DNS record", "zone id", zoneID, "domain", domain, "target", target, "response", resp)
+	if add {
+		values = append(values, c.rrset.ResourceRecords[0])
+	}
+
+	switch {
+	case len(values) > 0:
+		if !add {
+			// Keep the properties of the resource record set, such
+			// as its TTL and health check.
+			rrset := *base
+			rrset.Name = c.rrset.Name
+			c.rrset = &rrset
+			c.removesRecord = true
+			if queued != nil {
+				c.createdHealthCheckID = queued.createdHealthCheckID
+				c.obsoleteHealthCheckID = queued.obsoleteHealthCheckID
+			}
+		}
+		c.action = upsertAction
+		c.rrset.ResourceRecords = values
+	case original == nil:
+		// There is nothing to delete.
+		if queued != nil {
+			b.unqueue(queued)
+		}
+		log.Info("record not found", "zone id", c.zoneID, "record", c.record)
+		return nil
+	default:
+		rrset := *original
+		rrset.Name = c.rrset.Name
+		c.action = deleteAction
+		c.rrset = &rrset
+		c.obsoleteHealthCheckID = aws.StringValue(original.HealthCheckId)
+	}
+	b.replace(c)
 	return nil
 }
+
+// queued returns the queued change to the resource record set of c, if any.
+func (b *batch) queued(c *change) *change {
+	for _, queued := range b.changes[c.zoneID] {
+		if sameResourceRecordSet(queued.rrset, c.rrset) {
+			return queued
+		}
+	}
+	return nil
+}
+
+// unqueue removes the given queued change from the batch, along with the
+// health check that was created for it.
+func (b *batch) unqueue(c *change) {
+	changes := b.changes[c.zoneID]
+	for i, queued := range changes {
+		if queued == c {
+			b.changes[c.zoneID] = append(changes[:i], changes[i+1:]...)
+			break
+		}
+	}
+	if len(c.createdHealthCheckID) > 0 {
+		b.deleteHealthCheck(c.createdHealthCheckID)
+	}
+}
+
+// add queues c. Route53 rejects a change set that changes a resource record
+// set more than once, so c replaces any queued change to the same resource
+// record set, except that a deletion never replaces an update.
+func (b *batch) add(c *change) {
+	if queued := b.queued(c); queued != nil && c.action == deleteAction && queued.action == upsertAction {
+		// The update replaces the record that would be deleted.
+		queued.records = append(queued.records, c.records...)
+		return
+	}
+	b.replace(c)
+}
+
+// replace queues c in place of any queued change to the same resource record
+// set. The health check created for the replaced change is deleted unless c
+// uses it, as it would otherwise never be used.
+func (b *batch) replace(c *change) {
+	changes, ok := b.changes[c.zoneID]
+	if !ok {
+		b.zoneIDs = append(b.zoneIDs, c.zoneID)
+		b.zones[c.zoneID] = c.record.Zone
+	}
+	for i, queued := range changes {
+		if sameResourceRecordSet(queued.rrset, c.rrset) {
+			if len(queued.createdHealthCheckID) > 0 && queued.createdHealthCheckID != aws.StringValue(c.rrset.HealthCheckId) {
+				b.deleteHealthCheck(queued.createdHealthCheckID)
+			}
+			c.records = append(append([]*dns.Record{}, queued.records...), c.records...)
+			changes[i] = c
+			return
+		}
+	}
+	b.changes[c.zoneID] = append(changes, c)
+}
+
+// Commit submits the queued changes to each hosted zone, in change sets of at
+// most maxResourceRecordsPerChangeSet resource records. If a change set fails,
+// the changes in the later change sets for the zone are not submitted, and the
+// zone's error lists the records whose changes the earlier ones submitted.
+func (b *batch) Commit() []dns.ZoneError {
+	errs := []dns.ZoneError{}
+	for _, zoneID := range b.zoneIDs {
+		changes := b.changes[zoneID]
+		committed := []*dns.Record{}
+		for len(changes) > 0 {
+			n := changeSetSize(changes)
+			if err := b.submit(zoneID, changes[:n]); err != nil {
+				errs = append(errs, dns.ZoneError{Zone: b.zones[zoneID], Err: err, Committed: committed})
+				break
+			}
+			for _, c := range changes[:n] {
+				committed = append(committed, c.records...)
+			}
+			changes = changes[n:]
+		}
+	}
+	b.zoneIDs = nil
+	b.zones = map[string]configv1.DNSZone{}
+	b.changes = map[string][]*change{}
+	return errs
+}
+
+// changeSetSize returns the number of the given changes, at least one, that fit
+// in a change set of at most maxResourceRecordsPerChangeSet resource records.
+func changeSetSize(changes []*change) int {
+	total := 0
+	for i, c := range changes {
+		size := len(c.rrset.ResourceRecords)
+		if size == 0 {
+			// An alias counts as one resource record.
+			size = 1
+		}
+		if c.action == upsertAction {
+			size *= 2
+		}
+		total += size
+		if total > maxResourceRecordsPerChangeSet && i > 0 {
+			return i
+		}
+	}
+	return len(changes)
+}
+
+// sameResourceRecordSet returns true if a and b have the same name, type, and
+// set identifier.
+func sameResourceRecordSet(a, b *route53.ResourceRecordSet) bool {
+	return strings.EqualFold(strings.TrimSuffix(aws.StringValue(a.Name), "."), strings.TrimSuffix(aws.StringValue(b.Name), ".")) &&
+		aws.StringValue(a.Type) == aws.StringValue(b.Type) &&
+		aws.StringValue(a.SetIdentifier) == aws.StringValue(b.SetIdentifier)
+}
+
+// sameValues returns true if a and b have the same alias target or the same
+// resource records.
+func sameValues(a, b *route53.ResourceRecordSet) bool {
+	if a.AliasTarget != nil || b.AliasTarget != nil {
+		return a.AliasTarget != nil && b.AliasTarget != nil &&
+			strings.EqualFold(strings.TrimSuffix(aws.StringValue(a.AliasTarget.DNSName), "."), strings.TrimSuffix(aws.StringValue(b.AliasTarget.DNSName), "."))
+	}
+	if len(a.ResourceRecords) != len(b.ResourceRecords) {
+		return false
+	}
+	for i := range a.ResourceRecords {
+		if aws.StringValue(a.ResourceRecords[i].Value) != aws.StringValue(b.ResourceRecords[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Get returns the record that is currently published for the record's domain,
+// type, and set identifier in the record's zone, or nil if there is no such
+// record. A record in the cache of updated records is returned as is without
+// reading it from Route53. If the current record doesn't match the given
+// record, the record is removed from the cache of updated records so that the
+// next call to Ensure updates it.
+func (m *Manager) Get(record *dns.Record) (*dns.Record, error) {
+	domain, target, err := recordTarget(record)
+	if err != nil {
+		return nil, err
+	}
+	if len(domain) == 0 {
+		return nil, fmt.Errorf("domain is required")
+	}
+
+	zoneID, err := m.getZoneID(record.Zone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find hosted zone for record %v: %v", record, err)
+	}
+
+	key := recordKey(zoneID, domain, target, record.RoutingPolicy)
+	m.lock.RLock()
+	updated, ok := m.updatedRecords[key]
+	m.lock.RUnlock()
+	if ok && time.Since(updated) < updatedRecordTTL {
+		return record, nil
+	}
+
+	setIdentifier := ""
+	if record.RoutingPolicy != nil {
+		setIdentifier = record.RoutingPolicy.SetIdentifier
+	}
+	rrset, err := m.getResourceRecordSet(domain, zoneID, resourceRecordSetTypes[record.Type], setIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	current, currentTarget := recordFromResourceRecordSet(record, domain, rrset)
+
+	if current == nil || !strings.EqualFold(currentTarget, target) || !sameRoutingPolicy(current.RoutingPolicy, record.RoutingPolicy) {
+		m.lock.Lock()
+		delete(m.updatedRecords, key)
+		m.lock.Unlock()
+	}
+	return current, nil
+}
+
+// resourceRecordSetTypes maps the supported record types to the types of the
+// resource record sets that represent them.
+var resourceRecordSetTypes = map[dns.RecordType]string{
+	dns.ALIASRecord:    "A",
+	dns.AAAARecordType: "AAAA",
+	dns.TXTRecordType:  "TXT",
+}
+
+// recordFromResourceRecordSet returns the record of the given record's type
+// that rrset represents, along with its target, or nil if rrset is nil or
+// doesn't represent such a record. The routing policy of the record omits the
+// health check.
+func recordFromResourceRecordSet(record *dns.Record, domain string, rrset *route53.ResourceRecordSet) (*dns.Record, string) {
+	if rrset == nil {
+		return nil, ""
+	}
+	current := &dns.Record{Zone: record.Zone, Type: record.Type, RoutingPolicy: routingPolicy(rrset)}
+	var target string
+	switch record.Type {
+	case dns.ALIASRecord:
+		if rrset.AliasTarget == nil {
+			return nil, ""
+		}
+		target = strings.TrimSuffix(aws.StringValue(rrset.AliasTarget.DNSName), ".")
+		current.Alias = &dns.AliasRecord{Domain: domain, Target: target}
+	case dns.AAAARecordType:
+		if len(rrset.ResourceRecords) == 0 {
+			return nil, ""
+		}
+		// Prefer the record's own address among the addresses of the
+		// resource record set.
+		target = aws.StringValue(rrset.ResourceRecords[0].Value)
+		if record.AAAARecord != nil {
+			for _, rr := range rrset.ResourceRecords {
+				if net.ParseIP(aws.StringValue(rr.Value)).Equal(net.ParseIP(record.AAAARecord.Address)) {
+					target = aws.StringValue(rr.Value)
+					break
+				}
+			}
+		}
+		current.AAAARecord = &dns.AAAARecord{Domain: domain, Address: target}
+	case dns.TXTRecordType:
+		if len(rrset.ResourceRecords) == 0 {
+			return nil, ""
+		}
+		target = unquoteTXT(aws.StringValue(rrset.ResourceRecords[0].Value))
+		current.TXTRecord = &dns.TXTRecord{Domain: domain, Text: target}
+	default:
+		return nil, ""
+	}
+	return current, target
+}
+
+// routingPolicy returns the routing policy of rrset, without its health check,
+// or nil if rrset has no set identifier.
+func routingPolicy(rrset *route53.ResourceRecordSet) *dns.RoutingPolicy {
+	if len(aws.StringValue(rrset.SetIdentifier)) == 0 {
+		return nil
+	}
+	policy := &dns.RoutingPolicy{SetIdentifier: aws.StringValue(rrset.SetIdentifier)}
+	switch {
+	case rrset.Weight != nil:
+		policy.Type = dns.WeightedRoutingPolicy
+		policy.Weight = aws.Int64Value(rrset.Weight)
+	case rrset.Failover != nil:
+		policy.Type = dns.FailoverRoutingPolicy
+		policy.Failover = dns.PrimaryFailoverRole
+		if aws.StringValue(rrset.Failover) == route53.ResourceRecordSetFailoverSecondary {
+			policy.Failover = dns.SecondaryFailoverRole
+		}
+	case rrset.Region != nil:
+		policy.Type = dns.LatencyRoutingPolicy
+		policy.Region = aws.StringValue(rrset.Region)
+	}
+	return policy
+}
+
+// sameRoutingPolicy returns true if current, which has no health check, is the
+// desired routing policy, apart from the health check.
+func sameRoutingPolicy(current, desired *dns.RoutingPolicy) bool {
+	if current == nil || desired == nil {
+		return current == desired
+	}
+	d := *desired
+	d.HealthCheck = nil
+	return *current == d
+}
+
+// getResourceRecordSet returns the resource record set with the given domain,
+// type, and set identifier in zoneID, or nil if there is no such resource
+// record set. The set identifier is empty for a resource record set without a
+// routing policy. Resource record sets with the same domain and type are
+// listed together, so the listing stops at the first one with a different
+// domain or type.
+func (m *Manager) getResourceRecordSet(domain, zoneID, rrtype, setIdentifier string) (*route53.ResourceRecordSet, error) {
+	name := strings.TrimSuffix(domain, ".") + "."
+	input := &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(zoneID),
+		StartRecordName: aws.String(name),
+		StartRecordType: aws.String(rrtype),
+		MaxItems:        aws.String("10"),
+	}
+	for {
+		resp, err := m.route53.ListResourceRecordSets(input)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't list DNS records in zone %s: %v", zoneID, err)
+		}
+		for _, rrset := range resp.ResourceRecordSets {
+			// Route53 returns names with special characters escaped
+			// in octal, as in "\052" for "*".
+			if !strings.EqualFold(unescapeRecordName(aws.StringValue(rrset.Name)), name) || aws.StringValue(rrset.Type) != rrtype {
+				return nil, nil
+			}
+			if aws.StringValue(rrset.SetIdentifier) == setIdentifier {
+				return rrset, nil
+			}
+		}
+		if !aws.BoolValue(resp.IsTruncated) {
+			return nil, nil
+		}
+		input.StartRecordName = resp.NextRecordName
+		input.StartRecordType = resp.NextRecordType
+		input.StartRecordIdentifier = resp.NextRecordIdentifier
+	}
+}
+
+// quoteTXT returns text as the quoted string that Route53 expects as the value
+// of a TXT record.
+func quoteTXT(text string) string {
+	return `"` + strings.Replace(strings.Replace(text, `\`, `\\`, -1), `"`, `\"`, -1) + `"`
+}
+
+// unquoteTXT returns the text of a TXT record value returned by Route53, which
+// consists of one or more quoted strings.
+func unquoteTXT(value string) string {
+	var b strings.Builder
+	quoted := false
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case c == '"':
+			quoted = !quoted
+		case c == '\\' && quoted && i+1 < len(value):
+			i++
+			b.WriteByte(value[i])
+		case quoted:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// unescapeRecordName replaces octal escape sequences in a record name returned
+// by Route53 with the characters that they represent.
+func unescapeRecordName(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] == '\\' && i+3 < len(name) {
+			if c, err := strconv.ParseUint(name[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(name[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,706 @@
+package aws
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go/service/route53"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+)
+
+func TestUnescapeRecordName(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect string
+	}{
+		{`\052.apps.example.com.`, "*.apps.example.com."},
+		{`apps.example.com.`, "apps.example.com."},
+		{`foo\100bar.example.com.`, "foo@bar.example.com."},
+		{`trailing\05`, `trailing\05`},
+	}
+	for _, test := range tests {
+		if actual := unescapeRecordName(test.name); actual != test.expect {
+			t.Errorf("expected %q to unescape to %q, got %q", test.name, test.expect, actual)
+		}
+	}
+}
+
+func TestQuoteTXT(t *testing.T) {
+	tests := []struct {
+		text   string
+		quoted string
+	}{
+		{"heritage=openshift-ingress-operator,cluster=test,ingresscontroller=default", `"heritage=openshift-ingress-operator,cluster=test,ingresscontroller=default"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`back\slash`, `"back\\slash"`},
+	}
+	for _, test := range tests {
+		if actual := quoteTXT(test.text); actual != test.quoted {
+			t.Errorf("expected %q to quote to %q, got %q", test.text, test.quoted, actual)
+		}
+		if actual := unquoteTXT(test.quoted); actual != test.text {
+			t.Errorf("expected %q to unquote to %q, got %q", test.quoted, test.text, actual)
+		}
+	}
+	if actual := unquoteTXT(`"split" "text"`); actual != "splittext" {
+		t.Errorf("expected multiple strings to be concatenated, got %q", actual)
+	}
+}
+
+const classicLBResponse = `<DescribeLoadBalancersResponse xmlns="http://elasticloadbalancing.amazonaws.com/doc/2012-06-01/">
+  <DescribeLoadBalancersResult>
+    <LoadBalancerDescriptions>
+      <member>
+        <LoadBalancerName>classic</LoadBalancerName>
+        <CanonicalHostedZoneName>classic-1.us-east-1.elb.amazonaws.com</CanonicalHostedZoneName>
+        <CanonicalHostedZoneNameID>ZCLASSIC</CanonicalHostedZoneNameID>
+      </member>
+    </LoadBalancerDescriptions>
+  </DescribeLoadBalancersResult>
+</DescribeLoadBalancersResponse>`
+
+const v2LBResponse = `<DescribeLoadBalancersResponse xmlns="http://elasticloadbalancing.amazonaws.com/doc/2015-12-01/">
+  <DescribeLoadBalancersResult>
+    <LoadBalancers>
+      <member>
+        <LoadBalancerName>nlb</LoadBalancerName>
+        <Type>network</Type>
+        <DNSName>nlb-1.elb.us-east-1.amazonaws.com</DNSName>
+        <CanonicalHostedZoneId>ZNETWORK</CanonicalHostedZoneId>
+      </member>
+    </LoadBalancers>
+  </DescribeLoadBalancersResult>
+</DescribeLoadBalancersResponse>`
+
+func TestGetLBHostedZone(t *testing.T) {
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		version := r.Form.Get("Version")
+		requests[version]++
+		switch version {
+		case "2012-06-01":
+			w.Write([]byte(classicLBResponse))
+		case "2015-12-01":
+			w.Write([]byte(v2LBResponse))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	sess, err := session.NewSession(aws.NewConfig().
+		WithCredentials(credentials.NewStaticCredentials("id", "key", "")).
+		WithRegion("us-east-1").
+		WithEndpoint(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	m := &Manager{
+		elb:     elb.New(sess),
+		elbv2:   elbv2.New(sess),
+		lbZones: map[string]string{},
+	}
+
+	tests := []struct {
+		name   string
+		expect string
+	}{
+		{"classic-1.us-east-1.elb.amazonaws.com", "ZCLASSIC"},
+		{"nlb-1.elb.us-east-1.amazonaws.com", "ZNETWORK"},
+		// Cached results are returned without describing load balancers.
+		{"classic-1.us-east-1.elb.amazonaws.com", "ZCLASSIC"},
+		{"nlb-1.elb.us-east-1.amazonaws.com", "ZNETWORK"},
+	}
+	for _, test := range tests {
+		id, err := m.getLBHostedZone(test.name)
+		if err != nil {
+			t.Fatalf("failed to get hosted zone of %s: %v", test.name, err)
+		}
+		if id != test.expect {
+			t.Errorf("expected hosted zone %s for %s, got %s", test.expect, test.name, id)
+		}
+	}
+	if requests["2012-06-01"] != 2 || requests["2015-12-01"] != 1 {
+		t.Errorf("expected 2 classic and 1 v2 requests, got %v", requests)
+	}
+
+	if _, err := m.getLBHostedZone("missing.elb.amazonaws.com"); err == nil {
+		t.Errorf("expected an error for an unknown load balancer")
+	}
+}
+
+const changeInfo = `<ChangeInfo><Id>/change/C1</Id><Status>%s</Status><SubmittedAt>2019-01-01T00:00:00Z</SubmittedAt></ChangeInfo>`
+
+const listResourceRecordSetsResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<ListResourceRecordSetsResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/">
+  <ResourceRecordSets>
+    <ResourceRecordSet>
+      <Name>old.example.com.</Name>
+      <Type>TXT</Type>
+      <TTL>60</TTL>
+      <ResourceRecords>
+        <ResourceRecord><Value>"owner"</Value></ResourceRecord>
+      </ResourceRecords>
+    </ResourceRecordSet>
+  </ResourceRecordSets>
+  <IsTruncated>false</IsTruncated>
+  <MaxItems>1</MaxItems>
+</ListResourceRecordSetsResponse>`
+
+func TestBatch(t *testing.T) {
+	var changeSets []string
+	getChanges := 0
+	// failChangeSet, if positive, is the number of the change set that
+	// Route53 rejects.
+	failChangeSet := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/2013-04-01/hostedzone/Z1/rrset/":
+			body, _ := ioutil.ReadAll(r.Body)
+			changeSets = append(changeSets, string(body))
+			if len(changeSets) == failChangeSet {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`<ErrorResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/"><Error><Type>Sender</Type><Code>InvalidChangeBatch</Code><Message>rejected</Message></Error></ErrorResponse>`))
+				return
+			}
+			fmt.Fprintf(w, `<ChangeResourceRecordSetsResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/">`+changeInfo+`</ChangeResourceRecordSetsResponse>`, "PENDING")
+		case r.Method == http.MethodGet && r.URL.Path == "/2013-04-01/hostedzone/Z1/rrset":
+			w.Write([]byte(listResourceRecordSetsResponse))
+		case r.Method == http.MethodGet && r.URL.Path == "/2013-04-01/change/C1":
+			getChanges++
+			status := "PENDING"
+			if getChanges > 1 {
+				status = "INSYNC"
+			}
+			fmt.Fprintf(w, `<GetChangeResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/">`+changeInfo+`</GetChangeResponse>`, status)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	sess, err := session.NewSession(aws.NewConfig().
+		WithCredentials(credentials.NewStaticCredentials("id", "key", "")).
+		WithRegion("us-east-1").
+		WithEndpoint(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	m := &Manager{
+		route53:        route53.New(sess),
+		updatedRecords: map[string]time.Time{},
+		pendingChanges: map[string]string{},
+	}
+
+	zone := configv1.DNSZone{ID: "Z1"}
+	aaaa := &dns.Record{
+		Zone:       zone,
+		Type:       dns.AAAARecordType,
+		AAAARecord: &dns.AAAARecord{Domain: "*.apps.example.com", Address: "2001:db8::1"},
+	}
+	txt := &dns.Record{
+		Zone:      zone,
+		Type:      dns.TXTRecordType,
+		TXTRecord: &dns.TXTRecord{Domain: "*.apps.example.com", Text: "owner"},
+	}
+	oldTXT := &dns.Record{
+		Zone:      zone,
+		Type:      dns.TXTRecordType,
+		TXTRecord: &dns.TXTRecord{Domain: "old.example.com", Text: "owner"},
+	}
+	missing := &dns.Record{
+		Zone:       zone,
+		Type:       dns.AAAARecordType,
+		AAAARecord: &dns.AAAARecord{Domain: "missing.example.com", Address: "2001:db8::2"},
+	}
+
+	b := m.NewBatch()
+	for _, record := range []*dns.Record{aaaa, txt} {
+		if err := b.Ensure(record); err != nil {
+			t.Fatalf("failed to ensure %v: %v", record, err)
+		}
+	}
+	// A deletion doesn't replace an update of the same record set, and
+	// records that don't exist are not deleted.
+	for _, record := range []*dns.Record{oldTXT, txt, missing} {
+		if err := b.Delete(record); err != nil {
+			t.Fatalf("failed to delete %v: %v", record, err)
+		}
+	}
+	if errs := b.Commit(); len(errs) != 0 {
+		t.Fatalf("failed to commit batch: %v", errs)
+	}
+	if len(changeSets) != 1 {
+		t.Fatalf("expected 1 change set, got %d", len(changeSets))
+	}
+	if n := strings.Count(changeSets[0], "<Change>"); n != 3 {
+		t.Errorf("expected 3 changes, got %d: %s", n, changeSets[0])
+	}
+	if n := strings.Count(changeSets[0], "<Action>DELETE</Action>"); n != 1 {
+		t.Errorf("expected 1 deletion, got %d: %s", n, changeSets[0])
+	}
+
+	// Committing an empty batch submits nothing.
+	if errs := b.Commit(); len(errs) != 0 || len(changeSets) != 1 {
+		t.Errorf("expected an empty batch to submit nothing, got %d change sets and errors %v", len(changeSets), errs)
+	}
+
+	for i, expect := range []bool{true, false, false} {
+		pending, err := m.Pending(aaaa)
+		if err != nil {
+			t.Fatalf("failed to get propagation status: %v", err)
+		}
+		if pending != expect {
+			t.Errorf("call %d: expected pending to be %v, got %v", i, expect, pending)
+		}
+	}
+	if getChanges != 2 {
+		t.Errorf("expected 2 requests for the change status, got %d", getChanges)
+	}
+	if pending, err := m.Pending(oldTXT); err != nil || pending {
+		t.Errorf("expected a deleted record not to be pending, got %v, %v", pending, err)
+	}
+
+	// A batch that is too large for one change set is split.
+	changeSets = nil
+	for i := 0; i <= maxResourceRecordsPerChangeSet/2; i++ {
+		record := &dns.Record{
+			Zone:       zone,
+			Type:       dns.AAAARecordType,
+			AAAARecord: &dns.AAAARecord{Domain: fmt.Sprintf("route-%d.apps.example.com", i), Address: "2001:db8::1"},
+		}
+		if err := b.Ensure(record); err != nil {
+			t.Fatalf("failed to ensure %v: %v", record, err)
+		}
+	}
+	if errs := b.Commit(); len(errs) != 0 {
+		t.Fatalf("failed to commit batch: %v", errs)
+	}
+	if len(changeSets) != 2 {
+		t.Fatalf("expected 2 change sets, got %d", len(changeSets))
+	}
+	for i, expect := range []int{maxResourceRecordsPerChangeSet / 2, 1} {
+		if n := strings.Count(changeSets[i], "<Change>"); n != expect {
+			t.Errorf("change set %d: expected %d changes, got %d", i, expect, n)
+		}
+	}
+
+	// If a later change set fails, the zone's error lists the records of
+	// the change sets that succeeded.
+	changeSets = nil
+	failChangeSet = 2
+	var records []*dns.Record
+	for i := 0; i <= maxResourceRecordsPerChangeSet/2; i++ {
+		record := &dns.Record{
+			Zone:       zone,
+			Type:       dns.AAAARecordType,
+			AAAARecord: &dns.AAAARecord{Domain: fmt.Sprintf("other-route-%d.apps.example.com", i), Address: "2001:db8::1"},
+		}
+		if err := b.Ensure(record); err != nil {
+			t.Fatalf("failed to ensure %v: %v", record, err)
+		}
+		records = append(records, record)
+	}
+	errs := b.Commit()
+	if len(errs) != 1 || len(changeSets) != 2 {
+		t.Fatalf("expected 1 error after 2 change sets, got %v after %d", errs, len(changeSets))
+	}
+	if !reflect.DeepEqual(errs[0].Committed, records[:len(records)-1]) {
+		t.Errorf("expected the records of the first change set to be committed, got %d records", len(errs[0].Committed))
+	}
+}
+
+const listAAAAResourceRecordSetsResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<ListResourceRecordSetsResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/">
+  <ResourceRecordSets>
+    <ResourceRecordSet>
+      <Name>\052.apps.example.com.</Name>
+      <Type>AAAA</Type>
+      <TTL>60</TTL>
+      <ResourceRecords>
+        <ResourceRecord><Value>2001:db8::1</Value></ResourceRecord>
+        <ResourceRecord><Value>2001:db8::2</Value></ResourceRecord>
+      </ResourceRecords>
+    </ResourceRecordSet>
+  </ResourceRecordSets>
+  <IsTruncated>false</IsTruncated>
+  <MaxItems>10</MaxItems>
+</ListResourceRecordSetsResponse>`
+
+func TestAAAARecordSet(t *testing.T) {
+	var changeSets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/2013-04-01/hostedzone/Z1/rrset/":
+			body, _ := ioutil.ReadAll(r.Body)
+			changeSets = append(changeSets, string(body))
+			fmt.Fprintf(w, `<ChangeResourceRecordSetsResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/">`+changeInfo+`</ChangeResourceRecordSetsResponse>`, "INSYNC")
+		case r.Method == http.MethodGet && r.URL.Path == "/2013-04-01/hostedzone/Z1/rrset":
+			w.Write([]byte(listAAAAResourceRecordSetsResponse))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	sess, err := session.NewSession(aws.NewConfig().
+		WithCredentials(credentials.NewStaticCredentials("id", "key", "")).
+		WithRegion("us-east-1").
+		WithEndpoint(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	m := &Manager{
+		route53:        route53.New(sess),
+		updatedRecords: map[string]time.Time{},
+		pendingChanges: map[string]string{},
+	}
+	aaaa := func(address string) *dns.Record {
+		return &dns.Record{
+			Zone:       configv1.DNSZone{ID: "Z1"},
+			Type:       dns.AAAARecordType,
+			AAAARecord: &dns.AAAARecord{Domain: "*.apps.example.com", Address: address},
+		}
+	}
+
+	tests := []struct {
+		description  string
+		ensure       []string
+		delete       []string
+		expectAction string
+		expectValues []string
+	}{
+		{
+			description:  "address added to the record set",
+			ensure:       []string{"2001:db8::3"},
+			expectAction: "UPSERT",
+			expectValues: []string{"2001:db8::1", "2001:db8::2", "2001:db8::3"},
+		},
+		{
+			description:  "address replaced in the record set",
+			delete:       []string{"2001:db8::1"},
+			ensure:       []string{"2001:db8::4"},
+			expectAction: "UPSERT",
+			expectValues: []string{"2001:db8::2", "2001:db8::4"},
+		},
+		{
+			description:  "last addresses removed",
+			delete:       []string{"2001:db8::1", "2001:db8::2"},
+			expectAction: "DELETE",
+			expectValues: []string{"2001:db8::1", "2001:db8::2"},
+		},
+	}
+	for _, test := range tests {
+		changeSets = nil
+		b := m.NewBatch()
+		for _, address := range test.delete {
+			if err := b.Delete(aaaa(address)); err != nil {
+				t.Fatalf("%s: failed to delete %s: %v", test.description, address, err)
+			}
+		}
+		for _, address := range test.ensure {
+			if err := b.Ensure(aaaa(address)); err != nil {
+				t.Fatalf("%s: failed to ensure %s: %v", test.description, address, err)
+			}
+		}
+		if errs := b.Commit(); len(errs) != 0 {
+			t.Fatalf("%s: failed to commit batch: %v", test.description, errs)
+		}
+		if len(changeSets) != 1 {
+			t.Fatalf("%s: expected 1 change set, got %d", test.description, len(changeSets))
+		}
+		changeSet := changeSets[0]
+		if n := strings.Count(changeSet, "<Change>"); n != 1 {
+			t.Errorf("%s: expected 1 change, got %d: %s", test.description, n, changeSet)
+		}
+		if !strings.Contains(changeSet, "<Action>"+test.expectAction+"</Action>") {
+			t.Errorf("%s: expected action %s: %s", test.description, test.expectAction, changeSet)
+		}
+		if n := strings.Count(changeSet, "<Value>"); n != len(test.expectValues) {
+			t.Errorf("%s: expected %d values, got %d: %s", test.description, len(test.expectValues), n, changeSet)
+		}
+		for _, value := range test.expectValues {
+			if !strings.Contains(changeSet, "<Value>"+value+"</Value>") {
+				t.Errorf("%s: expected value %s: %s", test.description, value, changeSet)
+			}
+		}
+	}
+
+	// Get returns the record's own address from the record set.
+	current, err := m.Get(aaaa("2001:db8::2"))
+	if err != nil {
+		t.Fatalf("failed to get record: %v", err)
+	}
+	if current == nil || current.AAAARecord.Address != "2001:db8::2" {
+		t.Errorf("expected address 2001:db8::2, got %v", current)
+	}
+}
+
+func TestPartitionFor(t *testing.T) {
+	tests := []struct {
+		region          string
+		expectPartition string
+		expectRegion    string
+		expectEndpoint  string
+	}{
+		{"us-west-2", "aws", "us-east-1", ""},
+		{"eu-central-1", "aws", "us-east-1", ""},
+		{"us-gov-west-1", "aws-us-gov", "us-gov-west-1", "https://route53.us-gov.amazonaws.com"},
+		{"us-gov-east-1", "aws-us-gov", "us-gov-west-1", "https://route53.us-gov.amazonaws.com"},
+		{"cn-north-1", "aws-cn", "cn-northwest-1", "https://route53.amazonaws.com.cn"},
+		{"cn-northwest-1", "aws-cn", "cn-northwest-1", "https://route53.amazonaws.com.cn"},
+	}
+	for _, test := range tests {
+		p, err := partitionFor(test.region)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.region, err)
+			continue
+		}
+		if p.id != test.expectPartition || p.route53Region != test.expectRegion || p.route53Endpoint != test.expectEndpoint {
+			t.Errorf("%s: expected partition %s with Route53 region %s and endpoint %q, got %+v", test.region, test.expectPartition, test.expectRegion, test.expectEndpoint, p)
+		}
+	}
+
+	if _, err := partitionFor("mars-central-1"); err == nil {
+		t.Errorf("expected an error for an unknown region")
+	}
+}
+
+func TestGetZoneIDPartition(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"PaginationToken":"","ResourceTagMappingList":[{"ResourceARN":"arn:aws-cn:route53:::hostedzone/Z1"}]}`))
+	}))
+	defer server.Close()
+
+	sess, err := session.NewSession(aws.NewConfig().
+		WithCredentials(credentials.NewStaticCredentials("id", "key", "")).
+		WithRegion("cn-northwest-1").
+		WithEndpoint(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	zone := configv1.DNSZone{Tags: map[string]string{"Name": "private"}}
+
+	m := &Manager{
+		tags:      resourcegroupstaggingapi.New(sess),
+		partition: "aws-cn",
+		idsToTags: map[string]map[string]string{},
+	}
+	id, err := m.getZoneID(zone)
+	if err != nil {
+		t.Fatalf("failed to get zone ID: %v", err)
+	}
+	if id != "Z1" {
+		t.Errorf("expected zone ID Z1, got %s", id)
+	}
+
+	m = &Manager{
+		tags:      resourcegroupstaggingapi.New(sess),
+		partition: "aws",
+		idsToTags: map[string]map[string]string{},
+	}
+	if _, err := m.getZoneID(zone); err == nil {
+		t.Errorf("expected an error for a hosted zone in another partition")
+	}
+}
+
+const weightedRecordSetsPage1 = `<?xml version="1.0" encoding="UTF-8"?>
+<ListResourceRecordSetsResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/">
+  <ResourceRecordSets>
+    <ResourceRecordSet>
+      <Name>\052.apps.example.com.</Name>
+      <Type>AAAA</Type>
+      <SetIdentifier>cluster-0</SetIdentifier>
+      <Weight>100</Weight>
+      <TTL>300</TTL>
+      <ResourceRecords>
+        <ResourceRecord><Value>2001:db8::ff</Value></ResourceRecord>
+      </ResourceRecords>
+    </ResourceRecordSet>
+  </ResourceRecordSets>
+  <IsTruncated>true</IsTruncated>
+  <NextRecordName>\052.apps.example.com.</NextRecordName>
+  <NextRecordType>AAAA</NextRecordType>
+  <NextRecordIdentifier>cluster-1</NextRecordIdentifier>
+  <MaxItems>1</MaxItems>
+</ListResourceRecordSetsResponse>`
+
+const weightedRecordSetsPage2 = `<?xml version="1.0" encoding="UTF-8"?>
+<ListResourceRecordSetsResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/">
+  <ResourceRecordSets>
+    <ResourceRecordSet>
+      <Name>\052.apps.example.com.</Name>
+      <Type>AAAA</Type>
+      <SetIdentifier>cluster-1</SetIdentifier>
+      <Weight>100</Weight>
+      <TTL>300</TTL>
+      <ResourceRecords>
+        <ResourceRecord><Value>2001:db8::1</Value></ResourceRecord>
+      </ResourceRecords>
+      <HealthCheckId>HC1</HealthCheckId>
+    </ResourceRecordSet>
+    <ResourceRecordSet>
+      <Name>\052.apps.example.com.</Name>
+      <Type>AAAA</Type>
+      <SetIdentifier>cluster-2</SetIdentifier>
+      <Weight>100</Weight>
+      <TTL>300</TTL>
+      <ResourceRecords>
+        <ResourceRecord><Value>2001:db8::2</Value></ResourceRecord>
+      </ResourceRecords>
+    </ResourceRecordSet>
+    <ResourceRecordSet>
+      <Name>other.example.com.</Name>
+      <Type>AAAA</Type>
+      <TTL>300</TTL>
+      <ResourceRecords>
+        <ResourceRecord><Value>2001:db8::3</Value></ResourceRecord>
+      </ResourceRecords>
+    </ResourceRecordSet>
+  </ResourceRecordSets>
+  <IsTruncated>false</IsTruncated>
+  <MaxItems>10</MaxItems>
+</ListResourceRecordSetsResponse>`
+
+const healthCheck = `<HealthCheck><Id>%s</Id><CallerReference>ref</CallerReference><HealthCheckConfig><IPAddress>2001:db8::1</IPAddress><Port>%d</Port><Type>HTTP</Type><ResourcePath>%s</ResourcePath></HealthCheckConfig><HealthCheckVersion>1</HealthCheckVersion></HealthCheck>`
+
+func TestRoutingPolicy(t *testing.T) {
+	var changeSets []string
+	var createdHealthChecks, deletedHealthChecks []string
+	reads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/2013-04-01/hostedzone/Z1/rrset/":
+			body, _ := ioutil.ReadAll(r.Body)
+			changeSets = append(changeSets, string(body))
+			fmt.Fprintf(w, `<ChangeResourceRecordSetsResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/">`+changeInfo+`</ChangeResourceRecordSetsResponse>`, "INSYNC")
+		case r.Method == http.MethodGet && r.URL.Path == "/2013-04-01/hostedzone/Z1/rrset":
+			reads++
+			if r.URL.Query().Get("identifier") == "cluster-1" {
+				w.Write([]byte(weightedRecordSetsPage2))
+			} else {
+				w.Write([]byte(weightedRecordSetsPage1))
+			}
+		case r.Method == http.MethodGet && r.URL.Path == "/2013-04-01/healthcheck/HC1":
+			// The current health check is an HTTP check of another port.
+			fmt.Fprintf(w, `<GetHealthCheckResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/">`+healthCheck+`</GetHealthCheckResponse>`, "HC1", 1936, "/healthz")
+		case r.Method == http.MethodPost && r.URL.Path == "/2013-04-01/healthcheck":
+			body, _ := ioutil.ReadAll(r.Body)
+			createdHealthChecks = append(createdHealthChecks, string(body))
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `<CreateHealthCheckResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/">`+healthCheck+`</CreateHealthCheckResponse>`, "HC2", 443, "")
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/2013-04-01/healthcheck/"):
+			deletedHealthChecks = append(deletedHealthChecks, strings.TrimPrefix(r.URL.Path, "/2013-04-01/healthcheck/"))
+			w.Write([]byte(`<DeleteHealthCheckResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/"></DeleteHealthCheckResponse>`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	sess, err := session.NewSession(aws.NewConfig().
+		WithCredentials(credentials.NewStaticCredentials("id", "key", "")).
+		WithRegion("us-east-1").
+		WithEndpoint(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	m := &Manager{
+		route53:        route53.New(sess),
+		updatedRecords: map[string]time.Time{},
+		pendingChanges: map[string]string{},
+	}
+
+	record := &dns.Record{
+		Zone:       configv1.DNSZone{ID: "Z1"},
+		Type:       dns.AAAARecordType,
+		AAAARecord: &dns.AAAARecord{Domain: "*.apps.example.com", Address: "2001:db8::1"},
+		RoutingPolicy: &dns.RoutingPolicy{
+			Type:          dns.WeightedRoutingPolicy,
+			SetIdentifier: "cluster-1",
+			Weight:        50,
+			HealthCheck:   &dns.HealthCheck{Port: 443},
+		},
+	}
+
+	// Get finds the record with the same set identifier on the second
+	// page and reports that its weight differs.
+	current, err := m.Get(record)
+	if err != nil {
+		t.Fatalf("failed to get record: %v", err)
+	}
+	expectPolicy := dns.RoutingPolicy{Type: dns.WeightedRoutingPolicy, SetIdentifier: "cluster-1", Weight: 100}
+	if current == nil || current.AAAARecord.Address != "2001:db8::1" || current.RoutingPolicy == nil || *current.RoutingPolicy != expectPolicy {
+		t.Fatalf("expected the record of cluster-1 with policy %v, got %v", &expectPolicy, current)
+	}
+
+	// Ensure replaces the health check with a TCP check of port 443 and
+	// then deletes it.
+	if err := m.Ensure(record); err != nil {
+		t.Fatalf("failed to ensure record: %v", err)
+	}
+	if len(createdHealthChecks) != 1 || !strings.Contains(createdHealthChecks[0], "<Type>TCP</Type>") || !strings.Contains(createdHealthChecks[0], "<Port>443</Port>") || strings.Contains(createdHealthChecks[0], "<ResourcePath>") || !strings.Contains(createdHealthChecks[0], "<IPAddress>2001:db8::1</IPAddress>") {
+		t.Errorf("expected a TCP health check of 2001:db8::1 port 443, got %v", createdHealthChecks)
+	}
+	if len(changeSets) != 1 {
+		t.Fatalf("expected 1 change set, got %d", len(changeSets))
+	}
+	for _, expect := range []string{"<Action>UPSERT</Action>", "<SetIdentifier>cluster-1</SetIdentifier>", "<Weight>50</Weight>", "<HealthCheckId>HC2</HealthCheckId>"} {
+		if !strings.Contains(changeSets[0], expect) {
+			t.Errorf("expected change set to contain %s, got %s", expect, changeSets[0])
+		}
+	}
+	if len(deletedHealthChecks) != 1 || deletedHealthChecks[0] != "HC1" {
+		t.Errorf("expected health check HC1 to be deleted, got %v", deletedHealthChecks)
+	}
+
+	// Get returns the record that was just updated without reading it.
+	reads = 0
+	if current, err := m.Get(record); err != nil || current != record || reads != 0 {
+		t.Errorf("expected the updated record without any reads, got %v, %v, and %d reads", current, err, reads)
+	}
+
+	// Delete deletes only the record of cluster-1, along with its health
+	// check.
+	if err := m.Delete(record); err != nil {
+		t.Fatalf("failed to delete record: %v", err)
+	}
+	if len(changeSets) != 2 {
+		t.Fatalf("expected 2 change sets, got %d", len(changeSets))
+	}
+	if n := strings.Count(changeSets[1], "<Change>"); n != 1 || !strings.Contains(changeSets[1], "<Action>DELETE</Action>") || !strings.Contains(changeSets[1], "<SetIdentifier>cluster-1</SetIdentifier>") {
+		t.Errorf("expected 1 deletion of the record of cluster-1, got %s", changeSets[1])
+	}
+	if len(deletedHealthChecks) != 2 || deletedHealthChecks[1] != "HC1" {
+		t.Errorf("expected health check HC1 to be deleted, got %v", deletedHealthChecks)
+	}
+
+	// A record with a set identifier that doesn't exist is not found.
+	missing := *record
+	missing.RoutingPolicy = &dns.RoutingPolicy{Type: dns.WeightedRoutingPolicy, SetIdentifier: "cluster-3", Weight: 100}
+	if current, err := m.Get(&missing); err != nil || current != nil {
+		t.Errorf("expected no record for cluster-3, got %v, %v", current, err)
+	}
+}
@@ -1,27 +1,80 @@
 package client
 
 import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
 	"github.com/Azure/go-autorest/autorest"
 	"github.com/Azure/go-autorest/autorest/adal"
 	"github.com/Azure/go-autorest/autorest/azure"
 )
 
-func getAuthorizerForResource(config Config) (autorest.Authorizer, error) {
-	env, err := azure.EnvironmentFromName(config.Environment)
-	if err != nil {
-		return nil, err
-	}
+// clientAssertionType is the type of the client assertion with which a
+// federated workload identity authenticates.
+const clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
 
-	oauthConfig, err := adal.NewOAuthConfig(
-		env.ActiveDirectoryEndpoint, config.TenantID)
-	if err != nil {
-		return nil, err
+// getAuthorizerForResource returns an authorizer for env's resource manager.
+// If a federated token file is configured, the authorizer exchanges the token
+// for the configured client's credentials (workload identity). Otherwise, if a
+// client secret is configured, the authorizer authenticates as the service
+// principal. Otherwise, the authorizer uses the managed identity of the host,
+// or the user-assigned managed identity with the configured client ID.
+func getAuthorizerForResource(config Config, env azure.Environment) (autorest.Authorizer, error) {
+	var token *adal.ServicePrincipalToken
+	switch {
+	case len(config.FederatedTokenFile) > 0:
+		oauthConfig, err := adal.NewOAuthConfig(env.ActiveDirectoryEndpoint, config.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		token, err = adal.NewServicePrincipalTokenWithSecret(
+			*oauthConfig, config.ClientID, env.ResourceManagerEndpoint, &federatedTokenSecret{tokenFile: config.FederatedTokenFile})
+		if err != nil {
+			return nil, err
+		}
+	case len(config.ClientSecret) > 0:
+		oauthConfig, err := adal.NewOAuthConfig(env.ActiveDirectoryEndpoint, config.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		token, err = adal.NewServicePrincipalToken(
+			*oauthConfig, config.ClientID, config.ClientSecret, env.ResourceManagerEndpoint)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		msiEndpoint, err := adal.GetMSIVMEndpoint()
+		if err != nil {
+			return nil, err
+		}
+		if len(config.ClientID) > 0 {
+			token, err = adal.NewServicePrincipalTokenFromMSIWithUserAssignedID(msiEndpoint, env.ResourceManagerEndpoint, config.ClientID)
+		} else {
+			token, err = adal.NewServicePrincipalTokenFromMSI(msiEndpoint, env.ResourceManagerEndpoint)
+		}
+		if err != nil {
+			return nil, err
+		}
 	}
+	return autorest.NewBearerAuthorizer(token), nil
+}
+
+// federatedTokenSecret is an adal.ServicePrincipalSecret that authenticates
+// with a federated token, such as a projected service account token, which it
+// reads from a file every time a token is acquired so that rotated tokens are
+// picked up.
+type federatedTokenSecret struct {
+	tokenFile string
+}
 
-	token, err := adal.NewServicePrincipalToken(
-		*oauthConfig, config.ClientID, config.ClientSecret, env.ResourceManagerEndpoint)
+func (s *federatedTokenSecret) SetAuthenticationValues(spt *adal.ServicePrincipalToken, v *url.Values) error {
+	token, err := ioutil.ReadFile(s.tokenFile)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to read federated token file %s: %v", s.tokenFile, err)
 	}
-	return autorest.NewBearerAuthorizer(token), err
+	v.Set("client_assertion_type", clientAssertionType)
+	v.Set("client_assertion", strings.TrimSpace(string(token)))
+	return nil
 }
@@ -2,22 +2,64 @@ package client
 
 import (
 	"context"
+	"net/http"
+	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/services/dns/mgmt/2017-10-01/dns"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/pkg/errors"
 )
 
 type DNSClient interface {
+	// Put adds the address of arec to the A record set with arec's name.
 	Put(ctx context.Context, zone Zone, arec ARecord) error
+	// Delete removes the address of arec from the A record set with arec's
+	// name.
 	Delete(ctx context.Context, zone Zone, arec ARecord) error
+	// Get returns the A record set with the given relative name in zone, or
+	// nil if no such record set exists.
+	Get(ctx context.Context, zone Zone, name string) (*ARecordSet, error)
+
+	// PutAAAA adds the address of aaaa to the AAAA record set with aaaa's
+	// name.
+	PutAAAA(ctx context.Context, zone Zone, aaaa AAAARecord) error
+	// DeleteAAAA removes the address of aaaa from the AAAA record set with
+	// aaaa's name.
+	DeleteAAAA(ctx context.Context, zone Zone, aaaa AAAARecord) error
+	// GetAAAA returns the AAAA record set with the given relative name in
+	// zone, or nil if no such record set exists.
+	GetAAAA(ctx context.Context, zone Zone, name string) (*AAAARecordSet, error)
+
+	PutCNAME(ctx context.Context, zone Zone, cname CNAMERecord) error
+	DeleteCNAME(ctx context.Context, zone Zone, cname CNAMERecord) error
+	// GetCNAME returns the CNAME record with the given relative name in
+	// zone, or nil if no such record exists.
+	GetCNAME(ctx context.Context, zone Zone, name string) (*CNAMERecord, error)
+
+	PutTXT(ctx context.Context, zone Zone, txt TXTRecord) error
+	DeleteTXT(ctx context.Context, zone Zone, txt TXTRecord) error
+	// GetTXT returns the TXT record with the given relative name in zone, or
+	// nil if no such record exists.
+	GetTXT(ctx context.Context, zone Zone, name string) (*TXTRecord, error)
+
+	// ListZones returns the DNS zones in the subscription.
+	ListZones(ctx context.Context) ([]TaggedZone, error)
 }
 
 type Config struct {
+	// Environment is the name of the azure cloud environment, such as
+	// "AzurePublicCloud" or "AzureUSGovernmentCloud".
 	Environment    string
 	SubscriptionID string
 	ClientID       string
 	ClientSecret   string
 	TenantID       string
+	// FederatedTokenFile is the path to a federated token, such as a
+	// projected service account token, with which to authenticate as the
+	// client.
+	FederatedTokenFile string
 }
 
 // ARecord is a DNS A record.
@@ -32,6 +74,66 @@ type ARecord struct {
 	TTL int64
 }
 
+// ARecordSet is a DNS A record set, which may have several addresses.
+type ARecordSet struct {
+	// Name is the record set name.
+	Name string
+
+	// Addresses are the IPv4 addresses of the record set.
+	Addresses []string
+
+	// TTL is the Time To Live property of the record set.
+	TTL int64
+}
+
+// AAAARecord is a DNS AAAA record.
+type AAAARecord struct {
+	// Name is the record name.
+	Name string
+
+	// Address is the IPv6 address of the AAAA record.
+	Address string
+
+	// TTL is the Time To Live property of the AAAA record.
+	TTL int64
+}
+
+// AAAARecordSet is a DNS AAAA record set, which may have several addresses.
+type AAAARecordSet struct {
+	// Name is the record set name.
+	Name string
+
+	// Addresses are the IPv6 addresses of the record set.
+	Addresses []string
+
+	// TTL is the Time To Live property of the record set.
+	TTL int64
+}
+
+// CNAMERecord is a DNS CNAME record.
+type CNAMERecord struct {
+	// Name is the record name.
+	Name string
+
+	// Target is the canonical name of the CNAME record.
+	Target string
+
+	// TTL is the Time To Live property of the CNAME record.
+	TTL int64
+}
+
+// TXTRecord is a DNS TXT record.
+type TXTRecord struct {
+	// Name is the record name.
+	Name string
+
+	// Text is the text of the TXT record.
+	Text string
+
+	// TTL is the Time To Live property of the TXT record.
+	TTL int64
+}
+
 type dnsClient struct {
 	zones      dns.ZonesClient
 	recordSets dns.RecordSetsClient
@@ -40,44 +142,339 @@ type dnsClient struct {
 
 // New returns an authenticated DNSClient
 func New(config Config, userAgentExtension string) (DNSClient, error) {
-	authorizer, err := getAuthorizerForResource(config)
+	env, err := azure.EnvironmentFromName(config.Environment)
 	if err != nil {
 		return nil, err
 	}
-	zc := dns.NewZonesClient(config.SubscriptionID)
+	authorizer, err := getAuthorizerForResource(config, env)
+	if err != nil {
+		return nil, err
+	}
+	baseURI := strings.TrimSuffix(env.ResourceManagerEndpoint, "/")
+	zc := dns.NewZonesClientWithBaseURI(baseURI, config.SubscriptionID)
 	zc.AddToUserAgent(userAgentExtension)
 	zc.Authorizer = authorizer
-	rc := dns.NewRecordSetsClient(config.SubscriptionID)
+	rc := dns.NewRecordSetsClientWithBaseURI(baseURI, config.SubscriptionID)
 	rc.AddToUserAgent(userAgentExtension)
 	rc.Authorizer = authorizer
 	return &dnsClient{zones: zc, recordSets: rc, config: config}, nil
 }
 
+// Put adds the address of arec to the A record set with arec's name, which is
+// created if it doesn't exist, and sets the record set's TTL to arec's.
 func (c *dnsClient) Put(ctx context.Context, zone Zone, arec ARecord) error {
+	current, err := c.recordSets.Get(ctx, zone.ResourceGroup, zone.Name, arec.Name, dns.A)
+	if err != nil && !isNotFound(err) {
+		return errors.Wrapf(err, "failed to get dns a record: %s.%s", arec.Name, zone.Name)
+	}
+	var records []dns.ARecord
+	if err == nil && current.RecordSetProperties != nil && current.ARecords != nil {
+		records = *current.ARecords
+	}
+	found := false
+	for _, r := range records {
+		if r.Ipv4Address != nil && *r.Ipv4Address == arec.Address {
+			found = true
+		}
+	}
+	if found && current.TTL != nil && *current.TTL == arec.TTL {
+		return nil
+	}
+	if !found {
+		records = append(records, dns.ARecord{Ipv4Address: to.StringPtr(arec.Address)})
+	}
 	rs := dns.RecordSet{
 		RecordSetProperties: &dns.RecordSetProperties{
-			TTL: &arec.TTL,
-			ARecords: &[]dns.ARecord{
-				{Ipv4Address: &arec.Address},
-			},
+			TTL:      to.Int64Ptr(arec.TTL),
+			ARecords: &records,
 		},
 	}
-	_, err := c.recordSets.CreateOrUpdate(ctx, zone.ResourceGroup, zone.Name, arec.Name, dns.A, rs, "", "")
-	if err != nil {
+	ifMatch, ifNoneMatch := recordSetPreconditions(current, err)
+	if _, err := c.recordSets.CreateOrUpdate(ctx, zone.ResourceGroup, zone.Name, arec.Name, dns.A, rs, ifMatch, ifNoneMatch); err != nil {
 		return errors.Wrapf(err, "failed to update dns a record: %s.%s", arec.Name, zone.Name)
 	}
 	return nil
 }
 
+// Delete removes the address of arec from the A record set with arec's name,
+// which is deleted if no other addresses remain.
 func (c *dnsClient) Delete(ctx context.Context, zone Zone, arec ARecord) error {
-	_, err := c.recordSets.Get(ctx, zone.ResourceGroup, zone.Name, arec.Name, dns.A)
+	current, err := c.recordSets.Get(ctx, zone.ResourceGroup, zone.Name, arec.Name, dns.A)
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get dns a record: %s.%s", arec.Name, zone.Name)
+	}
+	var records, remaining []dns.ARecord
+	if current.RecordSetProperties != nil && current.ARecords != nil {
+		records = *current.ARecords
+	}
+	for _, r := range records {
+		if r.Ipv4Address == nil || *r.Ipv4Address != arec.Address {
+			remaining = append(remaining, r)
+		}
+	}
+	if len(remaining) == len(records) && len(records) > 0 {
+		return nil
+	}
+	if len(remaining) == 0 {
+		if _, err := c.recordSets.Delete(ctx, zone.ResourceGroup, zone.Name, arec.Name, dns.A, to.String(current.Etag)); err != nil && !isNotFound(err) {
+			return errors.Wrapf(err, "failed to delete dns a record: %s.%s", arec.Name, zone.Name)
+		}
+		return nil
+	}
+	current.ARecords = &remaining
+	if _, err := c.recordSets.CreateOrUpdate(ctx, zone.ResourceGroup, zone.Name, arec.Name, dns.A, current, to.String(current.Etag), ""); err != nil {
+		return errors.Wrapf(err, "failed to update dns a record: %s.%s", arec.Name, zone.Name)
+	}
+	return nil
+}
+
+func (c *dnsClient) Get(ctx context.Context, zone Zone, name string) (*ARecordSet, error) {
+	rs, err := c.recordSets.Get(ctx, zone.ResourceGroup, zone.Name, name, dns.A)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get dns a record: %s.%s", name, zone.Name)
+	}
+	if rs.RecordSetProperties == nil || rs.ARecords == nil || len(*rs.ARecords) == 0 {
+		return nil, nil
+	}
+	set := &ARecordSet{Name: name}
+	for _, r := range *rs.ARecords {
+		if r.Ipv4Address != nil {
+			set.Addresses = append(set.Addresses, *r.Ipv4Address)
+		}
+	}
+	if rs.TTL != nil {
+		set.TTL = *rs.TTL
+	}
+	return set, nil
+}
+
+// PutAAAA adds the address of aaaa to the AAAA record set with aaaa's name,
+// which is created if it doesn't exist, and sets the record set's TTL to
+// aaaa's.
+func (c *dnsClient) PutAAAA(ctx context.Context, zone Zone, aaaa AAAARecord) error {
+	current, err := c.recordSets.Get(ctx, zone.ResourceGroup, zone.Name, aaaa.Name, dns.AAAA)
+	if err != nil && !isNotFound(err) {
+		return errors.Wrapf(err, "failed to get dns aaaa record: %s.%s", aaaa.Name, zone.Name)
+	}
+	var records []dns.AaaaRecord
+	if err == nil && current.RecordSetProperties != nil && current.AaaaRecords != nil {
+		records = *current.AaaaRecords
+	}
+	found := false
+	for _, r := range records {
+		if r.Ipv6Address != nil && *r.Ipv6Address == aaaa.Address {
+			found = true
+		}
+	}
+	if found && current.TTL != nil && *current.TTL == aaaa.TTL {
+		return nil
+	}
+	if !found {
+		records = append(records, dns.AaaaRecord{Ipv6Address: to.StringPtr(aaaa.Address)})
+	}
+	rs := dns.RecordSet{
+		RecordSetProperties: &dns.RecordSetProperties{
+			TTL:         to.Int64Ptr(aaaa.TTL),
+			AaaaRecords: &records,
+		},
+	}
+	ifMatch, ifNoneMatch := recordSetPreconditions(current, err)
+	if _, err := c.recordSets.CreateOrUpdate(ctx, zone.ResourceGroup, zone.Name, aaaa.Name, dns.AAAA, rs, ifMatch, ifNoneMatch); err != nil {
+		return errors.Wrapf(err, "failed to update dns aaaa record: %s.%s", aaaa.Name, zone.Name)
+	}
+	return nil
+}
+
+// DeleteAAAA removes the address of aaaa from the AAAA record set with aaaa's
+// name, which is deleted if no other addresses remain.
+func (c *dnsClient) DeleteAAAA(ctx context.Context, zone Zone, aaaa AAAARecord) error {
+	current, err := c.recordSets.Get(ctx, zone.ResourceGroup, zone.Name, aaaa.Name, dns.AAAA)
 	if err != nil {
-		// TODO: How do we interpret this as a notfound error?
+		if isNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get dns aaaa record: %s.%s", aaaa.Name, zone.Name)
+	}
+	var records, remaining []dns.AaaaRecord
+	if current.RecordSetProperties != nil && current.AaaaRecords != nil {
+		records = *current.AaaaRecords
+	}
+	for _, r := range records {
+		if r.Ipv6Address == nil || *r.Ipv6Address != aaaa.Address {
+			remaining = append(remaining, r)
+		}
+	}
+	if len(remaining) == len(records) && len(records) > 0 {
+		return nil
+	}
+	if len(remaining) == 0 {
+		if _, err := c.recordSets.Delete(ctx, zone.ResourceGroup, zone.Name, aaaa.Name, dns.AAAA, to.String(current.Etag)); err != nil && !isNotFound(err) {
+			return errors.Wrapf(err, "failed to delete dns aaaa record: %s.%s", aaaa.Name, zone.Name)
+		}
 		return nil
 	}
-	_, err = c.recordSets.Delete(ctx, zone.ResourceGroup, zone.Name, arec.Name, dns.A, "")
+	current.AaaaRecords = &remaining
+	if _, err := c.recordSets.CreateOrUpdate(ctx, zone.ResourceGroup, zone.Name, aaaa.Name, dns.AAAA, current, to.String(current.Etag), ""); err != nil {
+		return errors.Wrapf(err, "failed to update dns aaaa record: %s.%s", aaaa.Name, zone.Name)
+	}
+	return nil
+}
+
+func (c *dnsClient) GetAAAA(ctx context.Context, zone Zone, name string) (*AAAARecordSet, error) {
+	rs, err := c.recordSets.Get(ctx, zone.ResourceGroup, zone.Name, name, dns.AAAA)
 	if err != nil {
-		return errors.Wrapf(err, "failed to delete dns a record: %s.%s", arec.Name, zone.Name)
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get dns aaaa record: %s.%s", name, zone.Name)
+	}
+	if rs.RecordSetProperties == nil || rs.AaaaRecords == nil || len(*rs.AaaaRecords) == 0 {
+		return nil, nil
+	}
+	set := &AAAARecordSet{Name: name}
+	for _, r := range *rs.AaaaRecords {
+		if r.Ipv6Address != nil {
+			set.Addresses = append(set.Addresses, *r.Ipv6Address)
+		}
+	}
+	if rs.TTL != nil {
+		set.TTL = *rs.TTL
+	}
+	return set, nil
+}
+
+// recordSetPreconditions returns the If-Match and If-None-Match values with
+// which to update a record set that was read with the given result, so that
+// the update fails rather than overwrite concurrent changes to the record
+// set.
+func recordSetPreconditions(current dns.RecordSet, getErr error) (string, string) {
+	if getErr != nil {
+		return "", "*"
+	}
+	return to.String(current.Etag), ""
+}
+
+// isNotFound returns true if err is an error response with status 404.
+func isNotFound(err error) bool {
+	derr, ok := err.(autorest.DetailedError)
+	return ok && derr.StatusCode == http.StatusNotFound
+}
+
+func (c *dnsClient) PutCNAME(ctx context.Context, zone Zone, cname CNAMERecord) error {
+	rs := dns.RecordSet{
+		RecordSetProperties: &dns.RecordSetProperties{
+			TTL:         &cname.TTL,
+			CnameRecord: &dns.CnameRecord{Cname: &cname.Target},
+		},
+	}
+	_, err := c.recordSets.CreateOrUpdate(ctx, zone.ResourceGroup, zone.Name, cname.Name, dns.CNAME, rs, "", "")
+	if err != nil {
+		return errors.Wrapf(err, "failed to update dns cname record: %s.%s", cname.Name, zone.Name)
 	}
 	return nil
 }
+
+func (c *dnsClient) DeleteCNAME(ctx context.Context, zone Zone, cname CNAMERecord) error {
+	_, err := c.recordSets.Delete(ctx, zone.ResourceGroup, zone.Name, cname.Name, dns.CNAME, "")
+	if err != nil {
+		if derr, ok := err.(autorest.DetailedError); ok && derr.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to delete dns cname record: %s.%s", cname.Name, zone.Name)
+	}
+	return nil
+}
+
+func (c *dnsClient) GetCNAME(ctx context.Context, zone Zone, name string) (*CNAMERecord, error) {
+	rs, err := c.recordSets.Get(ctx, zone.ResourceGroup, zone.Name, name, dns.CNAME)
+	if err != nil {
+		if derr, ok := err.(autorest.DetailedError); ok && derr.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get dns cname record: %s.%s", name, zone.Name)
+	}
+	if rs.RecordSetProperties == nil || rs.CnameRecord == nil || rs.CnameRecord.Cname == nil {
+		return nil, nil
+	}
+	cname := &CNAMERecord{Name: name, Target: *rs.CnameRecord.Cname}
+	if rs.TTL != nil {
+		cname.TTL = *rs.TTL
+	}
+	return cname, nil
+}
+
+func (c *dnsClient) PutTXT(ctx context.Context, zone Zone, txt TXTRecord) error {
+	rs := dns.RecordSet{
+		RecordSetProperties: &dns.RecordSetProperties{
+			TTL: &txt.TTL,
+			TxtRecords: &[]dns.TxtRecord{
+				{Value: &[]string{txt.Text}},
+			},
+		},
+	}
+	_, err := c.recordSets.CreateOrUpdate(ctx, zone.ResourceGroup, zone.Name, txt.Name, dns.TXT, rs, "", "")
+	if err != nil {
+		return errors.Wrapf(err, "failed to update dns txt record: %s.%s", txt.Name, zone.Name)
+	}
+	return nil
+}
+
+func (c *dnsClient) DeleteTXT(ctx context.Context, zone Zone, txt TXTRecord) error {
+	_, err := c.recordSets.Delete(ctx, zone.ResourceGroup, zone.Name, txt.Name, dns.TXT, "")
+	if err != nil {
+		if derr, ok := err.(autorest.DetailedError); ok && derr.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to delete dns txt record: %s.%s", txt.Name, zone.Name)
+	}
+	return nil
+}
+
+func (c *dnsClient) GetTXT(ctx context.Context, zone Zone, name string) (*TXTRecord, error) {
+	rs, err := c.recordSets.Get(ctx, zone.ResourceGroup, zone.Name, name, dns.TXT)
+	if err != nil {
+		if derr, ok := err.(autorest.DetailedError); ok && derr.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get dns txt record: %s.%s", name, zone.Name)
+	}
+	if rs.RecordSetProperties == nil || rs.TxtRecords == nil || len(*rs.TxtRecords) == 0 {
+		return nil, nil
+	}
+	txt := &TXTRecord{Name: name}
+	if value := (*rs.TxtRecords)[0].Value; value != nil {
+		txt.Text = strings.Join(*value, "")
+	}
+	if rs.TTL != nil {
+		txt.TTL = *rs.TTL
+	}
+	return txt, nil
+}
+
+func (c *dnsClient) ListZones(ctx context.Context) ([]TaggedZone, error) {
+	var zones []TaggedZone
+	iter, err := c.zones.ListComplete(ctx, nil)
+	for ; err == nil && iter.NotDone(); err = iter.NextWithContext(ctx) {
+		zone := iter.Value()
+		if zone.ID == nil {
+			continue
+		}
+		tags := map[string]string{}
+		for k, v := range zone.Tags {
+			if v != nil {
+				tags[k] = *v
+			}
+		}
+		zones = append(zones, TaggedZone{ID: *zone.ID, Tags: tags})
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list dns zones")
+	}
+	return zones, nil
+}
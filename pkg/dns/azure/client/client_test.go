@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/dns/mgmt/2017-10-01/dns"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+// fakeRecordSetsServer is an Azure Resource Manager server that stores the
+// record sets that it is sent, keyed by URL path.
+type fakeRecordSetsServer struct {
+	lock       sync.Mutex
+	recordSets map[string]dns.RecordSet
+	etag       int
+}
+
+func (s *fakeRecordSetsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	path := strings.ToLower(r.URL.Path)
+	current, exists := s.recordSets[path]
+	if exists && len(r.Header.Get("If-None-Match")) > 0 {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	if ifMatch := r.Header.Get("If-Match"); len(ifMatch) > 0 && (!exists || ifMatch != to.String(current.Etag)) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":"NotFound","message":"not found"}}`))
+			return
+		}
+		json.NewEncoder(w).Encode(current)
+	case http.MethodPut:
+		body, _ := ioutil.ReadAll(r.Body)
+		var rs dns.RecordSet
+		if err := json.Unmarshal(body, &rs); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.etag++
+		rs.Etag = to.StringPtr(strconv.Itoa(s.etag))
+		s.recordSets[path] = rs
+		json.NewEncoder(w).Encode(rs)
+	case http.MethodDelete:
+		delete(s.recordSets, path)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func TestARecordSet(t *testing.T) {
+	server := &fakeRecordSetsServer{recordSets: map[string]dns.RecordSet{}}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	c := &dnsClient{recordSets: dns.NewRecordSetsClientWithBaseURI(ts.URL, "subscription")}
+	zone := Zone{SubscriptionID: "subscription", ResourceGroup: "test-rg", Name: "dnszone.io"}
+	ctx := context.TODO()
+
+	expectAddresses := func(expect []string, ttl int64) {
+		t.Helper()
+		set, err := c.Get(ctx, zone, "subdomain")
+		if err != nil {
+			t.Fatalf("failed to get record set: %v", err)
+		}
+		if len(expect) == 0 {
+			if set != nil {
+				t.Fatalf("expected no record set, got %v", set)
+			}
+			return
+		}
+		if set == nil {
+			t.Fatalf("expected record set with addresses %v, got none", expect)
+		}
+		if !reflect.DeepEqual(set.Addresses, expect) || set.TTL != ttl {
+			t.Fatalf("expected addresses %v with TTL %d, got %v with TTL %d", expect, ttl, set.Addresses, set.TTL)
+		}
+	}
+
+	for _, address := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.1"} {
+		if err := c.Put(ctx, zone, ARecord{Name: "subdomain", Address: address, TTL: 30}); err != nil {
+			t.Fatalf("failed to put %s: %v", address, err)
+		}
+	}
+	expectAddresses([]string{"10.0.0.1", "10.0.0.2"}, 30)
+
+	if err := c.Put(ctx, zone, ARecord{Name: "subdomain", Address: "10.0.0.2", TTL: 60}); err != nil {
+		t.Fatalf("failed to put: %v", err)
+	}
+	expectAddresses([]string{"10.0.0.1", "10.0.0.2"}, 60)
+
+	if err := c.Delete(ctx, zone, ARecord{Name: "subdomain", Address: "10.0.0.3"}); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	expectAddresses([]string{"10.0.0.1", "10.0.0.2"}, 60)
+
+	if err := c.Delete(ctx, zone, ARecord{Name: "subdomain", Address: "10.0.0.1"}); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	expectAddresses([]string{"10.0.0.2"}, 60)
+
+	if err := c.Delete(ctx, zone, ARecord{Name: "subdomain", Address: "10.0.0.2"}); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	expectAddresses(nil, 0)
+
+	if err := c.Delete(ctx, zone, ARecord{Name: "subdomain", Address: "10.0.0.2"}); err != nil {
+		t.Fatalf("expected deleting a missing record set to succeed, got %v", err)
+	}
+}
@@ -5,24 +5,147 @@ import (
 )
 
 type FakeDNSClient struct {
-	fakeARM map[string]string
+	fakeARM     map[string]string
+	fakeRecords map[string]ARecordSet
+	fakeAAAA    map[string]AAAARecordSet
+	fakeCNAME   map[string]CNAMERecord
+	fakeTXT     map[string]TXTRecord
+	fakeZones   []TaggedZone
 }
 
 func NewFake(config Config) (*FakeDNSClient, error) {
-	return &FakeDNSClient{fakeARM: map[string]string{}}, nil
+	return &FakeDNSClient{fakeARM: map[string]string{}, fakeRecords: map[string]ARecordSet{}, fakeAAAA: map[string]AAAARecordSet{}, fakeCNAME: map[string]CNAMERecord{}, fakeTXT: map[string]TXTRecord{}}, nil
 }
 
 func (c *FakeDNSClient) Put(ctx context.Context, zone Zone, arec ARecord) error {
-	c.fakeARM[zone.ResourceGroup+zone.Name+arec.Name] = "PUT"
+	key := zone.ResourceGroup + zone.Name + arec.Name
+	c.fakeARM[key] = "PUT"
+	set := c.fakeRecords[key]
+	set.Name, set.TTL, set.Addresses = arec.Name, arec.TTL, addAddress(set.Addresses, arec.Address)
+	c.fakeRecords[key] = set
 	return nil
 }
 
 func (c *FakeDNSClient) Delete(ctx context.Context, zone Zone, arec ARecord) error {
-	c.fakeARM[zone.ResourceGroup+zone.Name+arec.Name] = "DELETE"
+	key := zone.ResourceGroup + zone.Name + arec.Name
+	c.fakeARM[key] = "DELETE"
+	if set, ok := c.fakeRecords[key]; ok {
+		if set.Addresses = removeAddress(set.Addresses, arec.Address); len(set.Addresses) == 0 {
+			delete(c.fakeRecords, key)
+		} else {
+			c.fakeRecords[key] = set
+		}
+	}
 	return nil
 }
 
+func (c *FakeDNSClient) Get(ctx context.Context, zone Zone, name string) (*ARecordSet, error) {
+	if set, ok := c.fakeRecords[zone.ResourceGroup+zone.Name+name]; ok {
+		return &set, nil
+	}
+	return nil, nil
+}
+
+func (c *FakeDNSClient) PutAAAA(ctx context.Context, zone Zone, aaaa AAAARecord) error {
+	key := zone.ResourceGroup + zone.Name + aaaa.Name
+	c.fakeARM[key] = "PUT"
+	set := c.fakeAAAA[key]
+	set.Name, set.TTL, set.Addresses = aaaa.Name, aaaa.TTL, addAddress(set.Addresses, aaaa.Address)
+	c.fakeAAAA[key] = set
+	return nil
+}
+
+func (c *FakeDNSClient) DeleteAAAA(ctx context.Context, zone Zone, aaaa AAAARecord) error {
+	key := zone.ResourceGroup + zone.Name + aaaa.Name
+	c.fakeARM[key] = "DELETE"
+	if set, ok := c.fakeAAAA[key]; ok {
+		if set.Addresses = removeAddress(set.Addresses, aaaa.Address); len(set.Addresses) == 0 {
+			delete(c.fakeAAAA, key)
+		} else {
+			c.fakeAAAA[key] = set
+		}
+	}
+	return nil
+}
+
+func (c *FakeDNSClient) GetAAAA(ctx context.Context, zone Zone, name string) (*AAAARecordSet, error) {
+	if set, ok := c.fakeAAAA[zone.ResourceGroup+zone.Name+name]; ok {
+		return &set, nil
+	}
+	return nil, nil
+}
+
+func (c *FakeDNSClient) PutCNAME(ctx context.Context, zone Zone, cname CNAMERecord) error {
+	c.fakeARM[zone.ResourceGroup+zone.Name+cname.Name] = "PUT"
+	c.fakeCNAME[zone.ResourceGroup+zone.Name+cname.Name] = cname
+	return nil
+}
+
+func (c *FakeDNSClient) DeleteCNAME(ctx context.Context, zone Zone, cname CNAMERecord) error {
+	c.fakeARM[zone.ResourceGroup+zone.Name+cname.Name] = "DELETE"
+	delete(c.fakeCNAME, zone.ResourceGroup+zone.Name+cname.Name)
+	return nil
+}
+
+func (c *FakeDNSClient) GetCNAME(ctx context.Context, zone Zone, name string) (*CNAMERecord, error) {
+	if cname, ok := c.fakeCNAME[zone.ResourceGroup+zone.Name+name]; ok {
+		return &cname, nil
+	}
+	return nil, nil
+}
+
+func (c *FakeDNSClient) PutTXT(ctx context.Context, zone Zone, txt TXTRecord) error {
+	c.fakeARM[zone.ResourceGroup+zone.Name+txt.Name] = "PUT"
+	c.fakeTXT[zone.ResourceGroup+zone.Name+txt.Name] = txt
+	return nil
+}
+
+func (c *FakeDNSClient) DeleteTXT(ctx context.Context, zone Zone, txt TXTRecord) error {
+	c.fakeARM[zone.ResourceGroup+zone.Name+txt.Name] = "DELETE"
+	delete(c.fakeTXT, zone.ResourceGroup+zone.Name+txt.Name)
+	return nil
+}
+
+func (c *FakeDNSClient) GetTXT(ctx context.Context, zone Zone, name string) (*TXTRecord, error) {
+	if txt, ok := c.fakeTXT[zone.ResourceGroup+zone.Name+name]; ok {
+		return &txt, nil
+	}
+	return nil, nil
+}
+
+func (c *FakeDNSClient) ListZones(ctx context.Context) ([]TaggedZone, error) {
+	return c.fakeZones, nil
+}
+
+// AddZone adds a zone with the given resource ID and tags to the zones that
+// ListZones returns.
+func (c *FakeDNSClient) AddZone(id string, tags map[string]string) {
+	c.fakeZones = append(c.fakeZones, TaggedZone{ID: id, Tags: tags})
+}
+
 func (c *FakeDNSClient) RecordedCall(rg, zone, rel string) (string, bool) {
 	call, ok := c.fakeARM[rg+zone+rel]
 	return call, ok
 }
+
+// addAddress returns addresses with address appended unless it is already
+// present.
+func addAddress(addresses []string, address string) []string {
+	for _, a := range addresses {
+		if a == address {
+			return addresses
+		}
+	}
+	return append(addresses, address)
+}
+
+// removeAddress returns addresses without address.
+func removeAddress(addresses []string, address string) []string {
+	var remaining []string
+	for _, a := range addresses {
+		if a != address {
+			remaining = append(remaining, a)
+		}
+	}
+	return remaining
+}
@@ -18,3 +18,9 @@ func ParseZone(id string) (*Zone, error) {
 	}
 	return &Zone{SubscriptionID: s[2], ResourceGroup: s[4], Name: s[8]}, nil
 }
+
+// TaggedZone is the resource ID of a DNS zone and the zone's tags.
+type TaggedZone struct {
+	ID   string
+	Tags map[string]string
+}
@@ -3,7 +3,9 @@ package azure
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"strings"
+	"sync"
 
 	configv1 "github.com/openshift/api/config/v1"
 	dns "github.com/openshift/cluster-ingress-operator/pkg/dns"
@@ -12,6 +14,10 @@ import (
 	"github.com/pkg/errors"
 )
 
+// defaultRecordTTL is the TTL, in seconds, of the records created by the
+// manager unless Config.TTL is set.
+const defaultRecordTTL int64 = 300
+
 var (
 	_   dns.Manager = &manager{}
 	log             = logf.Logger.WithName("dns")
@@ -19,38 +25,56 @@ var (
 
 // Config is the necessary input to configure the manager for azure.
 type Config struct {
-	// Environment is the azure cloud environment.
+	// Environment is the azure cloud environment, such as
+	// "AzurePublicCloud", "AzureUSGovernmentCloud", or "AzureChinaCloud".
 	Environment string
-	// ClientID is an azure service principal appID.
+	// ClientID is an azure service principal appID, or the client ID of a
+	// user-assigned managed identity.
 	ClientID string
-	// ClientSecret is an azure service principal's credential.
+	// ClientSecret is an azure service principal's credential. If neither
+	// ClientSecret nor FederatedTokenFile is set, the manager authenticates
+	// with a managed identity.
 	ClientSecret string
+	// FederatedTokenFile is the path to a federated token, such as a
+	// projected service account token, with which to authenticate as the
+	// client (workload identity).
+	FederatedTokenFile string
 	// TenantID is the azure identity's tenant ID.
 	TenantID string
 	// SubscriptionID is the azure identity's subscription ID.
 	SubscriptionID string
 	// DNS is public and private DNS zone configuration for the cluster.
 	DNS *configv1.DNS
+	// TTL, if positive, is the TTL in seconds of the records created by the
+	// manager.
+	TTL int64
 }
 
 type manager struct {
 	config       Config
 	client       client.DNSClient
 	clientConfig client.Config
+
+	// lock protects access to everything below.
+	lock sync.Mutex
+
+	// idsToTags caches the IDs of the zones that were looked up by tags.
+	idsToTags map[string]map[string]string
 }
 
 func NewManager(config Config, operatorReleaseVersion string) (dns.Manager, error) {
 	c, err := client.New(client.Config{
-		Environment:    config.Environment,
-		SubscriptionID: config.SubscriptionID,
-		ClientID:       config.ClientID,
-		ClientSecret:   config.ClientSecret,
-		TenantID:       config.TenantID,
+		Environment:        config.Environment,
+		SubscriptionID:     config.SubscriptionID,
+		ClientID:           config.ClientID,
+		ClientSecret:       config.ClientSecret,
+		TenantID:           config.TenantID,
+		FederatedTokenFile: config.FederatedTokenFile,
 	}, userAgent(operatorReleaseVersion))
 	if err != nil {
 		return nil, err
 	}
-	return &manager{config: config, client: c}, nil
+	return &manager{config: config, client: c, idsToTags: map[string]map[string]string{}}, nil
 }
 
 func userAgent(operatorReleaseVersion string) string {
@@ -58,59 +82,279 @@ func userAgent(operatorReleaseVersion string) string {
 }
 
 func (m *manager) Ensure(record *dns.Record) error {
-	if record.Type != dns.ARecordType {
-		return fmt.Errorf("only A record types are supported")
+	targetZone, name, err := m.recordName(record)
+	if err != nil {
+		return err
 	}
 
-	targetZone, err := client.ParseZone(record.Zone.ID)
-	if err != nil {
-		return errors.Wrap(err, "failed to parse zoneID")
+	switch record.Type {
+	case dns.ARecordType:
+		err = m.client.Put(
+			context.TODO(),
+			*targetZone,
+			client.ARecord{
+				Address: record.ARecord.Address,
+				Name:    name,
+				TTL:     m.recordTTL(),
+			})
+	case dns.AAAARecordType:
+		err = m.client.PutAAAA(
+			context.TODO(),
+			*targetZone,
+			client.AAAARecord{
+				Address: record.AAAARecord.Address,
+				Name:    name,
+				TTL:     m.recordTTL(),
+			})
+	case dns.CNAMERecordType:
+		err = m.client.PutCNAME(
+			context.TODO(),
+			*targetZone,
+			client.CNAMERecord{
+				Target: record.CNAMERecord.Target,
+				Name:   name,
+				TTL:    m.recordTTL(),
+			})
+	case dns.TXTRecordType:
+		err = m.client.PutTXT(
+			context.TODO(),
+			*targetZone,
+			client.TXTRecord{
+				Text: record.TXTRecord.Text,
+				Name: name,
+				TTL:  m.recordTTL(),
+			})
+	}
+
+	if err == nil {
+		log.Info("upserted DNS record", "record", record)
 	}
 
-	ARecordName, err := getARecordName(record.ARecord.Domain, "."+targetZone.Name)
+	return err
+}
+
+func (m *manager) Delete(record *dns.Record) error {
+	targetZone, name, err := m.recordName(record)
 	if err != nil {
 		return err
 	}
 
-	err = m.client.Put(
-		context.TODO(),
-		*targetZone,
-		client.ARecord{
-			Address: record.ARecord.Address,
-			Name:    ARecordName,
-		})
+	switch record.Type {
+	case dns.ARecordType:
+		err = m.client.Delete(
+			context.TODO(),
+			*targetZone,
+			client.ARecord{
+				Address: record.ARecord.Address,
+				Name:    name,
+			})
+	case dns.AAAARecordType:
+		err = m.client.DeleteAAAA(
+			context.TODO(),
+			*targetZone,
+			client.AAAARecord{
+				Address: record.AAAARecord.Address,
+				Name:    name,
+			})
+	case dns.CNAMERecordType:
+		err = m.client.DeleteCNAME(
+			context.TODO(),
+			*targetZone,
+			client.CNAMERecord{
+				Target: record.CNAMERecord.Target,
+				Name:   name,
+			})
+	case dns.TXTRecordType:
+		err = m.client.DeleteTXT(
+			context.TODO(),
+			*targetZone,
+			client.TXTRecord{
+				Text: record.TXTRecord.Text,
+				Name: name,
+			})
+	}
 
 	if err == nil {
-		log.Info("upserted DNS record", "record", record)
+		log.Info("deleted DNS record", "record", record)
 	}
 
 	return err
 }
 
-func (m *manager) Delete(record *dns.Record) error {
-	targetZone, err := client.ParseZone(record.Zone.ID)
+func (m *manager) Get(record *dns.Record) (*dns.Record, error) {
+	targetZone, name, err := m.recordName(record)
 	if err != nil {
-		return errors.Wrap(err, "failed to parse zoneID")
+		return nil, err
 	}
 
-	ARecordName, err := getARecordName(record.ARecord.Domain, "."+targetZone.Name)
+	switch record.Type {
+	case dns.ARecordType:
+		arec, err := m.client.Get(context.TODO(), *targetZone, name)
+		if err != nil || arec == nil {
+			return nil, err
+		}
+		return &dns.Record{
+			Zone: record.Zone,
+			Type: dns.ARecordType,
+			ARecord: &dns.ARecord{
+				Domain:  record.ARecord.Domain,
+				Address: selectAddress(arec.Addresses, record.ARecord.Address),
+			},
+		}, nil
+	case dns.AAAARecordType:
+		aaaa, err := m.client.GetAAAA(context.TODO(), *targetZone, name)
+		if err != nil || aaaa == nil {
+			return nil, err
+		}
+		return &dns.Record{
+			Zone: record.Zone,
+			Type: dns.AAAARecordType,
+			AAAARecord: &dns.AAAARecord{
+				Domain:  record.AAAARecord.Domain,
+				Address: selectAddress(aaaa.Addresses, record.AAAARecord.Address),
+			},
+		}, nil
+	case dns.CNAMERecordType:
+		cname, err := m.client.GetCNAME(context.TODO(), *targetZone, name)
+		if err != nil || cname == nil {
+			return nil, err
+		}
+		return &dns.Record{
+			Zone: record.Zone,
+			Type: dns.CNAMERecordType,
+			CNAMERecord: &dns.CNAMERecord{
+				Domain: record.CNAMERecord.Domain,
+				Target: cname.Target,
+			},
+		}, nil
+	case dns.TXTRecordType:
+		txt, err := m.client.GetTXT(context.TODO(), *targetZone, name)
+		if err != nil || txt == nil {
+			return nil, err
+		}
+		return &dns.Record{
+			Zone: record.Zone,
+			Type: dns.TXTRecordType,
+			TXTRecord: &dns.TXTRecord{
+				Domain: record.TXTRecord.Domain,
+				Text:   txt.Text,
+			},
+		}, nil
+	}
+	return nil, nil
+}
+
+// selectAddress returns address if it is one of the addresses of a record set,
+// or else the record set's first address, if any.
+func selectAddress(addresses []string, address string) string {
+	for _, a := range addresses {
+		if a == address {
+			return a
+		}
+	}
+	if len(addresses) > 0 {
+		return addresses[0]
+	}
+	return ""
+}
+
+// recordTTL returns the TTL of the records created by the manager.
+func (m *manager) recordTTL() int64 {
+	if m.config.TTL > 0 {
+		return m.config.TTL
+	}
+	return defaultRecordTTL
+}
+
+// SupportsRecordType returns true for A, AAAA, CNAME, and TXT records.
+func (m *manager) SupportsRecordType(zone configv1.DNSZone, recordType dns.RecordType) bool {
+	switch recordType {
+	case dns.ARecordType, dns.AAAARecordType, dns.CNAMERecordType, dns.TXTRecordType:
+		return true
+	}
+	return false
+}
+
+// recordName returns the zone of the given record and the name of the record
+// relative to the zone. It returns an error if the record's type is not
+// supported.
+func (m *manager) recordName(record *dns.Record) (*client.Zone, string, error) {
+	var domain string
+	switch {
+	case record.Type == dns.ARecordType && record.ARecord != nil:
+		domain = record.ARecord.Domain
+	case record.Type == dns.AAAARecordType && record.AAAARecord != nil:
+		domain = record.AAAARecord.Domain
+	case record.Type == dns.CNAMERecordType && record.CNAMERecord != nil:
+		domain = record.CNAMERecord.Domain
+	case record.Type == dns.TXTRecordType && record.TXTRecord != nil:
+		domain = record.TXTRecord.Domain
+	case m.SupportsRecordType(record.Zone, record.Type):
+		return nil, "", fmt.Errorf("missing %s record", record.Type)
+	default:
+		return nil, "", &dns.UnsupportedRecordTypeError{Type: record.Type}
+	}
+
+	zoneID, err := m.getZoneID(record.Zone)
 	if err != nil {
-		return err
+		return nil, "", err
+	}
+	targetZone, err := client.ParseZone(zoneID)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to parse zoneID")
+	}
+
+	name, err := getARecordName(domain, "."+targetZone.Name)
+	if err != nil {
+		return nil, "", err
 	}
+	return targetZone, name, nil
+}
 
-	err = m.client.Delete(
-		context.TODO(),
-		*targetZone,
-		client.ARecord{
-			Address: record.ARecord.Address,
-			Name:    ARecordName,
-		})
+// getZoneID returns the resource ID of the given zone. If the zone has no ID,
+// the ID of the first zone in the subscription that has all of the zone's tags
+// is looked up and cached.
+func (m *manager) getZoneID(zoneConfig configv1.DNSZone) (string, error) {
+	if len(zoneConfig.ID) > 0 {
+		return zoneConfig.ID, nil
+	}
+	if len(zoneConfig.Tags) == 0 {
+		return "", fmt.Errorf("zone has neither an ID nor tags")
+	}
 
-	if err == nil {
-		log.Info("deleted DNS record", "record", record)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	// If the ID for these tags is already cached, use it
+	for id, tags := range m.idsToTags {
+		if reflect.DeepEqual(tags, zoneConfig.Tags) {
+			return id, nil
+		}
 	}
 
-	return err
+	// Look up and cache the ID for these tags.
+	zones, err := m.client.ListZones(context.TODO())
+	if err != nil {
+		return "", err
+	}
+	for _, zone := range zones {
+		if hasTags(zone.Tags, zoneConfig.Tags) {
+			m.idsToTags[zone.ID] = zoneConfig.Tags
+			log.Info("found dns zone using tags", "zone id", zone.ID, "tags", zoneConfig.Tags)
+			return zone.ID, nil
+		}
+	}
+	return "", fmt.Errorf("no matching dns zone found")
+}
+
+// hasTags returns true if tags has every key of want with the same value.
+func hasTags(tags, want map[string]string) bool {
+	for k, v := range want {
+		if value, ok := tags[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
 }
 
 // getARecordName extracts the ARecord subdomain name from the full domain string.
@@ -1,6 +1,7 @@
 package azure_test
 
 import (
+	"context"
 	"testing"
 
 	v1 "github.com/openshift/api/config/v1"
@@ -92,3 +93,282 @@ func TestDeleteDNS(t *testing.T) {
 		t.Fatalf("expected the dns client 'Delete' func to be called, but found %s instead", recordedCall)
 	}
 }
+
+func TestGetDNS(t *testing.T) {
+	c := client.Config{}
+	fc, err := client.NewFake(c)
+	if err != nil {
+		t.Fatal("failed to create client")
+	}
+	mgr, err := azure.NewFakeManager(azure.Config{}, fc)
+	if err != nil {
+		t.Fatal("failed to create manager")
+	}
+
+	record := dns.Record{
+		Zone: v1.DNSZone{
+			ID: "/subscriptions/E540B02D-5CCE-4D47-A13B-EB05A19D696E/resourceGroups/test-rg/providers/Microsoft.Network/dnszones/dnszone.io",
+		},
+		Type: dns.ARecordType,
+		ARecord: &dns.ARecord{
+			Domain:  "subdomain.dnszone.io",
+			Address: "55.11.22.33",
+		},
+	}
+
+	current, err := mgr.Get(&record)
+	if err != nil {
+		t.Fatalf("failed to get dns: %v", err)
+	}
+	if current != nil {
+		t.Fatalf("expected no record before ensure, got %v", current)
+	}
+
+	if err := mgr.Ensure(&record); err != nil {
+		t.Fatalf("failed to ensure dns: %v", err)
+	}
+	current, err = mgr.Get(&record)
+	if err != nil {
+		t.Fatalf("failed to get dns: %v", err)
+	}
+	if current == nil || current.ARecord == nil || current.ARecord.Address != record.ARecord.Address {
+		t.Fatalf("expected record %v, got %v", record, current)
+	}
+}
+
+func TestTXTRecord(t *testing.T) {
+	fc, err := client.NewFake(client.Config{})
+	if err != nil {
+		t.Fatal("failed to create client")
+	}
+	mgr, err := azure.NewFakeManager(azure.Config{}, fc)
+	if err != nil {
+		t.Fatal("failed to create manager")
+	}
+
+	record := dns.Record{
+		Zone: v1.DNSZone{
+			ID: "/subscriptions/E540B02D-5CCE-4D47-A13B-EB05A19D696E/resourceGroups/test-rg/providers/Microsoft.Network/dnszones/dnszone.io",
+		},
+		Type: dns.TXTRecordType,
+		TXTRecord: &dns.TXTRecord{
+			Domain: "_owner.subdomain.dnszone.io",
+			Text:   "heritage=openshift-ingress-operator",
+		},
+	}
+
+	if err := mgr.Ensure(&record); err != nil {
+		t.Fatalf("failed to ensure dns: %v", err)
+	}
+	if call, _ := fc.RecordedCall("test-rg", "dnszone.io", "_owner.subdomain"); call != "PUT" {
+		t.Fatalf("expected the dns client 'PutTXT' func to be called, but found %s instead", call)
+	}
+	current, err := mgr.Get(&record)
+	if err != nil {
+		t.Fatalf("failed to get dns: %v", err)
+	}
+	if current == nil || current.TXTRecord == nil || current.TXTRecord.Text != record.TXTRecord.Text {
+		t.Fatalf("expected record %v, got %v", record, current)
+	}
+
+	if err := mgr.Delete(&record); err != nil {
+		t.Fatalf("failed to delete dns: %v", err)
+	}
+	current, err = mgr.Get(&record)
+	if err != nil {
+		t.Fatalf("failed to get dns: %v", err)
+	}
+	if current != nil {
+		t.Fatalf("expected no record after delete, got %v", current)
+	}
+}
+
+func TestAAAARecord(t *testing.T) {
+	fc, err := client.NewFake(client.Config{})
+	if err != nil {
+		t.Fatal("failed to create client")
+	}
+	mgr, err := azure.NewFakeManager(azure.Config{}, fc)
+	if err != nil {
+		t.Fatal("failed to create manager")
+	}
+
+	record := dns.Record{
+		Zone: v1.DNSZone{
+			ID: "/subscriptions/E540B02D-5CCE-4D47-A13B-EB05A19D696E/resourceGroups/test-rg/providers/Microsoft.Network/dnszones/dnszone.io",
+		},
+		Type: dns.AAAARecordType,
+		AAAARecord: &dns.AAAARecord{
+			Domain:  "subdomain.dnszone.io",
+			Address: "2001:db8::1",
+		},
+	}
+
+	if err := mgr.Ensure(&record); err != nil {
+		t.Fatalf("failed to ensure dns: %v", err)
+	}
+	if call, _ := fc.RecordedCall("test-rg", "dnszone.io", "subdomain"); call != "PUT" {
+		t.Fatalf("expected the dns client 'PutAAAA' func to be called, but found %s instead", call)
+	}
+	current, err := mgr.Get(&record)
+	if err != nil {
+		t.Fatalf("failed to get dns: %v", err)
+	}
+	if current == nil || current.AAAARecord == nil || current.AAAARecord.Address != record.AAAARecord.Address {
+		t.Fatalf("expected record %v, got %v", record, current)
+	}
+
+	if err := mgr.Delete(&record); err != nil {
+		t.Fatalf("failed to delete dns: %v", err)
+	}
+	current, err = mgr.Get(&record)
+	if err != nil {
+		t.Fatalf("failed to get dns: %v", err)
+	}
+	if current != nil {
+		t.Fatalf("expected no record after delete, got %v", current)
+	}
+}
+
+func TestCNAMERecord(t *testing.T) {
+	fc, err := client.NewFake(client.Config{})
+	if err != nil {
+		t.Fatal("failed to create client")
+	}
+	mgr, err := azure.NewFakeManager(azure.Config{}, fc)
+	if err != nil {
+		t.Fatal("failed to create manager")
+	}
+
+	record := dns.Record{
+		Zone: v1.DNSZone{
+			ID: "/subscriptions/E540B02D-5CCE-4D47-A13B-EB05A19D696E/resourceGroups/test-rg/providers/Microsoft.Network/dnszones/dnszone.io",
+		},
+		Type: dns.CNAMERecordType,
+		CNAMERecord: &dns.CNAMERecord{
+			Domain: "subdomain.dnszone.io",
+			Target: "lb.example.com",
+		},
+	}
+	if !mgr.SupportsRecordType(record.Zone, dns.CNAMERecordType) {
+		t.Fatal("expected CNAME records to be supported")
+	}
+	if mgr.SupportsRecordType(record.Zone, dns.ALIASRecord) {
+		t.Fatal("expected ALIAS records not to be supported")
+	}
+
+	if err := mgr.Ensure(&record); err != nil {
+		t.Fatalf("failed to ensure dns: %v", err)
+	}
+	if call, _ := fc.RecordedCall("test-rg", "dnszone.io", "subdomain"); call != "PUT" {
+		t.Fatalf("expected the dns client 'PutCNAME' func to be called, but found %s instead", call)
+	}
+	current, err := mgr.Get(&record)
+	if err != nil {
+		t.Fatalf("failed to get dns: %v", err)
+	}
+	if current == nil || current.CNAMERecord == nil || current.CNAMERecord.Target != record.CNAMERecord.Target {
+		t.Fatalf("expected record %v, got %v", record, current)
+	}
+
+	if err := mgr.Delete(&record); err != nil {
+		t.Fatalf("failed to delete dns: %v", err)
+	}
+	current, err = mgr.Get(&record)
+	if err != nil {
+		t.Fatalf("failed to get dns: %v", err)
+	}
+	if current != nil {
+		t.Fatalf("expected no record after delete, got %v", current)
+	}
+}
+
+func TestZoneTags(t *testing.T) {
+	fc, err := client.NewFake(client.Config{})
+	if err != nil {
+		t.Fatal("failed to create client")
+	}
+	fc.AddZone("/subscriptions/E540B02D-5CCE-4D47-A13B-EB05A19D696E/resourceGroups/other-rg/providers/Microsoft.Network/dnszones/other.io",
+		map[string]string{"kubernetes.io_cluster.other": "owned"})
+	fc.AddZone("/subscriptions/E540B02D-5CCE-4D47-A13B-EB05A19D696E/resourceGroups/test-rg/providers/Microsoft.Network/dnszones/dnszone.io",
+		map[string]string{"kubernetes.io_cluster.test": "owned", "environment": "test"})
+	mgr, err := azure.NewFakeManager(azure.Config{}, fc)
+	if err != nil {
+		t.Fatal("failed to create manager")
+	}
+
+	record := dns.Record{
+		Zone: v1.DNSZone{
+			Tags: map[string]string{"kubernetes.io_cluster.test": "owned"},
+		},
+		Type: dns.ARecordType,
+		ARecord: &dns.ARecord{
+			Domain:  "subdomain.dnszone.io",
+			Address: "55.11.22.33",
+		},
+	}
+	if err := mgr.Ensure(&record); err != nil {
+		t.Fatalf("failed to ensure dns: %v", err)
+	}
+	if call, _ := fc.RecordedCall("test-rg", "dnszone.io", "subdomain"); call != "PUT" {
+		t.Fatalf("expected the record to be put in the tagged zone, but found %q instead", call)
+	}
+
+	record.Zone.Tags = map[string]string{"kubernetes.io_cluster.missing": "owned"}
+	if err := mgr.Ensure(&record); err == nil {
+		t.Fatal("expected an error for tags that match no zone")
+	}
+}
+
+func TestMultipleAddresses(t *testing.T) {
+	fc, err := client.NewFake(client.Config{})
+	if err != nil {
+		t.Fatal("failed to create client")
+	}
+	mgr, err := azure.NewFakeManager(azure.Config{TTL: 60}, fc)
+	if err != nil {
+		t.Fatal("failed to create manager")
+	}
+
+	newRecord := func(address string) *dns.Record {
+		return &dns.Record{
+			Zone: v1.DNSZone{
+				ID: "/subscriptions/E540B02D-5CCE-4D47-A13B-EB05A19D696E/resourceGroups/test-rg/providers/Microsoft.Network/dnszones/dnszone.io",
+			},
+			Type: dns.ARecordType,
+			ARecord: &dns.ARecord{
+				Domain:  "subdomain.dnszone.io",
+				Address: address,
+			},
+		}
+	}
+	for _, address := range []string{"55.11.22.33", "55.11.22.34"} {
+		if err := mgr.Ensure(newRecord(address)); err != nil {
+			t.Fatalf("failed to ensure dns: %v", err)
+		}
+	}
+	for _, address := range []string{"55.11.22.33", "55.11.22.34"} {
+		current, err := mgr.Get(newRecord(address))
+		if err != nil {
+			t.Fatalf("failed to get dns: %v", err)
+		}
+		if current == nil || current.ARecord.Address != address {
+			t.Fatalf("expected record with address %s, got %v", address, current)
+		}
+	}
+	set, _ := fc.Get(context.TODO(), client.Zone{ResourceGroup: "test-rg", Name: "dnszone.io"}, "subdomain")
+	if set == nil || set.TTL != 60 {
+		t.Fatalf("expected record set with TTL 60, got %v", set)
+	}
+
+	if err := mgr.Delete(newRecord("55.11.22.33")); err != nil {
+		t.Fatalf("failed to delete dns: %v", err)
+	}
+	current, err := mgr.Get(newRecord("55.11.22.33"))
+	if err != nil {
+		t.Fatalf("failed to get dns: %v", err)
+	}
+	if current == nil || current.ARecord.Address != "55.11.22.34" {
+		t.Fatalf("expected the other address to remain, got %v", current)
+	}
+}
@@ -6,5 +6,5 @@ import (
 )
 
 func NewFakeManager(config Config, client client.DNSClient) (dns.Manager, error) {
-	return &manager{config: config, client: client}, nil
+	return &manager{config: config, client: client, idsToTags: map[string]map[string]string{}}, nil
 }
@@ -13,14 +13,83 @@ type Manager interface {
 
 	// Delete will delete record.
 	Delete(record *Record) error
+
+	// Get returns the record that is currently published in the zone with
+	// the same type and domain as record, or nil if there is no such record.
+	Get(record *Record) (*Record, error)
+
+	// SupportsRecordType returns true if the manager can manage records of
+	// the given type in zone.
+	SupportsRecordType(zone configv1.DNSZone, recordType RecordType) bool
+}
+
+// BatchManager is implemented by a Manager that can submit several changes to
+// a zone together.
+type BatchManager interface {
+	Manager
+
+	// NewBatch returns a Batch that queues changes until it is committed.
+	NewBatch() Batch
+}
+
+// Batch is a Manager whose Ensure and Delete queue changes instead of
+// submitting them. Get and SupportsRecordType are not affected.
+type Batch interface {
+	Manager
+
+	// Commit submits the queued changes, with one change set per zone, and
+	// returns an error for every zone whose changes failed.
+	Commit() []ZoneError
+}
+
+// ImmediateBatch is a Batch for a Manager that doesn't support batches. Its
+// changes are applied immediately by the manager that it embeds, so committing
+// it does nothing.
+type ImmediateBatch struct {
+	Manager
+}
+
+func (b *ImmediateBatch) Commit() []ZoneError {
+	return nil
+}
+
+// ZoneError is an error that applies to every change that a Batch submitted
+// to a zone, except for the changes that it lists as committed.
+type ZoneError struct {
+	// Zone is the zone whose changes failed.
+	Zone configv1.DNSZone
+
+	// Err is the error.
+	Err error
+
+	// Committed are the records whose changes succeeded despite the error,
+	// such as those in the change sets that a Batch submitted to the zone
+	// before the one that failed.
+	Committed []*Record
+}
+
+func (e ZoneError) Error() string {
+	return fmt.Sprintf("zone %v: %v", e.Zone, e.Err)
+}
+
+// PropagationTracker is implemented by a Manager whose changes take time to
+// propagate to a zone's nameservers.
+type PropagationTracker interface {
+	// Pending returns true if the manager has submitted a change to record
+	// that has not yet propagated to the zone's nameservers.
+	Pending(record *Record) (bool, error)
 }
 
 var _ Manager = &NoopManager{}
 
 type NoopManager struct{}
 
-func (_ *NoopManager) Ensure(record *Record) error { return nil }
-func (_ *NoopManager) Delete(record *Record) error { return nil }
+func (_ *NoopManager) Ensure(record *Record) error         { return nil }
+func (_ *NoopManager) Delete(record *Record) error         { return nil }
+func (_ *NoopManager) Get(record *Record) (*Record, error) { return record, nil }
+func (_ *NoopManager) SupportsRecordType(zone configv1.DNSZone, recordType RecordType) bool {
+	return true
+}
 
 // Record represents a DNS record.
 type Record struct {
@@ -34,10 +103,28 @@ type Record struct {
 
 	// ARecord is options for an A record.
 	ARecord *ARecord
+
+	// AAAARecord is options for an AAAA record.
+	AAAARecord *AAAARecord
+
+	// CNAMERecord is options for a CNAME record.
+	CNAMERecord *CNAMERecord
+
+	// TXTRecord is options for a TXT record.
+	TXTRecord *TXTRecord
+
+	// RoutingPolicy, if not nil, makes the record one of several records
+	// with the same domain and type. Managers that don't support routing
+	// policies ignore it.
+	RoutingPolicy *RoutingPolicy
 }
 
 func (r *Record) String() string {
-	return fmt.Sprintf("Zone: %v, Type: %v, Alias: %s, A: %s", r.Zone, r.Type, r.Alias, r.ARecord)
+	s := fmt.Sprintf("Zone: %v, Type: %v, Alias: %s, A: %s, AAAA: %s, CNAME: %s, TXT: %s", r.Zone, r.Type, r.Alias, r.ARecord, r.AAAARecord, r.CNAMERecord, r.TXTRecord)
+	if r.RoutingPolicy != nil {
+		s += fmt.Sprintf(", RoutingPolicy: %s", r.RoutingPolicy)
+	}
+	return s
 }
 
 // RecordType is a DNS record type.
@@ -49,6 +136,15 @@ const (
 
 	// ARecordType is a DNS A record.
 	ARecordType RecordType = "A"
+
+	// AAAARecordType is a DNS AAAA record.
+	AAAARecordType RecordType = "AAAA"
+
+	// CNAMERecordType is a DNS CNAME record.
+	CNAMERecordType RecordType = "CNAME"
+
+	// TXTRecordType is a DNS TXT record.
+	TXTRecordType RecordType = "TXT"
 )
 
 // AliasRecord is a DNS ALIAS record.
@@ -76,3 +172,140 @@ type ARecord struct {
 func (r *ARecord) String() string {
 	return fmt.Sprintf("%s -> %s", r.Domain, r.Address)
 }
+
+// AAAARecord is a DNS AAAA record.
+type AAAARecord struct {
+	// Domain is the record name.
+	Domain string
+
+	// Address is the IPv6 address of the AAAA record.
+	Address string
+}
+
+func (r *AAAARecord) String() string {
+	return fmt.Sprintf("%s -> %s", r.Domain, r.Address)
+}
+
+// CNAMERecord is a DNS CNAME record.
+type CNAMERecord struct {
+	// Domain is the record name.
+	Domain string
+
+	// Target is the canonical name of Domain.
+	Target string
+}
+
+func (r *CNAMERecord) String() string {
+	return fmt.Sprintf("%s -> %s", r.Domain, r.Target)
+}
+
+// TXTRecord is a DNS TXT record.
+type TXTRecord struct {
+	// Domain is the record name.
+	Domain string
+
+	// Text is the text of the TXT record.
+	Text string
+}
+
+func (r *TXTRecord) String() string {
+	return fmt.Sprintf("%s -> %q", r.Domain, r.Text)
+}
+
+// RoutingPolicy determines how the DNS provider answers queries for a domain
+// that has several records of the same type, which are distinguished by their
+// set identifiers.
+type RoutingPolicy struct {
+	// Type is the kind of routing policy.
+	Type RoutingPolicyType
+
+	// SetIdentifier distinguishes the record from the other records with
+	// the same domain and type.
+	SetIdentifier string
+
+	// Weight is the relative weight of a weighted record.
+	Weight int64
+
+	// Failover is the role of a failover record.
+	Failover FailoverRole
+
+	// Region is the region of the target of a latency record.
+	Region string
+
+	// HealthCheck, if not nil, is a health check of the record's target.
+	HealthCheck *HealthCheck
+}
+
+func (p *RoutingPolicy) String() string {
+	s := fmt.Sprintf("%s %s", p.Type, p.SetIdentifier)
+	switch p.Type {
+	case WeightedRoutingPolicy:
+		s += fmt.Sprintf(" weight %d", p.Weight)
+	case FailoverRoutingPolicy:
+		s += fmt.Sprintf(" %s", p.Failover)
+	case LatencyRoutingPolicy:
+		s += fmt.Sprintf(" region %s", p.Region)
+	}
+	if p.HealthCheck != nil {
+		s += fmt.Sprintf(" health check :%d%s", p.HealthCheck.Port, p.HealthCheck.Path)
+	}
+	return s
+}
+
+// RoutingPolicyType is a kind of routing policy.
+type RoutingPolicyType string
+
+const (
+	// WeightedRoutingPolicy answers queries with each record in
+	// proportion to its weight.
+	WeightedRoutingPolicy RoutingPolicyType = "Weighted"
+
+	// FailoverRoutingPolicy answers queries with the primary record while
+	// it is healthy, and with the secondary record otherwise.
+	FailoverRoutingPolicy RoutingPolicyType = "Failover"
+
+	// LatencyRoutingPolicy answers queries with the record whose region
+	// has the lowest latency to the client.
+	LatencyRoutingPolicy RoutingPolicyType = "Latency"
+)
+
+// FailoverRole is the role of a failover record.
+type FailoverRole string
+
+const (
+	// PrimaryFailoverRole is the role of the record that answers queries
+	// while it is healthy.
+	PrimaryFailoverRole FailoverRole = "Primary"
+
+	// SecondaryFailoverRole is the role of the record that answers
+	// queries while the primary record is unhealthy.
+	SecondaryFailoverRole FailoverRole = "Secondary"
+)
+
+// HealthCheck is a health check of a record's target.
+type HealthCheck struct {
+	// Port is the port on the target to which the health check connects.
+	Port int64
+
+	// Path, if not empty, is the path that an HTTP health check requests.
+	// If empty, the health check only opens a TCP connection to the port.
+	Path string
+}
+
+// UnsupportedRecordTypeError is returned by a Manager that cannot manage
+// records of the given type.
+type UnsupportedRecordTypeError struct {
+	// Type is the unsupported record type.
+	Type RecordType
+}
+
+func (e *UnsupportedRecordTypeError) Error() string {
+	return fmt.Sprintf("unsupported record type %s", e.Type)
+}
+
+// IsUnsupportedRecordType returns true if err indicates that a Manager does
+// not support the type of a record.
+func IsUnsupportedRecordType(err error) bool {
+	_, ok := err.(*UnsupportedRecordTypeError)
+	return ok
+}
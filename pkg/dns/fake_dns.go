@@ -0,0 +1,111 @@
+package dns
+
+import (
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+var _ Manager = &FakeManager{}
+
+// FakeManager is an in-memory Manager for tests. It supports only the given
+// record types and stores one record for every type and domain, except for A
+// and AAAA records, of which it stores one for every address.
+type FakeManager struct {
+	// RecordTypes are the record types that the manager supports.
+	RecordTypes []RecordType
+
+	// Records are the current records, keyed by type, domain and, for A and
+	// AAAA records, address.
+	Records map[string]*Record
+
+	// Err, if not nil, is returned by every operation, which then leaves the
+	// records alone.
+	Err error
+}
+
+// NewFakeManager returns a FakeManager without records that supports the given
+// record types.
+func NewFakeManager(recordTypes ...RecordType) *FakeManager {
+	return &FakeManager{RecordTypes: recordTypes, Records: map[string]*Record{}}
+}
+
+// key returns the key of the given record in Records, or an error if the
+// record's type is not supported.
+func (m *FakeManager) key(record *Record) (string, error) {
+	if !m.SupportsRecordType(record.Zone, record.Type) {
+		return "", &UnsupportedRecordTypeError{Type: record.Type}
+	}
+	switch record.Type {
+	case ALIASRecord:
+		return "ALIAS/" + record.Alias.Domain, nil
+	case ARecordType:
+		return "A/" + record.ARecord.Domain + "/" + record.ARecord.Address, nil
+	case AAAARecordType:
+		return "AAAA/" + record.AAAARecord.Domain + "/" + record.AAAARecord.Address, nil
+	case CNAMERecordType:
+		return "CNAME/" + record.CNAMERecord.Domain, nil
+	case TXTRecordType:
+		return "TXT/" + record.TXTRecord.Domain, nil
+	}
+	return "", &UnsupportedRecordTypeError{Type: record.Type}
+}
+
+func (m *FakeManager) Ensure(record *Record) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	key, err := m.key(record)
+	if err != nil {
+		return err
+	}
+	m.Records[key] = record
+	return nil
+}
+
+func (m *FakeManager) Delete(record *Record) error {
+	if m.Err != nil {
+		return m.Err
+	}
+	key, err := m.key(record)
+	if err != nil {
+		return err
+	}
+	delete(m.Records, key)
+	return nil
+}
+
+// Get returns the record with the same type and domain as the given record.
+// For A and AAAA records, it returns the record with the same address if there
+// is one, or else another record of the record set.
+func (m *FakeManager) Get(record *Record) (*Record, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	key, err := m.key(record)
+	if err != nil {
+		return nil, err
+	}
+	if current, ok := m.Records[key]; ok {
+		return current, nil
+	}
+	if record.Type != ARecordType && record.Type != AAAARecordType {
+		return nil, nil
+	}
+	prefix := key[:strings.LastIndex(key, "/")+1]
+	for k, current := range m.Records {
+		if strings.HasPrefix(k, prefix) {
+			return current, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *FakeManager) SupportsRecordType(zone configv1.DNSZone, recordType RecordType) bool {
+	for _, t := range m.RecordTypes {
+		if t == recordType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,342 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	gdnsv1 "google.golang.org/api/dns/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+// DNSClient is the subset of the Cloud DNS API that is needed to manage
+// records in a managed zone.
+type DNSClient interface {
+	// GetZoneByLabels returns the first managed zone whose labels include
+	// every one of the given labels.
+	GetZoneByLabels(ctx context.Context, labels map[string]string) (*Zone, error)
+
+	// Put adds the address of arec to the A record set with arec's name.
+	Put(ctx context.Context, zone Zone, arec ARecord) error
+	// Delete removes the address of arec from the A record set with arec's
+	// name.
+	Delete(ctx context.Context, zone Zone, arec ARecord) error
+	// Get returns the A record set with the given name in zone, or nil if no
+	// such record set exists.
+	Get(ctx context.Context, zone Zone, name string) (*ARecordSet, error)
+
+	PutCNAME(ctx context.Context, zone Zone, cname CNAMERecord) error
+	DeleteCNAME(ctx context.Context, zone Zone, cname CNAMERecord) error
+	// GetCNAME returns the CNAME record with the given name in zone, or nil
+	// if no such record exists.
+	GetCNAME(ctx context.Context, zone Zone, name string) (*CNAMERecord, error)
+
+	PutTXT(ctx context.Context, zone Zone, txt TXTRecord) error
+	DeleteTXT(ctx context.Context, zone Zone, txt TXTRecord) error
+	// GetTXT returns the TXT record with the given name in zone, or nil if
+	// no such record exists.
+	GetTXT(ctx context.Context, zone Zone, name string) (*TXTRecord, error)
+}
+
+type Config struct {
+	// Project is the GCP project that owns the managed zones.
+	Project string
+	// CredentialsJSON is a GCP service account key in JSON format.
+	CredentialsJSON []byte
+}
+
+// Zone is a Cloud DNS managed zone.
+type Zone struct {
+	// Name is the name of the managed zone.
+	Name string
+
+	// DNSName is the DNS name of the managed zone, including the trailing
+	// dot.
+	DNSName string
+}
+
+// ARecord is a DNS A record.
+type ARecord struct {
+	// Name is the fully qualified record name, including the trailing dot.
+	Name string
+
+	// Address is the IPv4 address of the A record.
+	Address string
+
+	// TTL is the Time To Live property of the A record.
+	TTL int64
+}
+
+// ARecordSet is a DNS A record set, which may have several addresses.
+type ARecordSet struct {
+	// Name is the fully qualified record set name, including the trailing
+	// dot.
+	Name string
+
+	// Addresses are the IPv4 addresses of the record set.
+	Addresses []string
+
+	// TTL is the Time To Live property of the record set.
+	TTL int64
+}
+
+// CNAMERecord is a DNS CNAME record.
+type CNAMERecord struct {
+	// Name is the fully qualified record name, including the trailing dot.
+	Name string
+
+	// Target is the fully qualified canonical name of the CNAME record,
+	// including the trailing dot.
+	Target string
+
+	// TTL is the Time To Live property of the CNAME record.
+	TTL int64
+}
+
+// TXTRecord is a DNS TXT record.
+type TXTRecord struct {
+	// Name is the fully qualified record name, including the trailing dot.
+	Name string
+
+	// Text is the text of the TXT record.
+	Text string
+
+	// TTL is the Time To Live property of the TXT record.
+	TTL int64
+}
+
+type dnsClient struct {
+	service *gdnsv1.Service
+	config  Config
+}
+
+// New returns an authenticated DNSClient
+func New(config Config, userAgentExtension string) (DNSClient, error) {
+	service, err := gdnsv1.NewService(context.TODO(),
+		option.WithCredentialsJSON(config.CredentialsJSON),
+		option.WithUserAgent(userAgentExtension))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cloud dns service")
+	}
+	return &dnsClient{service: service, config: config}, nil
+}
+
+func (c *dnsClient) GetZoneByLabels(ctx context.Context, labels map[string]string) (*Zone, error) {
+	var found *Zone
+	err := c.service.ManagedZones.List(c.config.Project).Pages(ctx, func(resp *gdnsv1.ManagedZonesListResponse) error {
+		for _, zone := range resp.ManagedZones {
+			if found == nil && labelsMatch(zone.Labels, labels) {
+				found = &Zone{Name: zone.Name, DNSName: zone.DnsName}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list managed zones")
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no managed zone found with labels %v", labels)
+	}
+	return found, nil
+}
+
+// labelsMatch returns true if actual contains every key and value in wanted.
+func labelsMatch(actual, wanted map[string]string) bool {
+	if len(wanted) == 0 {
+		return false
+	}
+	for k, v := range wanted {
+		if actual[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Put adds the address of arec to the A record set with arec's name, which is
+// created if it doesn't exist, and sets the record set's TTL to arec's.
+func (c *dnsClient) Put(ctx context.Context, zone Zone, arec ARecord) error {
+	current, err := c.get(ctx, zone, arec.Name, "A")
+	if err != nil {
+		return err
+	}
+	addresses := []string{arec.Address}
+	if current != nil {
+		addresses = append([]string{}, current.Rrdatas...)
+		if !containsString(addresses, arec.Address) {
+			addresses = append(addresses, arec.Address)
+		}
+	}
+	return c.put(ctx, zone, &gdnsv1.ResourceRecordSet{
+		Name:    arec.Name,
+		Type:    "A",
+		Ttl:     arec.TTL,
+		Rrdatas: addresses,
+	})
+}
+
+// Delete removes the address of arec from the A record set with arec's name,
+// which is deleted if no other addresses remain.
+func (c *dnsClient) Delete(ctx context.Context, zone Zone, arec ARecord) error {
+	current, err := c.get(ctx, zone, arec.Name, "A")
+	if err != nil || current == nil || !containsString(current.Rrdatas, arec.Address) {
+		return err
+	}
+	remaining := []string{}
+	for _, address := range current.Rrdatas {
+		if address != arec.Address {
+			remaining = append(remaining, address)
+		}
+	}
+	if len(remaining) == 0 {
+		return c.delete(ctx, zone, arec.Name, "A")
+	}
+	return c.put(ctx, zone, &gdnsv1.ResourceRecordSet{
+		Name:    current.Name,
+		Type:    "A",
+		Ttl:     current.Ttl,
+		Rrdatas: remaining,
+	})
+}
+
+func (c *dnsClient) Get(ctx context.Context, zone Zone, name string) (*ARecordSet, error) {
+	current, err := c.get(ctx, zone, name, "A")
+	if err != nil || current == nil {
+		return nil, err
+	}
+	return &ARecordSet{Name: current.Name, Addresses: current.Rrdatas, TTL: current.Ttl}, nil
+}
+
+func (c *dnsClient) PutCNAME(ctx context.Context, zone Zone, cname CNAMERecord) error {
+	return c.put(ctx, zone, &gdnsv1.ResourceRecordSet{
+		Name:    cname.Name,
+		Type:    "CNAME",
+		Ttl:     cname.TTL,
+		Rrdatas: []string{cname.Target},
+	})
+}
+
+func (c *dnsClient) DeleteCNAME(ctx context.Context, zone Zone, cname CNAMERecord) error {
+	return c.delete(ctx, zone, cname.Name, "CNAME")
+}
+
+func (c *dnsClient) GetCNAME(ctx context.Context, zone Zone, name string) (*CNAMERecord, error) {
+	current, err := c.get(ctx, zone, name, "CNAME")
+	if err != nil || current == nil {
+		return nil, err
+	}
+	cname := &CNAMERecord{Name: current.Name, TTL: current.Ttl}
+	if len(current.Rrdatas) > 0 {
+		cname.Target = current.Rrdatas[0]
+	}
+	return cname, nil
+}
+
+func (c *dnsClient) PutTXT(ctx context.Context, zone Zone, txt TXTRecord) error {
+	return c.put(ctx, zone, &gdnsv1.ResourceRecordSet{
+		Name:    txt.Name,
+		Type:    "TXT",
+		Ttl:     txt.TTL,
+		Rrdatas: []string{strconv.Quote(txt.Text)},
+	})
+}
+
+func (c *dnsClient) DeleteTXT(ctx context.Context, zone Zone, txt TXTRecord) error {
+	return c.delete(ctx, zone, txt.Name, "TXT")
+}
+
+func (c *dnsClient) GetTXT(ctx context.Context, zone Zone, name string) (*TXTRecord, error) {
+	current, err := c.get(ctx, zone, name, "TXT")
+	if err != nil || current == nil {
+		return nil, err
+	}
+	txt := &TXTRecord{Name: current.Name, TTL: current.Ttl}
+	if len(current.Rrdatas) > 0 {
+		txt.Text = current.Rrdatas[0]
+		if text, err := strconv.Unquote(current.Rrdatas[0]); err == nil {
+			txt.Text = text
+		}
+	}
+	return txt, nil
+}
+
+// put creates or replaces the record set with the name and type of desired.
+func (c *dnsClient) put(ctx context.Context, zone Zone, desired *gdnsv1.ResourceRecordSet) error {
+	current, err := c.get(ctx, zone, desired.Name, desired.Type)
+	if err != nil {
+		return err
+	}
+	// Cloud DNS has no upsert operation, so an existing record set has to
+	// be deleted in the same change that adds the desired one.
+	change := &gdnsv1.Change{Additions: []*gdnsv1.ResourceRecordSet{desired}}
+	if current != nil {
+		if current.Ttl == desired.Ttl && reflect.DeepEqual(current.Rrdatas, desired.Rrdatas) {
+			return nil
+		}
+		change.Deletions = []*gdnsv1.ResourceRecordSet{current}
+	}
+	if _, err := c.service.Changes.Create(c.config.Project, zone.Name, change).Context(ctx).Do(); err != nil {
+		return errors.Wrapf(err, "failed to update dns %s record: %s in zone %s", strings.ToLower(desired.Type), desired.Name, zone.Name)
+	}
+	return nil
+}
+
+// delete deletes the record set with the given name and type, if it exists.
+func (c *dnsClient) delete(ctx context.Context, zone Zone, name, rrtype string) error {
+	current, err := c.get(ctx, zone, name, rrtype)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return nil
+	}
+	change := &gdnsv1.Change{Deletions: []*gdnsv1.ResourceRecordSet{current}}
+	if _, err := c.service.Changes.Create(c.config.Project, zone.Name, change).Context(ctx).Do(); err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to delete dns %s record: %s in zone %s", strings.ToLower(rrtype), name, zone.Name)
+	}
+	return nil
+}
+
+// get returns the record set with the given name and type in zone, or nil if
+// no such record set exists.
+func (c *dnsClient) get(ctx context.Context, zone Zone, name, rrtype string) (*gdnsv1.ResourceRecordSet, error) {
+	resp, err := c.service.ResourceRecordSets.List(c.config.Project, zone.Name).Name(name).Type(rrtype).Context(ctx).Do()
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get dns %s record: %s in zone %s", strings.ToLower(rrtype), name, zone.Name)
+	}
+	for _, rrset := range resp.Rrsets {
+		if strings.EqualFold(rrset.Name, name) && rrset.Type == rrtype {
+			return rrset, nil
+		}
+	}
+	return nil, nil
+}
+
+func isNotFound(err error) bool {
+	if gerr, ok := err.(*googleapi.Error); ok {
+		return gerr.Code == http.StatusNotFound
+	}
+	return false
+}
+
+// containsString returns true if values includes value.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
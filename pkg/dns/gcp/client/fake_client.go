@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+type FakeDNSClient struct {
+	zones   []Zone
+	labels  map[string]map[string]string
+	fakeAPI map[string]string
+	records map[string]ARecordSet
+	cnames  map[string]CNAMERecord
+	txt     map[string]TXTRecord
+}
+
+func NewFake(config Config) (*FakeDNSClient, error) {
+	return &FakeDNSClient{
+		labels:  map[string]map[string]string{},
+		fakeAPI: map[string]string{},
+		records: map[string]ARecordSet{},
+		cnames:  map[string]CNAMERecord{},
+		txt:     map[string]TXTRecord{},
+	}, nil
+}
+
+// AddZone registers a managed zone with the given labels with the fake.
+func (c *FakeDNSClient) AddZone(zone Zone, labels map[string]string) {
+	c.zones = append(c.zones, zone)
+	c.labels[zone.Name] = labels
+}
+
+func (c *FakeDNSClient) GetZoneByLabels(ctx context.Context, labels map[string]string) (*Zone, error) {
+	for i := range c.zones {
+		if labelsMatch(c.labels[c.zones[i].Name], labels) {
+			return &c.zones[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no managed zone found with labels %v", labels)
+}
+
+func (c *FakeDNSClient) Put(ctx context.Context, zone Zone, arec ARecord) error {
+	key := zone.Name + arec.Name
+	c.fakeAPI[key] = "PUT"
+	set := c.records[key]
+	set.Name, set.TTL = arec.Name, arec.TTL
+	if !containsString(set.Addresses, arec.Address) {
+		set.Addresses = append(set.Addresses, arec.Address)
+	}
+	c.records[key] = set
+	return nil
+}
+
+func (c *FakeDNSClient) Delete(ctx context.Context, zone Zone, arec ARecord) error {
+	key := zone.Name + arec.Name
+	c.fakeAPI[key] = "DELETE"
+	set, ok := c.records[key]
+	if !ok {
+		return nil
+	}
+	remaining := []string{}
+	for _, address := range set.Addresses {
+		if address != arec.Address {
+			remaining = append(remaining, address)
+		}
+	}
+	if len(remaining) == 0 {
+		delete(c.records, key)
+	} else {
+		set.Addresses = remaining
+		c.records[key] = set
+	}
+	return nil
+}
+
+func (c *FakeDNSClient) Get(ctx context.Context, zone Zone, name string) (*ARecordSet, error) {
+	if set, ok := c.records[zone.Name+name]; ok {
+		return &set, nil
+	}
+	return nil, nil
+}
+
+func (c *FakeDNSClient) PutCNAME(ctx context.Context, zone Zone, cname CNAMERecord) error {
+	c.fakeAPI[zone.Name+cname.Name] = "PUT"
+	c.cnames[zone.Name+cname.Name] = cname
+	return nil
+}
+
+func (c *FakeDNSClient) DeleteCNAME(ctx context.Context, zone Zone, cname CNAMERecord) error {
+	c.fakeAPI[zone.Name+cname.Name] = "DELETE"
+	delete(c.cnames, zone.Name+cname.Name)
+	return nil
+}
+
+func (c *FakeDNSClient) GetCNAME(ctx context.Context, zone Zone, name string) (*CNAMERecord, error) {
+	if cname, ok := c.cnames[zone.Name+name]; ok {
+		return &cname, nil
+	}
+	return nil, nil
+}
+
+func (c *FakeDNSClient) PutTXT(ctx context.Context, zone Zone, txt TXTRecord) error {
+	c.fakeAPI[zone.Name+txt.Name] = "PUT"
+	c.txt[zone.Name+txt.Name] = txt
+	return nil
+}
+
+func (c *FakeDNSClient) DeleteTXT(ctx context.Context, zone Zone, txt TXTRecord) error {
+	c.fakeAPI[zone.Name+txt.Name] = "DELETE"
+	delete(c.txt, zone.Name+txt.Name)
+	return nil
+}
+
+func (c *FakeDNSClient) GetTXT(ctx context.Context, zone Zone, name string) (*TXTRecord, error) {
+	if txt, ok := c.txt[zone.Name+name]; ok {
+		return &txt, nil
+	}
+	return nil, nil
+}
+
+func (c *FakeDNSClient) RecordedCall(zone, name string) (string, bool) {
+	call, ok := c.fakeAPI[zone+name]
+	return call, ok
+}
@@ -0,0 +1,273 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns/gcp/client"
+	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// recordTTL is the TTL, in seconds, of records created by the manager.
+	recordTTL int64 = 30
+)
+
+var (
+	_   dns.Manager = &manager{}
+	log             = logf.Logger.WithName("dns")
+)
+
+// Config is the necessary input to configure the manager for gcp.
+type Config struct {
+	// Project is the GCP project that owns the cluster's managed zones.
+	Project string
+	// CredentialsJSON is a GCP service account key in JSON format.
+	CredentialsJSON []byte
+	// DNS is public and private DNS zone configuration for the cluster.
+	DNS *configv1.DNS
+}
+
+// manager provides Cloud DNS record management. A zone is resolved from the
+// DNSZone ID, which is the name of the managed zone, or else from the DNSZone
+// tags, which must match the labels of the managed zone. A records with the same
+// name are published as one record set with all of their addresses.
+type manager struct {
+	config Config
+	client client.DNSClient
+
+	// lock protects access to everything below.
+	lock sync.Mutex
+
+	// zonesToLabels caches managed zones and their associated labels. There
+	// is an assumed 1:1 relationship between a zone and its set of labels.
+	zonesToLabels map[client.Zone]map[string]string
+}
+
+func NewManager(config Config, operatorReleaseVersion string) (dns.Manager, error) {
+	c, err := client.New(client.Config{
+		Project:         config.Project,
+		CredentialsJSON: config.CredentialsJSON,
+	}, userAgent(operatorReleaseVersion))
+	if err != nil {
+		return nil, err
+	}
+	return newManager(config, c), nil
+}
+
+func newManager(config Config, c client.DNSClient) *manager {
+	return &manager{
+		config:        config,
+		client:        c,
+		zonesToLabels: map[client.Zone]map[string]string{},
+	}
+}
+
+func userAgent(operatorReleaseVersion string) string {
+	return fmt.Sprintf("%s/%s", "openshift.io ingress-operator", operatorReleaseVersion)
+}
+
+func (m *manager) Ensure(record *dns.Record) error {
+	zone, err := m.recordZone(record)
+	if err != nil {
+		return err
+	}
+
+	switch record.Type {
+	case dns.ARecordType:
+		err = m.client.Put(context.TODO(), *zone, client.ARecord{
+			Name:    recordName(record.ARecord.Domain),
+			Address: record.ARecord.Address,
+			TTL:     recordTTL,
+		})
+	case dns.CNAMERecordType:
+		err = m.client.PutCNAME(context.TODO(), *zone, client.CNAMERecord{
+			Name:   recordName(record.CNAMERecord.Domain),
+			Target: recordName(record.CNAMERecord.Target),
+			TTL:    recordTTL,
+		})
+	case dns.TXTRecordType:
+		err = m.client.PutTXT(context.TODO(), *zone, client.TXTRecord{
+			Name: recordName(record.TXTRecord.Domain),
+			Text: record.TXTRecord.Text,
+			TTL:  recordTTL,
+		})
+	}
+	if err == nil {
+		log.Info("upserted DNS record", "record", record)
+	}
+
+	return err
+}
+
+func (m *manager) Delete(record *dns.Record) error {
+	zone, err := m.recordZone(record)
+	if err != nil {
+		return err
+	}
+
+	switch record.Type {
+	case dns.ARecordType:
+		err = m.client.Delete(context.TODO(), *zone, client.ARecord{
+			Name:    recordName(record.ARecord.Domain),
+			Address: record.ARecord.Address,
+		})
+	case dns.CNAMERecordType:
+		err = m.client.DeleteCNAME(context.TODO(), *zone, client.CNAMERecord{
+			Name:   recordName(record.CNAMERecord.Domain),
+			Target: recordName(record.CNAMERecord.Target),
+		})
+	case dns.TXTRecordType:
+		err = m.client.DeleteTXT(context.TODO(), *zone, client.TXTRecord{
+			Name: recordName(record.TXTRecord.Domain),
+			Text: record.TXTRecord.Text,
+		})
+	}
+	if err == nil {
+		log.Info("deleted DNS record", "record", record)
+	}
+
+	return err
+}
+
+func (m *manager) Get(record *dns.Record) (*dns.Record, error) {
+	zone, err := m.recordZone(record)
+	if err != nil {
+		return nil, err
+	}
+
+	switch record.Type {
+	case dns.ARecordType:
+		arec, err := m.client.Get(context.TODO(), *zone, recordName(record.ARecord.Domain))
+		if err != nil || arec == nil {
+			return nil, err
+		}
+		return &dns.Record{
+			Zone: record.Zone,
+			Type: dns.ARecordType,
+			ARecord: &dns.ARecord{
+				Domain:  record.ARecord.Domain,
+				Address: selectAddress(arec.Addresses, record.ARecord.Address),
+			},
+		}, nil
+	case dns.CNAMERecordType:
+		cname, err := m.client.GetCNAME(context.TODO(), *zone, recordName(record.CNAMERecord.Domain))
+		if err != nil || cname == nil {
+			return nil, err
+		}
+		return &dns.Record{
+			Zone: record.Zone,
+			Type: dns.CNAMERecordType,
+			CNAMERecord: &dns.CNAMERecord{
+				Domain: record.CNAMERecord.Domain,
+				Target: strings.TrimSuffix(cname.Target, "."),
+			},
+		}, nil
+	case dns.TXTRecordType:
+		txt, err := m.client.GetTXT(context.TODO(), *zone, recordName(record.TXTRecord.Domain))
+		if err != nil || txt == nil {
+			return nil, err
+		}
+		return &dns.Record{
+			Zone: record.Zone,
+			Type: dns.TXTRecordType,
+			TXTRecord: &dns.TXTRecord{
+				Domain: record.TXTRecord.Domain,
+				Text:   txt.Text,
+			},
+		}, nil
+	}
+	return nil, nil
+}
+
+// selectAddress returns address if addresses includes it, so that a record set
+// with several addresses matches each of them, or else the first of
+// addresses.
+func selectAddress(addresses []string, address string) string {
+	for _, a := range addresses {
+		if a == address {
+			return a
+		}
+	}
+	if len(addresses) > 0 {
+		return addresses[0]
+	}
+	return ""
+}
+
+// SupportsRecordType returns true for A, CNAME, and TXT records.
+func (m *manager) SupportsRecordType(zone configv1.DNSZone, recordType dns.RecordType) bool {
+	switch recordType {
+	case dns.ARecordType, dns.CNAMERecordType, dns.TXTRecordType:
+		return true
+	}
+	return false
+}
+
+// recordZone returns the managed zone of the given record. It returns an
+// error if the record's type is not supported.
+func (m *manager) recordZone(record *dns.Record) (*client.Zone, error) {
+	switch {
+	case record.Type == dns.ARecordType && record.ARecord != nil:
+	case record.Type == dns.CNAMERecordType && record.CNAMERecord != nil:
+	case record.Type == dns.TXTRecordType && record.TXTRecord != nil:
+	case m.SupportsRecordType(record.Zone, record.Type):
+		return nil, fmt.Errorf("missing %s record", record.Type)
+	default:
+		return nil, &dns.UnsupportedRecordTypeError{Type: record.Type}
+	}
+
+	zone, err := m.getZone(record.Zone)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find managed zone")
+	}
+	return zone, nil
+}
+
+// getZone finds the managed zone for the given zoneConfig. If an ID is
+// specified, it is used as the managed zone name; otherwise, the zone is
+// looked up using the zone's tags as managed zone labels. Results of label
+// lookups are cached.
+func (m *manager) getZone(zoneConfig configv1.DNSZone) (*client.Zone, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if len(zoneConfig.ID) > 0 {
+		return &client.Zone{Name: zoneConfig.ID}, nil
+	}
+
+	if len(zoneConfig.Tags) == 0 {
+		return nil, fmt.Errorf("zone has neither an ID nor tags")
+	}
+
+	for zone, labels := range m.zonesToLabels {
+		if reflect.DeepEqual(labels, zoneConfig.Tags) {
+			found := zone
+			return &found, nil
+		}
+	}
+
+	zone, err := m.client.GetZoneByLabels(context.TODO(), zoneConfig.Tags)
+	if err != nil {
+		return nil, err
+	}
+	m.zonesToLabels[*zone] = zoneConfig.Tags
+	log.Info("found managed zone using labels", "zone", zone.Name, "labels", zoneConfig.Tags)
+
+	return zone, nil
+}
+
+// recordName returns the fully qualified form of domain that Cloud DNS
+// expects, which includes the trailing dot.
+func recordName(domain string) string {
+	if strings.HasSuffix(domain, ".") {
+		return domain
+	}
+	return domain + "."
+}
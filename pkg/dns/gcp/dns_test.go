@@ -0,0 +1,223 @@
+package gcp_test
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns/gcp"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns/gcp/client"
+)
+
+func newARecord(zone configv1.DNSZone) *dns.Record {
+	return &dns.Record{
+		Zone: zone,
+		Type: dns.ARecordType,
+		ARecord: &dns.ARecord{
+			Domain:  "*.apps.example.com",
+			Address: "55.11.22.33",
+		},
+	}
+}
+
+func TestEnsureDNS(t *testing.T) {
+	fc, _ := client.NewFake(client.Config{})
+	fc.AddZone(client.Zone{Name: "public-zone", DNSName: "example.com."}, map[string]string{"cluster": "test"})
+	mgr, err := gcp.NewFakeManager(gcp.Config{}, fc)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	tests := []struct {
+		desc string
+		zone configv1.DNSZone
+	}{
+		{"zone by ID", configv1.DNSZone{ID: "public-zone"}},
+		{"zone by labels", configv1.DNSZone{Tags: map[string]string{"cluster": "test"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if err := mgr.Ensure(newARecord(tt.zone)); err != nil {
+				t.Fatalf("failed to ensure dns: %v", err)
+			}
+			recordedCall, _ := fc.RecordedCall("public-zone", "*.apps.example.com.")
+			if recordedCall != "PUT" {
+				t.Fatalf("expected the dns client 'Put' func to be called, but found %s instead", recordedCall)
+			}
+		})
+	}
+}
+
+func TestEnsureDNSUnknownLabels(t *testing.T) {
+	fc, _ := client.NewFake(client.Config{})
+	fc.AddZone(client.Zone{Name: "public-zone", DNSName: "example.com."}, map[string]string{"cluster": "test"})
+	mgr, err := gcp.NewFakeManager(gcp.Config{}, fc)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	if err := mgr.Ensure(newARecord(configv1.DNSZone{Tags: map[string]string{"cluster": "other"}})); err == nil {
+		t.Fatalf("expected an error for a zone with unknown labels")
+	}
+	if _, ok := fc.RecordedCall("public-zone", "*.apps.example.com."); ok {
+		t.Fatalf("expected no call to the dns client")
+	}
+}
+
+func TestDeleteDNS(t *testing.T) {
+	fc, _ := client.NewFake(client.Config{})
+	mgr, err := gcp.NewFakeManager(gcp.Config{}, fc)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	if err := mgr.Delete(newARecord(configv1.DNSZone{ID: "public-zone"})); err != nil {
+		t.Fatalf("failed to delete dns: %v", err)
+	}
+	recordedCall, _ := fc.RecordedCall("public-zone", "*.apps.example.com.")
+	if recordedCall != "DELETE" {
+		t.Fatalf("expected the dns client 'Delete' func to be called, but found %s instead", recordedCall)
+	}
+}
+
+func TestGetDNS(t *testing.T) {
+	fc, _ := client.NewFake(client.Config{})
+	mgr, err := gcp.NewFakeManager(gcp.Config{}, fc)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	record := newARecord(configv1.DNSZone{ID: "public-zone"})
+	current, err := mgr.Get(record)
+	if err != nil {
+		t.Fatalf("failed to get dns: %v", err)
+	}
+	if current != nil {
+		t.Fatalf("expected no record before ensure, got %v", current)
+	}
+
+	if err := mgr.Ensure(record); err != nil {
+		t.Fatalf("failed to ensure dns: %v", err)
+	}
+	current, err = mgr.Get(record)
+	if err != nil {
+		t.Fatalf("failed to get dns: %v", err)
+	}
+	if current == nil || current.ARecord == nil || current.ARecord.Address != record.ARecord.Address {
+		t.Fatalf("expected record %v, got %v", record, current)
+	}
+}
+
+func TestTXTRecord(t *testing.T) {
+	fc, _ := client.NewFake(client.Config{})
+	mgr, err := gcp.NewFakeManager(gcp.Config{}, fc)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	record := &dns.Record{
+		Zone: configv1.DNSZone{ID: "public-zone"},
+		Type: dns.TXTRecordType,
+		TXTRecord: &dns.TXTRecord{
+			Domain: "_owner.apps.example.com",
+			Text:   "heritage=openshift-ingress-operator",
+		},
+	}
+	if err := mgr.Ensure(record); err != nil {
+		t.Fatalf("failed to ensure dns: %v", err)
+	}
+	if recordedCall, _ := fc.RecordedCall("public-zone", "_owner.apps.example.com."); recordedCall != "PUT" {
+		t.Fatalf("expected the dns client 'PutTXT' func to be called, but found %s instead", recordedCall)
+	}
+	current, err := mgr.Get(record)
+	if err != nil {
+		t.Fatalf("failed to get dns: %v", err)
+	}
+	if current == nil || current.TXTRecord == nil || current.TXTRecord.Text != record.TXTRecord.Text {
+		t.Fatalf("expected record %v, got %v", record, current)
+	}
+
+	if err := mgr.Delete(record); err != nil {
+		t.Fatalf("failed to delete dns: %v", err)
+	}
+	if recordedCall, _ := fc.RecordedCall("public-zone", "_owner.apps.example.com."); recordedCall != "DELETE" {
+		t.Fatalf("expected the dns client 'DeleteTXT' func to be called, but found %s instead", recordedCall)
+	}
+}
+
+func TestCNAMERecord(t *testing.T) {
+	fc, _ := client.NewFake(client.Config{})
+	mgr, err := gcp.NewFakeManager(gcp.Config{}, fc)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	record := &dns.Record{
+		Zone: configv1.DNSZone{ID: "public-zone"},
+		Type: dns.CNAMERecordType,
+		CNAMERecord: &dns.CNAMERecord{
+			Domain: "*.apps.example.com",
+			Target: "lb.example.com",
+		},
+	}
+	if err := mgr.Ensure(record); err != nil {
+		t.Fatalf("failed to ensure dns: %v", err)
+	}
+	if recordedCall, _ := fc.RecordedCall("public-zone", "*.apps.example.com."); recordedCall != "PUT" {
+		t.Fatalf("expected the dns client 'PutCNAME' func to be called, but found %s instead", recordedCall)
+	}
+	current, err := mgr.Get(record)
+	if err != nil {
+		t.Fatalf("failed to get dns: %v", err)
+	}
+	if current == nil || current.CNAMERecord == nil || current.CNAMERecord.Target != record.CNAMERecord.Target {
+		t.Fatalf("expected record %v, got %v", record, current)
+	}
+
+	if err := mgr.Delete(record); err != nil {
+		t.Fatalf("failed to delete dns: %v", err)
+	}
+	if recordedCall, _ := fc.RecordedCall("public-zone", "*.apps.example.com."); recordedCall != "DELETE" {
+		t.Fatalf("expected the dns client 'DeleteCNAME' func to be called, but found %s instead", recordedCall)
+	}
+}
+
+func TestMultipleAddresses(t *testing.T) {
+	fc, _ := client.NewFake(client.Config{})
+	mgr, err := gcp.NewFakeManager(gcp.Config{}, fc)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	zone := configv1.DNSZone{ID: "public-zone"}
+	newRecord := func(address string) *dns.Record {
+		record := newARecord(zone)
+		record.ARecord.Address = address
+		return record
+	}
+	for _, address := range []string{"55.11.22.33", "55.11.22.34"} {
+		if err := mgr.Ensure(newRecord(address)); err != nil {
+			t.Fatalf("failed to ensure dns: %v", err)
+		}
+	}
+	for _, address := range []string{"55.11.22.33", "55.11.22.34"} {
+		current, err := mgr.Get(newRecord(address))
+		if err != nil {
+			t.Fatalf("failed to get dns: %v", err)
+		}
+		if current == nil || current.ARecord.Address != address {
+			t.Fatalf("expected record with address %s, got %v", address, current)
+		}
+	}
+
+	if err := mgr.Delete(newRecord("55.11.22.33")); err != nil {
+		t.Fatalf("failed to delete dns: %v", err)
+	}
+	current, err := mgr.Get(newRecord("55.11.22.33"))
+	if err != nil {
+		t.Fatalf("failed to get dns: %v", err)
+	}
+	if current == nil || current.ARecord.Address != "55.11.22.34" {
+		t.Fatalf("expected the other address to remain, got %v", current)
+	}
+}
@@ -0,0 +1,10 @@
+package gcp
+
+import (
+	dns "github.com/openshift/cluster-ingress-operator/pkg/dns"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns/gcp/client"
+)
+
+func NewFakeManager(config Config, client client.DNSClient) (dns.Manager, error) {
+	return newManager(config, client), nil
+}
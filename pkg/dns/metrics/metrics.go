@@ -0,0 +1,232 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	_ dns.BatchManager         = &manager{}
+	_ dns.PropagationTracker   = &manager{}
+	_ dns.CredentialsValidator = &manager{}
+)
+
+// action is the kind of operation that a DNS manager performs.
+type action string
+
+const (
+	ensureAction   action = "ensure"
+	deleteAction   action = "delete"
+	getAction      action = "get"
+	pendingAction  action = "pending"
+	validateAction action = "validate"
+)
+
+const (
+	// successOutcome is the outcome of an operation that succeeded.
+	successOutcome = "success"
+	// errorOutcome is the outcome of an operation that failed.
+	errorOutcome = "error"
+)
+
+var (
+	// operationsTotal counts the operations of the DNS managers.
+	operationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ingress_operator_dns_operations_total",
+			Help: "Number of DNS provider operations by provider, zone, record type, action, and outcome.",
+		},
+		[]string{"provider", "zone", "record_type", "action", "outcome"},
+	)
+	// operationDuration observes the latencies of the operations of the
+	// DNS managers.
+	operationDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "ingress_operator_dns_operation_duration_seconds",
+			Help:    "Latency of DNS provider operations by provider, zone, record type, action, and outcome.",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		},
+		[]string{"provider", "zone", "record_type", "action", "outcome"},
+	)
+)
+
+func init() {
+	// Serve the metrics on the operator's controller-runtime metrics
+	// endpoint.
+	metrics.Registry.MustRegister(operationsTotal, operationDuration)
+}
+
+// manager is a dns.Manager that records metrics of the operations of the
+// manager that it wraps.
+type manager struct {
+	provider string
+	manager  dns.Manager
+}
+
+// NewManager returns a dns.Manager that delegates to m and records the outcome
+// and latency of each of its operations, labelled with provider. The returned
+// manager supports batches, propagation tracking, and credentials validation
+// whether or not m does.
+func NewManager(provider string, m dns.Manager) dns.Manager {
+	return &manager{provider: provider, manager: m}
+}
+
+func (m *manager) Ensure(record *dns.Record) error {
+	start := time.Now()
+	err := m.manager.Ensure(record)
+	m.observe(record.Zone, record.Type, ensureAction, start, err)
+	return err
+}
+
+func (m *manager) Delete(record *dns.Record) error {
+	start := time.Now()
+	err := m.manager.Delete(record)
+	m.observe(record.Zone, record.Type, deleteAction, start, err)
+	return err
+}
+
+func (m *manager) Get(record *dns.Record) (*dns.Record, error) {
+	start := time.Now()
+	current, err := m.manager.Get(record)
+	m.observe(record.Zone, record.Type, getAction, start, err)
+	return current, err
+}
+
+func (m *manager) SupportsRecordType(zone configv1.DNSZone, recordType dns.RecordType) bool {
+	return m.manager.SupportsRecordType(zone, recordType)
+}
+
+// Pending returns whether the record has yet to propagate, if the wrapped
+// manager tracks propagation, or false otherwise.
+func (m *manager) Pending(record *dns.Record) (bool, error) {
+	tracker, ok := m.manager.(dns.PropagationTracker)
+	if !ok {
+		return false, nil
+	}
+	start := time.Now()
+	pending, err := tracker.Pending(record)
+	m.observe(record.Zone, record.Type, pendingAction, start, err)
+	return pending, err
+}
+
+// ValidateCredentials validates the credentials of the wrapped manager, if it
+// can, or returns nil otherwise.
+func (m *manager) ValidateCredentials() error {
+	validator, ok := m.manager.(dns.CredentialsValidator)
+	if !ok {
+		return nil
+	}
+	start := time.Now()
+	err := validator.ValidateCredentials()
+	m.observe(configv1.DNSZone{}, "", validateAction, start, err)
+	return err
+}
+
+// NewBatch returns a batch that records the outcome of each change when the
+// batch is committed, if the wrapped manager supports batches, or a batch that
+// applies and records every change immediately otherwise.
+func (m *manager) NewBatch() dns.Batch {
+	batchManager, ok := m.manager.(dns.BatchManager)
+	if !ok {
+		return &dns.ImmediateBatch{Manager: m}
+	}
+	return &batch{manager: m, batch: batchManager.NewBatch()}
+}
+
+// observe records an operation that started at start and returned err.
+func (m *manager) observe(zone configv1.DNSZone, recordType dns.RecordType, action action, start time.Time, err error) {
+	outcome := successOutcome
+	// An unsupported record type is not a failure of the DNS provider.
+	if err != nil && !dns.IsUnsupportedRecordType(err) {
+		outcome = errorOutcome
+	}
+	labels := prometheus.Labels{
+		"provider":    m.provider,
+		"zone":        zoneLabel(zone),
+		"record_type": string(recordType),
+		"action":      string(action),
+		"outcome":     outcome,
+	}
+	operationsTotal.With(labels).Inc()
+	operationDuration.With(labels).Observe(time.Since(start).Seconds())
+}
+
+// change is a change that a batch queued.
+type change struct {
+	zone       configv1.DNSZone
+	recordType dns.RecordType
+	action     action
+}
+
+// batch is a dns.Batch that records the outcome of each queued change when it
+// is committed, with the duration of the commit as the change's latency.
+type batch struct {
+	*manager
+
+	batch   dns.Batch
+	changes []change
+}
+
+func (b *batch) Ensure(record *dns.Record) error {
+	return b.queue(record, ensureAction, b.batch.Ensure)
+}
+
+func (b *batch) Delete(record *dns.Record) error {
+	return b.queue(record, deleteAction, b.batch.Delete)
+}
+
+func (b *batch) Get(record *dns.Record) (*dns.Record, error) {
+	start := time.Now()
+	current, err := b.batch.Get(record)
+	b.observe(record.Zone, record.Type, getAction, start, err)
+	return current, err
+}
+
+// queue queues a change with the given function, and records the change as
+// failed if it could not be queued.
+func (b *batch) queue(record *dns.Record, action action, f func(*dns.Record) error) error {
+	start := time.Now()
+	if err := f(record); err != nil {
+		b.observe(record.Zone, record.Type, action, start, err)
+		return err
+	}
+	b.changes = append(b.changes, change{zone: record.Zone, recordType: record.Type, action: action})
+	return nil
+}
+
+func (b *batch) Commit() []dns.ZoneError {
+	start := time.Now()
+	zoneErrs := b.batch.Commit()
+	failed := map[string]error{}
+	for _, zoneErr := range zoneErrs {
+		failed[zoneLabel(zoneErr.Zone)] = zoneErr.Err
+	}
+	for _, c := range b.changes {
+		b.observe(c.zone, c.recordType, c.action, start, failed[zoneLabel(c.zone)])
+	}
+	b.changes = nil
+	return zoneErrs
+}
+
+// zoneLabel returns the value of the zone label for zone, which is the zone's
+// ID, or else its tags sorted by key.
+func zoneLabel(zone configv1.DNSZone) string {
+	if len(zone.ID) > 0 {
+		return zone.ID
+	}
+	tags := make([]string, 0, len(zone.Tags))
+	for k, v := range zone.Tags {
+		tags = append(tags, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(tags)
+	return strings.Join(tags, ",")
+}
@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// fakeBatchManager is a dns.FakeManager whose batches fail to commit with
+// commitErr.
+type fakeBatchManager struct {
+	*dns.FakeManager
+	commitErr error
+	credsErr  error
+}
+
+func (m *fakeBatchManager) NewBatch() dns.Batch {
+	return &fakeBatch{FakeManager: m.FakeManager, commitErr: m.commitErr}
+}
+
+func (m *fakeBatchManager) ValidateCredentials() error {
+	return m.credsErr
+}
+
+type fakeBatch struct {
+	*dns.FakeManager
+	commitErr error
+}
+
+func (b *fakeBatch) Commit() []dns.ZoneError {
+	if b.commitErr != nil {
+		return []dns.ZoneError{{Zone: configv1.DNSZone{ID: "batched"}, Err: b.commitErr}}
+	}
+	return nil
+}
+
+func aRecord(zone configv1.DNSZone) *dns.Record {
+	return &dns.Record{
+		Zone:    zone,
+		Type:    dns.ARecordType,
+		ARecord: &dns.ARecord{Domain: "apps.example.com", Address: "192.0.2.1"},
+	}
+}
+
+// count returns the number of operations recorded with the given labels.
+func count(t *testing.T, provider, zone string, action action, outcome string) float64 {
+	t.Helper()
+	m := &dto.Metric{}
+	if err := operationsTotal.WithLabelValues(provider, zone, string(dns.ARecordType), string(action), outcome).Write(m); err != nil {
+		t.Fatalf("failed to read counter: %v", err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+func TestManager(t *testing.T) {
+	zone := configv1.DNSZone{ID: "plain"}
+	failing := NewManager("failing", &dns.FakeManager{RecordTypes: []dns.RecordType{dns.ARecordType}, Err: errors.New("throttled")})
+	working := NewManager("working", dns.NewFakeManager(dns.ARecordType))
+
+	for i := 0; i < 2; i++ {
+		if err := failing.Ensure(aRecord(zone)); err == nil {
+			t.Fatalf("expected an error")
+		}
+		if err := working.Ensure(aRecord(zone)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := working.Get(aRecord(zone)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := count(t, "failing", "plain", ensureAction, errorOutcome); n != 2 {
+		t.Errorf("expected 2 failed ensures, got %v", n)
+	}
+	if n := count(t, "failing", "plain", ensureAction, successOutcome); n != 0 {
+		t.Errorf("expected no successful ensures, got %v", n)
+	}
+	if n := count(t, "working", "plain", ensureAction, successOutcome); n != 2 {
+		t.Errorf("expected 2 successful ensures, got %v", n)
+	}
+	if n := count(t, "working", "plain", getAction, successOutcome); n != 1 {
+		t.Errorf("expected 1 successful get, got %v", n)
+	}
+
+	// A manager without batches gets a batch that records its changes
+	// immediately.
+	batch := working.(dns.BatchManager).NewBatch()
+	if err := batch.Delete(aRecord(zone)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := count(t, "working", "plain", deleteAction, successOutcome); n != 1 {
+		t.Errorf("expected 1 successful delete before commit, got %v", n)
+	}
+	if errs := batch.Commit(); len(errs) != 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+
+	// A manager that can't validate credentials is assumed to have valid
+	// credentials.
+	if err := working.(dns.CredentialsValidator).ValidateCredentials(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	zone := configv1.DNSZone{ID: "batched"}
+	mgr := NewManager("batched", &fakeBatchManager{FakeManager: dns.NewFakeManager(dns.ARecordType), commitErr: errors.New("throttled"), credsErr: errors.New("invalid credentials")})
+
+	batch := mgr.(dns.BatchManager).NewBatch()
+	for i := 0; i < 3; i++ {
+		if err := batch.Ensure(aRecord(zone)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if n := count(t, "batched", "batched", ensureAction, errorOutcome); n != 0 {
+		t.Errorf("expected no changes to be recorded before commit, got %v", n)
+	}
+	if errs := batch.Commit(); len(errs) != 1 {
+		t.Errorf("expected 1 zone error, got %v", errs)
+	}
+	if n := count(t, "batched", "batched", ensureAction, errorOutcome); n != 3 {
+		t.Errorf("expected 3 failed ensures after commit, got %v", n)
+	}
+
+	if err := mgr.(dns.CredentialsValidator).ValidateCredentials(); err == nil {
+		t.Errorf("expected the wrapped manager's credentials error")
+	}
+}
+
+func TestZoneLabel(t *testing.T) {
+	zone := configv1.DNSZone{Tags: map[string]string{"b": "2", "a": "1"}}
+	if label := zoneLabel(zone); label != "a=1,b=2" {
+		t.Errorf("expected tags sorted by key, got %q", label)
+	}
+	zone.ID = "Z1"
+	if label := zoneLabel(zone); label != "Z1" {
+		t.Errorf("expected the zone ID, got %q", label)
+	}
+}
@@ -0,0 +1,241 @@
+package multiplexer
+
+import (
+	"fmt"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
+)
+
+var (
+	_   dns.BatchManager         = &manager{}
+	_   dns.PropagationTracker   = &manager{}
+	_   dns.CredentialsValidator = &manager{}
+	log                          = logf.Logger.WithName("dns")
+)
+
+// Backend is a DNS manager and the zones for which it manages records.
+type Backend struct {
+	// Name identifies the backend in logs and errors.
+	Name string
+	// ZoneID, if not empty, restricts the backend to the zone with this ID.
+	ZoneID string
+	// ZoneTags, if not empty, restricts the backend to zones whose tags
+	// include all of these tags.
+	ZoneTags map[string]string
+	// Manager manages the records of the backend.
+	Manager dns.Manager
+}
+
+// Config is the necessary input to configure the multiplexer.
+type Config struct {
+	// Backends are the backends in order of precedence. Each record is
+	// dispatched to the first backend that matches the record's zone. A
+	// backend with neither a zone ID nor zone tags matches every zone.
+	Backends []Backend
+}
+
+// manager is a dns.Manager that dispatches each record to one of several
+// backends, chosen by the record's zone.
+type manager struct {
+	config Config
+}
+
+func NewManager(config Config) (dns.Manager, error) {
+	names := map[string]struct{}{}
+	for _, backend := range config.Backends {
+		if len(backend.Name) == 0 {
+			return nil, fmt.Errorf("backend name is required")
+		}
+		if _, ok := names[backend.Name]; ok {
+			return nil, fmt.Errorf("duplicate backend name %q", backend.Name)
+		}
+		names[backend.Name] = struct{}{}
+		if backend.Manager == nil {
+			return nil, fmt.Errorf("backend %q has no manager", backend.Name)
+		}
+	}
+	return &manager{config: config}, nil
+}
+
+// BackendError is an error returned by a backend.
+type BackendError struct {
+	// Backend is the name of the backend.
+	Backend string
+	// Err is the error that the backend returned.
+	Err error
+}
+
+func (e *BackendError) Error() string {
+	return fmt.Sprintf("dns backend %q: %v", e.Backend, e.Err)
+}
+
+func (m *manager) Ensure(record *dns.Record) error {
+	backend, err := m.backendFor(record.Zone)
+	if err != nil {
+		return err
+	}
+	return wrap(backend, backend.Manager.Ensure(record))
+}
+
+func (m *manager) Delete(record *dns.Record) error {
+	backend, err := m.backendFor(record.Zone)
+	if err != nil {
+		return err
+	}
+	return wrap(backend, backend.Manager.Delete(record))
+}
+
+func (m *manager) Get(record *dns.Record) (*dns.Record, error) {
+	backend, err := m.backendFor(record.Zone)
+	if err != nil {
+		return nil, err
+	}
+	current, err := backend.Manager.Get(record)
+	return current, wrap(backend, err)
+}
+
+// SupportsRecordType returns true if the backend that matches zone supports
+// the given record type, or false if no backend matches zone.
+func (m *manager) SupportsRecordType(zone configv1.DNSZone, recordType dns.RecordType) bool {
+	backend, err := m.backendFor(zone)
+	if err != nil {
+		return false
+	}
+	return backend.Manager.SupportsRecordType(zone, recordType)
+}
+
+// Pending returns whether the record has yet to propagate, if the backend that
+// matches the record's zone tracks propagation, or false otherwise.
+func (m *manager) Pending(record *dns.Record) (bool, error) {
+	backend, err := m.backendFor(record.Zone)
+	if err != nil {
+		return false, err
+	}
+	tracker, ok := backend.Manager.(dns.PropagationTracker)
+	if !ok {
+		return false, nil
+	}
+	pending, err := tracker.Pending(record)
+	return pending, wrap(backend, err)
+}
+
+// ValidateCredentials validates the credentials of every backend that can
+// validate its credentials, and returns the first error, wrapped in a
+// BackendError.
+func (m *manager) ValidateCredentials() error {
+	for i := range m.config.Backends {
+		backend := &m.config.Backends[i]
+		validator, ok := backend.Manager.(dns.CredentialsValidator)
+		if !ok {
+			continue
+		}
+		if err := validator.ValidateCredentials(); err != nil {
+			return wrap(backend, err)
+		}
+	}
+	return nil
+}
+
+// NewBatch returns a batch that queues the changes for backends that support
+// batches and applies the changes for other backends immediately.
+func (m *manager) NewBatch() dns.Batch {
+	return &batch{manager: m, batches: map[string]dns.Batch{}}
+}
+
+// batch is a dns.Batch that dispatches each record to the batch of the
+// backend that matches the record's zone.
+type batch struct {
+	*manager
+
+	// names are the names of the backends in batches, in the order in
+	// which their batches were created.
+	names []string
+	// batches maps a backend name to the batch of the backend.
+	batches map[string]dns.Batch
+}
+
+func (b *batch) Ensure(record *dns.Record) error {
+	backend, mgr, err := b.backendManagerFor(record.Zone)
+	if err != nil {
+		return err
+	}
+	return wrap(backend, mgr.Ensure(record))
+}
+
+func (b *batch) Delete(record *dns.Record) error {
+	backend, mgr, err := b.backendManagerFor(record.Zone)
+	if err != nil {
+		return err
+	}
+	return wrap(backend, mgr.Delete(record))
+}
+
+// Commit commits the batch of each backend. The errors are wrapped in a
+// BackendError.
+func (b *batch) Commit() []dns.ZoneError {
+	errs := []dns.ZoneError{}
+	for _, name := range b.names {
+		for _, zoneErr := range b.batches[name].Commit() {
+			errs = append(errs, dns.ZoneError{Zone: zoneErr.Zone, Err: &BackendError{Backend: name, Err: zoneErr.Err}, Committed: zoneErr.Committed})
+		}
+	}
+	b.names = nil
+	b.batches = map[string]dns.Batch{}
+	return errs
+}
+
+// backendManagerFor returns the backend that matches zone and the manager to
+// which the batch dispatches its records, which is either a batch of the
+// backend or the backend's manager.
+func (b *batch) backendManagerFor(zone configv1.DNSZone) (*Backend, dns.Manager, error) {
+	backend, err := b.backendFor(zone)
+	if err != nil {
+		return nil, nil, err
+	}
+	batchManager, ok := backend.Manager.(dns.BatchManager)
+	if !ok {
+		return backend, backend.Manager, nil
+	}
+	if _, ok := b.batches[backend.Name]; !ok {
+		b.names = append(b.names, backend.Name)
+		b.batches[backend.Name] = batchManager.NewBatch()
+	}
+	return backend, b.batches[backend.Name], nil
+}
+
+// backendFor returns the first backend that matches zone.
+func (m *manager) backendFor(zone configv1.DNSZone) (*Backend, error) {
+	for i := range m.config.Backends {
+		backend := &m.config.Backends[i]
+		if matches(backend, zone) {
+			log.V(1).Info("dispatching record to dns backend", "backend", backend.Name, "zone", zone)
+			return backend, nil
+		}
+	}
+	return nil, fmt.Errorf("no dns backend matches zone %v", zone)
+}
+
+// matches returns true if the backend manages records in zone.
+func matches(backend *Backend, zone configv1.DNSZone) bool {
+	if len(backend.ZoneID) > 0 && backend.ZoneID != zone.ID {
+		return false
+	}
+	for k, v := range backend.ZoneTags {
+		if tag, ok := zone.Tags[k]; !ok || tag != v {
+			return false
+		}
+	}
+	return true
+}
+
+// wrap returns err annotated with the backend that returned it. Errors that
+// indicate an unsupported record type are returned as they are so that
+// callers can still recognize them.
+func wrap(backend *Backend, err error) error {
+	if err == nil || dns.IsUnsupportedRecordType(err) {
+		return err
+	}
+	return &BackendError{Backend: backend.Name, Err: err}
+}
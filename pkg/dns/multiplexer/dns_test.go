@@ -0,0 +1,247 @@
+package multiplexer_test
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns/multiplexer"
+)
+
+// domains returns the sorted domains of the A records of the given manager.
+func domains(m *dns.FakeManager) []string {
+	domains := []string{}
+	for _, record := range m.Records {
+		domains = append(domains, record.ARecord.Domain)
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+func aRecord(domain string, zone configv1.DNSZone) *dns.Record {
+	return &dns.Record{
+		Zone:    zone,
+		Type:    dns.ARecordType,
+		ARecord: &dns.ARecord{Domain: domain, Address: "192.0.2.1"},
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	private := dns.NewFakeManager(dns.ARecordType)
+	public := dns.NewFakeManager(dns.ARecordType)
+	fallback := dns.NewFakeManager(dns.ARecordType)
+	mgr, err := multiplexer.NewManager(multiplexer.Config{
+		Backends: []multiplexer.Backend{
+			{Name: "route53", ZoneTags: map[string]string{"kubernetes.io/cluster/test": "owned"}, Manager: private},
+			{Name: "public", ZoneID: "example.com", Manager: public},
+			{Name: "fallback", Manager: fallback},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	records := []*dns.Record{
+		aRecord("private", configv1.DNSZone{Tags: map[string]string{"kubernetes.io/cluster/test": "owned", "Name": "test-int"}}),
+		aRecord("public", configv1.DNSZone{ID: "example.com"}),
+		aRecord("other", configv1.DNSZone{ID: "example.org"}),
+	}
+	backends := map[string]struct {
+		manager *dns.FakeManager
+		expect  string
+	}{
+		"route53":  {private, "private"},
+		"public":   {public, "public"},
+		"fallback": {fallback, "other"},
+	}
+
+	for _, record := range records {
+		if err := mgr.Ensure(record); err != nil {
+			t.Fatalf("failed to ensure record %v: %v", record, err)
+		}
+	}
+	for name, tc := range backends {
+		if actual := domains(tc.manager); len(actual) != 1 || actual[0] != tc.expect {
+			t.Errorf("expected backend %s to ensure only %q, got %v", name, tc.expect, actual)
+		}
+	}
+
+	for _, record := range records {
+		if err := mgr.Delete(record); err != nil {
+			t.Fatalf("failed to delete record %v: %v", record, err)
+		}
+	}
+	for name, tc := range backends {
+		if actual := domains(tc.manager); len(actual) != 0 {
+			t.Errorf("expected backend %s to delete %q, got %v", name, tc.expect, actual)
+		}
+	}
+}
+
+func TestNoMatchingBackend(t *testing.T) {
+	mgr, err := multiplexer.NewManager(multiplexer.Config{
+		Backends: []multiplexer.Backend{
+			{Name: "public", ZoneID: "example.com", Manager: dns.NewFakeManager(dns.ARecordType)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	if err := mgr.Ensure(aRecord("other", configv1.DNSZone{ID: "example.org"})); err == nil {
+		t.Errorf("expected an error for a zone without a backend")
+	}
+}
+
+func TestSupportsRecordType(t *testing.T) {
+	mgr, err := multiplexer.NewManager(multiplexer.Config{
+		Backends: []multiplexer.Backend{
+			{Name: "route53", ZoneID: "private.example.com", Manager: dns.NewFakeManager(dns.ALIASRecord)},
+			{Name: "public", ZoneID: "example.com", Manager: dns.NewFakeManager(dns.CNAMERecordType)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	tests := []struct {
+		zone       string
+		recordType dns.RecordType
+		expect     bool
+	}{
+		{"private.example.com", dns.ALIASRecord, true},
+		{"private.example.com", dns.CNAMERecordType, false},
+		{"example.com", dns.ALIASRecord, false},
+		{"example.com", dns.CNAMERecordType, true},
+		{"example.org", dns.CNAMERecordType, false},
+	}
+	for _, test := range tests {
+		if actual := mgr.SupportsRecordType(configv1.DNSZone{ID: test.zone}, test.recordType); actual != test.expect {
+			t.Errorf("zone %s, type %s: expected %t, got %t", test.zone, test.recordType, test.expect, actual)
+		}
+	}
+}
+
+func TestBackendErrors(t *testing.T) {
+	mgr, err := multiplexer.NewManager(multiplexer.Config{
+		Backends: []multiplexer.Backend{
+			{Name: "broken", ZoneID: "broken.example.com", Manager: &dns.FakeManager{RecordTypes: []dns.RecordType{dns.ARecordType}, Err: errors.New("throttled")}},
+			{Name: "unsupported", ZoneID: "unsupported.example.com", Manager: dns.NewFakeManager()},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	err = mgr.Ensure(aRecord("a", configv1.DNSZone{ID: "broken.example.com"}))
+	if backendErr, ok := err.(*multiplexer.BackendError); !ok || backendErr.Backend != "broken" {
+		t.Errorf("expected an error from backend %q, got %v", "broken", err)
+	}
+
+	err = mgr.Ensure(aRecord("a", configv1.DNSZone{ID: "unsupported.example.com"}))
+	if !dns.IsUnsupportedRecordType(err) {
+		t.Errorf("expected an unsupported record type error, got %v", err)
+	}
+}
+
+func TestNewManagerValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		backends []multiplexer.Backend
+	}{
+		{"missing name", []multiplexer.Backend{{Manager: dns.NewFakeManager()}}},
+		{"duplicate name", []multiplexer.Backend{{Name: "a", Manager: dns.NewFakeManager()}, {Name: "a", Manager: dns.NewFakeManager()}}},
+		{"missing manager", []multiplexer.Backend{{Name: "a"}}},
+	}
+	for _, test := range tests {
+		if _, err := multiplexer.NewManager(multiplexer.Config{Backends: test.backends}); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
+
+// fakeBatchManager is a dns.FakeManager that supports batches and propagation
+// tracking.
+type fakeBatchManager struct {
+	*dns.FakeManager
+	commits   int
+	commitErr error
+	pending   bool
+}
+
+func (m *fakeBatchManager) NewBatch() dns.Batch {
+	return &fakeBatch{FakeManager: m.FakeManager, parent: m}
+}
+
+func (m *fakeBatchManager) Pending(record *dns.Record) (bool, error) {
+	return m.pending, nil
+}
+
+// fakeBatch applies the changes it is asked to make immediately with the
+// dns.FakeManager of its parent.
+type fakeBatch struct {
+	*dns.FakeManager
+	parent *fakeBatchManager
+}
+
+func (b *fakeBatch) Commit() []dns.ZoneError {
+	b.parent.commits++
+	if b.parent.commitErr != nil {
+		return []dns.ZoneError{{Zone: configv1.DNSZone{ID: "batched.example.com"}, Err: b.parent.commitErr}}
+	}
+	return nil
+}
+
+func TestBatch(t *testing.T) {
+	batched := &fakeBatchManager{FakeManager: dns.NewFakeManager(dns.ARecordType), commitErr: errors.New("throttled"), pending: true}
+	if err := batched.Ensure(aRecord("b", configv1.DNSZone{ID: "batched.example.com"})); err != nil {
+		t.Fatalf("failed to ensure record: %v", err)
+	}
+	unbatched := dns.NewFakeManager(dns.ARecordType)
+	mgr, err := multiplexer.NewManager(multiplexer.Config{
+		Backends: []multiplexer.Backend{
+			{Name: "batched", ZoneID: "batched.example.com", Manager: batched},
+			{Name: "unbatched", Manager: unbatched},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	b := mgr.(dns.BatchManager).NewBatch()
+	if err := b.Ensure(aRecord("a", configv1.DNSZone{ID: "batched.example.com"})); err != nil {
+		t.Fatalf("failed to ensure record: %v", err)
+	}
+	if err := b.Delete(aRecord("b", configv1.DNSZone{ID: "batched.example.com"})); err != nil {
+		t.Fatalf("failed to delete record: %v", err)
+	}
+	if err := b.Ensure(aRecord("c", configv1.DNSZone{ID: "other.example.com"})); err != nil {
+		t.Fatalf("failed to ensure record: %v", err)
+	}
+	if actual, other := domains(batched.FakeManager), domains(unbatched); len(actual) != 1 || actual[0] != "a" || len(other) != 1 || other[0] != "c" {
+		t.Errorf("expected records to be dispatched, got batched %v and unbatched %v", actual, other)
+	}
+
+	errs := b.Commit()
+	if batched.commits != 1 {
+		t.Errorf("expected 1 commit, got %d", batched.commits)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 zone error, got %v", errs)
+	}
+	if backendErr, ok := errs[0].Err.(*multiplexer.BackendError); !ok || backendErr.Backend != "batched" {
+		t.Errorf("expected an error from backend %q, got %v", "batched", errs[0].Err)
+	}
+	if errs := b.Commit(); len(errs) != 0 || batched.commits != 1 {
+		t.Errorf("expected a committed batch to be empty, got %d commits and errors %v", batched.commits, errs)
+	}
+
+	tracker := mgr.(dns.PropagationTracker)
+	if pending, err := tracker.Pending(aRecord("a", configv1.DNSZone{ID: "batched.example.com"})); err != nil || !pending {
+		t.Errorf("expected record in batched zone to be pending, got %v, %v", pending, err)
+	}
+	if pending, err := tracker.Pending(aRecord("c", configv1.DNSZone{ID: "other.example.com"})); err != nil || pending {
+		t.Errorf("expected record in untracked zone not to be pending, got %v, %v", pending, err)
+	}
+}
@@ -0,0 +1,234 @@
+package dns
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+// ownershipRecordPrefix is the prefix of the first label of the name of every
+// ownership record.
+const ownershipRecordPrefix = "_openshift-ingress-owner"
+
+// Owner identifies the cluster and ingresscontroller that own a record.
+type Owner struct {
+	// ClusterID is the infrastructure name of the cluster.
+	ClusterID string
+
+	// IngressController is the name of the ingresscontroller.
+	IngressController string
+}
+
+// String returns the text of the ownership records of the owner.
+func (o Owner) String() string {
+	return fmt.Sprintf("heritage=openshift-ingress-operator,cluster=%s,ingresscontroller=%s", o.ClusterID, o.IngressController)
+}
+
+// OwnershipConflictError is returned when a record cannot be modified or
+// deleted because it is owned by someone else.
+type OwnershipConflictError struct {
+	// Domain is the name of the record.
+	Domain string
+
+	// Owner is the text of the record's ownership record, or empty if the
+	// record exists without an ownership record.
+	Owner string
+}
+
+func (e *OwnershipConflictError) Error() string {
+	if len(e.Owner) == 0 {
+		return fmt.Sprintf("record %s already exists with a different target and no owner", e.Domain)
+	}
+	return fmt.Sprintf("record %s is owned by %q", e.Domain, e.Owner)
+}
+
+// IsOwnershipConflict returns true if err indicates that a record is owned by
+// someone else.
+func IsOwnershipConflict(err error) bool {
+	_, ok := err.(*OwnershipConflictError)
+	return ok
+}
+
+// NewOwnershipManager returns a Manager that publishes, next to every record,
+// a TXT record whose text identifies owner, and that refuses to modify or
+// delete records whose ownership record identifies someone else. A record that
+// exists without an ownership record is adopted only if it already has the
+// desired target. If manager does not support TXT records, records are managed
+// without ownership records.
+//
+// retained are the records that stay published while records are deleted
+// through the returned manager. Records with the same zone, type, and name,
+// such as the addresses of an A or AAAA record set, share one ownership record,
+// which is deleted only along with the last of them.
+func NewOwnershipManager(manager Manager, owner Owner, retained ...*Record) Manager {
+	return &ownershipManager{manager: manager, owner: owner, retained: retained}
+}
+
+type ownershipManager struct {
+	manager  Manager
+	owner    Owner
+	retained []*Record
+}
+
+func (m *ownershipManager) Ensure(record *Record) error {
+	ownership, err := m.checkOwnership(record, true)
+	if err != nil {
+		return err
+	}
+	if ownership != nil {
+		if err := m.manager.Ensure(ownership); err != nil {
+			return fmt.Errorf("failed to ensure ownership record %s: %v", ownership.TXTRecord.Domain, err)
+		}
+	}
+	return m.manager.Ensure(record)
+}
+
+func (m *ownershipManager) Delete(record *Record) error {
+	ownership, err := m.checkOwnership(record, true)
+	if err != nil {
+		return err
+	}
+	if err := m.manager.Delete(record); err != nil {
+		return err
+	}
+	if ownership != nil && !m.isRetained(ownership) {
+		if err := m.manager.Delete(ownership); err != nil {
+			return fmt.Errorf("failed to delete ownership record %s: %v", ownership.TXTRecord.Domain, err)
+		}
+	}
+	return nil
+}
+
+func (m *ownershipManager) Get(record *Record) (*Record, error) {
+	if _, err := m.checkOwnership(record, false); err != nil {
+		return nil, err
+	}
+	return m.manager.Get(record)
+}
+
+func (m *ownershipManager) SupportsRecordType(zone configv1.DNSZone, recordType RecordType) bool {
+	return m.manager.SupportsRecordType(zone, recordType)
+}
+
+// isRetained returns true if the given ownership record is the ownership record
+// of one of the retained records.
+func (m *ownershipManager) isRetained(ownership *Record) bool {
+	for _, record := range m.retained {
+		other := OwnershipRecord(record, m.owner)
+		if reflect.DeepEqual(other.Zone, ownership.Zone) &&
+			strings.EqualFold(other.TXTRecord.Domain, ownership.TXTRecord.Domain) &&
+			reflect.DeepEqual(other.RoutingPolicy, ownership.RoutingPolicy) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkOwnership returns the ownership record of the given record, or nil if
+// the manager does not support TXT records. It returns an
+// OwnershipConflictError if the record's ownership record identifies someone
+// else or, if adopt is true, if the record exists with a different target and
+// without an ownership record.
+func (m *ownershipManager) checkOwnership(record *Record, adopt bool) (*Record, error) {
+	domain := recordDomain(record)
+	ownership := OwnershipRecord(record, m.owner)
+	current, err := m.manager.Get(ownership)
+	if err != nil {
+		if IsUnsupportedRecordType(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get ownership record %s: %v", ownership.TXTRecord.Domain, err)
+	}
+	if current != nil {
+		if current.TXTRecord == nil || current.TXTRecord.Text != ownership.TXTRecord.Text {
+			text := ""
+			if current.TXTRecord != nil {
+				text = current.TXTRecord.Text
+			}
+			return nil, &OwnershipConflictError{Domain: domain, Owner: text}
+		}
+		return ownership, nil
+	}
+	if adopt {
+		existing, err := m.manager.Get(record)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil && !sameTarget(existing, record) {
+			return nil, &OwnershipConflictError{Domain: domain}
+		}
+	}
+	return ownership, nil
+}
+
+// OwnershipRecord returns the TXT record that identifies owner as the owner of
+// record. The ownership record is in the same zone as the record, and its name
+// is derived from the record's name and type so that records of different
+// types have separate owners. The ownership record of a wildcard record such as
+// "*.apps.example.com" is named "_openshift-ingress-owner-a-wildcard.apps.example.com".
+// If the record has a routing policy, so does the ownership record, minus the
+// health check, so that every owner of a record with the same name has its own
+// ownership record.
+func OwnershipRecord(record *Record, owner Owner) *Record {
+	label := ownershipRecordPrefix + "-" + strings.ToLower(string(record.Type))
+	domain := recordDomain(record)
+	if strings.HasPrefix(domain, "*.") {
+		label += "-wildcard"
+		domain = strings.TrimPrefix(domain, "*.")
+	}
+	var policy *RoutingPolicy
+	if record.RoutingPolicy != nil {
+		p := *record.RoutingPolicy
+		p.HealthCheck = nil
+		policy = &p
+	}
+	return &Record{
+		Zone: record.Zone,
+		Type: TXTRecordType,
+		TXTRecord: &TXTRecord{
+			Domain: label + "." + domain,
+			Text:   owner.String(),
+		},
+		RoutingPolicy: policy,
+	}
+}
+
+// recordDomain returns the name of record.
+func recordDomain(record *Record) string {
+	switch {
+	case record.Type == ALIASRecord && record.Alias != nil:
+		return record.Alias.Domain
+	case record.Type == ARecordType && record.ARecord != nil:
+		return record.ARecord.Domain
+	case record.Type == AAAARecordType && record.AAAARecord != nil:
+		return record.AAAARecord.Domain
+	case record.Type == CNAMERecordType && record.CNAMERecord != nil:
+		return record.CNAMERecord.Domain
+	case record.Type == TXTRecordType && record.TXTRecord != nil:
+		return record.TXTRecord.Domain
+	}
+	return ""
+}
+
+// sameTarget returns true if a and b have the same type and target.
+func sameTarget(a, b *Record) bool {
+	if a.Type != b.Type {
+		return false
+	}
+	switch {
+	case a.Type == ALIASRecord && a.Alias != nil && b.Alias != nil:
+		return strings.EqualFold(strings.TrimSuffix(a.Alias.Target, "."), strings.TrimSuffix(b.Alias.Target, "."))
+	case a.Type == ARecordType && a.ARecord != nil && b.ARecord != nil:
+		return a.ARecord.Address == b.ARecord.Address
+	case a.Type == AAAARecordType && a.AAAARecord != nil && b.AAAARecord != nil:
+		return net.ParseIP(a.AAAARecord.Address).Equal(net.ParseIP(b.AAAARecord.Address))
+	case a.Type == CNAMERecordType && a.CNAMERecord != nil && b.CNAMERecord != nil:
+		return strings.EqualFold(strings.TrimSuffix(a.CNAMERecord.Target, "."), strings.TrimSuffix(b.CNAMERecord.Target, "."))
+	case a.Type == TXTRecordType && a.TXTRecord != nil && b.TXTRecord != nil:
+		return a.TXTRecord.Text == b.TXTRecord.Text
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package dns_test
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+)
+
+func aliasRecord(target string) *dns.Record {
+	return &dns.Record{
+		Zone: configv1.DNSZone{ID: "example.com"},
+		Type: dns.ALIASRecord,
+		Alias: &dns.AliasRecord{
+			Domain: "*.apps.example.com",
+			Target: target,
+		},
+	}
+}
+
+var (
+	owner = dns.Owner{ClusterID: "test-abcde", IngressController: "default"}
+	other = dns.Owner{ClusterID: "other-fghij", IngressController: "default"}
+)
+
+func TestOwnershipRecord(t *testing.T) {
+	ownership := dns.OwnershipRecord(aliasRecord("lb.example.com"), owner)
+	if ownership.Type != dns.TXTRecordType || ownership.Zone.ID != "example.com" {
+		t.Errorf("expected a TXT record in zone example.com, got %v", ownership)
+	}
+	if expected := "_openshift-ingress-owner-alias-wildcard.apps.example.com"; ownership.TXTRecord.Domain != expected {
+		t.Errorf("expected ownership record %s, got %s", expected, ownership.TXTRecord.Domain)
+	}
+	if expected := "heritage=openshift-ingress-operator,cluster=test-abcde,ingresscontroller=default"; ownership.TXTRecord.Text != expected {
+		t.Errorf("expected ownership text %q, got %q", expected, ownership.TXTRecord.Text)
+	}
+	if ownership.RoutingPolicy != nil {
+		t.Errorf("expected no routing policy, got %v", ownership.RoutingPolicy)
+	}
+
+	// The ownership record of a record with a routing policy has the same
+	// routing policy, minus the health check.
+	record := aliasRecord("lb.example.com")
+	record.RoutingPolicy = &dns.RoutingPolicy{
+		Type:          dns.WeightedRoutingPolicy,
+		SetIdentifier: "test-abcde",
+		Weight:        100,
+		HealthCheck:   &dns.HealthCheck{Port: 443},
+	}
+	ownership = dns.OwnershipRecord(record, owner)
+	expected := dns.RoutingPolicy{Type: dns.WeightedRoutingPolicy, SetIdentifier: "test-abcde", Weight: 100}
+	if ownership.RoutingPolicy == nil || *ownership.RoutingPolicy != expected {
+		t.Errorf("expected routing policy %v, got %v", &expected, ownership.RoutingPolicy)
+	}
+	if record.RoutingPolicy.HealthCheck == nil {
+		t.Errorf("expected the record's health check to be left alone")
+	}
+}
+
+func TestOwnershipManager(t *testing.T) {
+	fake := dns.NewFakeManager(dns.ALIASRecord, dns.ARecordType, dns.TXTRecordType)
+	mgr := dns.NewOwnershipManager(fake, owner)
+
+	if err := mgr.Ensure(aliasRecord("lb.example.com")); err != nil {
+		t.Fatalf("failed to ensure record: %v", err)
+	}
+	ownership, _ := fake.Get(dns.OwnershipRecord(aliasRecord("lb.example.com"), owner))
+	if ownership == nil || ownership.TXTRecord.Text != owner.String() {
+		t.Fatalf("expected ownership record for %s, got %v", owner, ownership)
+	}
+
+	// Updating our own record is allowed.
+	if err := mgr.Ensure(aliasRecord("lb2.example.com")); err != nil {
+		t.Fatalf("failed to update record: %v", err)
+	}
+
+	// Another owner may neither modify, get, nor delete the record.
+	otherMgr := dns.NewOwnershipManager(fake, other)
+	if err := otherMgr.Ensure(aliasRecord("other.example.com")); !dns.IsOwnershipConflict(err) {
+		t.Errorf("expected ownership conflict on ensure, got %v", err)
+	}
+	if _, err := otherMgr.Get(aliasRecord("other.example.com")); !dns.IsOwnershipConflict(err) {
+		t.Errorf("expected ownership conflict on get, got %v", err)
+	}
+	if err := otherMgr.Delete(aliasRecord("lb2.example.com")); !dns.IsOwnershipConflict(err) {
+		t.Errorf("expected ownership conflict on delete, got %v", err)
+	}
+	if current, _ := fake.Get(aliasRecord("")); current == nil || current.Alias.Target != "lb2.example.com" {
+		t.Errorf("expected record to be unchanged, got %v", current)
+	}
+
+	// Deleting the record deletes the ownership record.
+	if err := mgr.Delete(aliasRecord("lb2.example.com")); err != nil {
+		t.Fatalf("failed to delete record: %v", err)
+	}
+	if len(fake.Records) != 0 {
+		t.Errorf("expected no records, got %v", fake.Records)
+	}
+}
+
+func TestOwnershipManagerRecordSet(t *testing.T) {
+	aRecord := func(address string) *dns.Record {
+		return &dns.Record{
+			Zone:    configv1.DNSZone{ID: "example.com"},
+			Type:    dns.ARecordType,
+			ARecord: &dns.ARecord{Domain: "*.apps.example.com", Address: address},
+		}
+	}
+	fake := dns.NewFakeManager(dns.ALIASRecord, dns.ARecordType, dns.TXTRecordType)
+	mgr := dns.NewOwnershipManager(fake, owner)
+	for _, address := range []string{"192.0.2.1", "192.0.2.2"} {
+		if err := mgr.Ensure(aRecord(address)); err != nil {
+			t.Fatalf("failed to ensure record with address %s: %v", address, err)
+		}
+	}
+	ownership := dns.OwnershipRecord(aRecord(""), owner)
+
+	// Deleting one address while another is retained keeps the shared
+	// ownership record.
+	mgr = dns.NewOwnershipManager(fake, owner, aRecord("192.0.2.2"))
+	if err := mgr.Delete(aRecord("192.0.2.1")); err != nil {
+		t.Fatalf("failed to delete record: %v", err)
+	}
+	if current, _ := fake.Get(ownership); current == nil {
+		t.Errorf("expected the ownership record to be kept while an address remains")
+	}
+
+	// Deleting the last address deletes the ownership record.
+	mgr = dns.NewOwnershipManager(fake, owner)
+	if err := mgr.Delete(aRecord("192.0.2.2")); err != nil {
+		t.Fatalf("failed to delete record: %v", err)
+	}
+	if len(fake.Records) != 0 {
+		t.Errorf("expected no records, got %v", fake.Records)
+	}
+}
+
+func TestOwnershipManagerAdoption(t *testing.T) {
+	fake := dns.NewFakeManager(dns.ALIASRecord, dns.ARecordType, dns.TXTRecordType)
+	mgr := dns.NewOwnershipManager(fake, owner)
+
+	// A record without an ownership record and with a different target is
+	// not adopted.
+	fake.Ensure(aliasRecord("unowned.example.com"))
+	if err := mgr.Ensure(aliasRecord("lb.example.com")); !dns.IsOwnershipConflict(err) {
+		t.Errorf("expected ownership conflict, got %v", err)
+	}
+
+	// A record without an ownership record and with the desired target is
+	// adopted.
+	fake.Ensure(aliasRecord("lb.example.com"))
+	if err := mgr.Ensure(aliasRecord("lb.example.com")); err != nil {
+		t.Fatalf("failed to adopt record: %v", err)
+	}
+	if ownership, _ := fake.Get(dns.OwnershipRecord(aliasRecord("lb.example.com"), owner)); ownership == nil {
+		t.Errorf("expected adopted record to have an ownership record")
+	}
+}
+
+func TestOwnershipManagerWithoutTXTSupport(t *testing.T) {
+	fake := dns.NewFakeManager(dns.ALIASRecord, dns.ARecordType)
+	mgr := dns.NewOwnershipManager(fake, owner)
+
+	if err := mgr.Ensure(aliasRecord("lb.example.com")); err != nil {
+		t.Fatalf("failed to ensure record: %v", err)
+	}
+	if current, err := mgr.Get(aliasRecord("lb.example.com")); err != nil || current == nil {
+		t.Fatalf("expected record to be published, got %v, %v", current, err)
+	}
+	if err := mgr.Delete(aliasRecord("lb.example.com")); err != nil {
+		t.Fatalf("failed to delete record: %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package dns
+
+import (
+	"sync"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+var (
+	_ BatchManager       = &ReloadableManager{}
+	_ PropagationTracker = &ReloadableManager{}
+)
+
+// CredentialsValidator is implemented by a Manager that can check whether its
+// credentials are accepted by the DNS provider without changing any records.
+type CredentialsValidator interface {
+	// ValidateCredentials returns an error if the DNS provider rejects the
+	// manager's credentials.
+	ValidateCredentials() error
+}
+
+// ReloadableManager is a Manager that delegates to another manager, which can
+// be replaced while the operator runs, for example when the cloud credentials
+// are rotated. A batch uses the manager that was current when the batch was
+// created, so that every change in a batch goes to the same manager.
+type ReloadableManager struct {
+	// lock protects access to everything below.
+	lock sync.RWMutex
+
+	// manager is the manager to which calls are delegated.
+	manager Manager
+	// reloadErr is the error of the most recent reload, if it failed.
+	reloadErr error
+}
+
+// NewReloadableManager returns a ReloadableManager that delegates to manager.
+func NewReloadableManager(manager Manager) *ReloadableManager {
+	return &ReloadableManager{manager: manager}
+}
+
+// Reload replaces the manager to which m delegates and clears the error of any
+// previous reload.
+func (m *ReloadableManager) Reload(manager Manager) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.manager = manager
+	m.reloadErr = nil
+}
+
+// SetReloadError records that a reload failed. The manager to which m
+// delegates is kept.
+func (m *ReloadableManager) SetReloadError(err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.reloadErr = err
+}
+
+// ReloadError returns the error of the most recent reload, or nil if the most
+// recent reload succeeded.
+func (m *ReloadableManager) ReloadError() error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.reloadErr
+}
+
+// current returns the manager to which m delegates.
+func (m *ReloadableManager) current() Manager {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.manager
+}
+
+func (m *ReloadableManager) Ensure(record *Record) error {
+	return m.current().Ensure(record)
+}
+
+func (m *ReloadableManager) Delete(record *Record) error {
+	return m.current().Delete(record)
+}
+
+func (m *ReloadableManager) Get(record *Record) (*Record, error) {
+	return m.current().Get(record)
+}
+
+func (m *ReloadableManager) SupportsRecordType(zone configv1.DNSZone, recordType RecordType) bool {
+	return m.current().SupportsRecordType(zone, recordType)
+}
+
+// NewBatch returns a batch of the current manager if it supports batches, or
+// a batch that applies every change immediately otherwise.
+func (m *ReloadableManager) NewBatch() Batch {
+	current := m.current()
+	if batchManager, ok := current.(BatchManager); ok {
+		return batchManager.NewBatch()
+	}
+	return &ImmediateBatch{Manager: current}
+}
+
+// Pending returns whether the record has yet to propagate, if the current
+// manager tracks propagation, or false otherwise.
+func (m *ReloadableManager) Pending(record *Record) (bool, error) {
+	if tracker, ok := m.current().(PropagationTracker); ok {
+		return tracker.Pending(record)
+	}
+	return false, nil
+}
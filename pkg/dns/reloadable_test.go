@@ -0,0 +1,58 @@
+package dns_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+)
+
+func TestReloadableManager(t *testing.T) {
+	old := dns.NewFakeManager(dns.ALIASRecord, dns.ARecordType, dns.TXTRecordType)
+	m := dns.NewReloadableManager(old)
+
+	// A batch keeps using the manager that was current when it was
+	// created.
+	batch := m.NewBatch()
+
+	if err := m.Ensure(aliasRecord("old.example.com")); err != nil {
+		t.Fatalf("failed to ensure record: %v", err)
+	}
+	if len(old.Records) != 1 {
+		t.Errorf("expected the record to be ensured by the old manager, got %v", old.Records)
+	}
+
+	m.SetReloadError(errors.New("invalid credentials"))
+	if m.ReloadError() == nil {
+		t.Errorf("expected a reload error")
+	}
+
+	current := dns.NewFakeManager(dns.ALIASRecord, dns.ARecordType)
+	m.Reload(current)
+	if err := m.ReloadError(); err != nil {
+		t.Errorf("expected reload error to be cleared, got %v", err)
+	}
+	if err := m.Ensure(aliasRecord("new.example.com")); err != nil {
+		t.Fatalf("failed to ensure record: %v", err)
+	}
+	if len(current.Records) != 1 || len(old.Records) != 1 {
+		t.Errorf("expected the record to be ensured by the new manager, got %v and %v", current.Records, old.Records)
+	}
+	if m.SupportsRecordType(aliasRecord("").Zone, dns.TXTRecordType) {
+		t.Errorf("expected the new manager's record types")
+	}
+
+	if err := batch.Delete(aliasRecord("old.example.com")); err != nil {
+		t.Fatalf("failed to delete record: %v", err)
+	}
+	if errs := batch.Commit(); len(errs) != 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+	if len(old.Records) != 0 {
+		t.Errorf("expected the batch to delete the record with the old manager, got %v", old.Records)
+	}
+
+	if pending, err := m.Pending(aliasRecord("new.example.com")); err != nil || pending {
+		t.Errorf("expected a manager without propagation tracking not to report pending records, got %v, %v", pending, err)
+	}
+}
@@ -0,0 +1,103 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Resolver looks up names in DNS. *net.Resolver implements Resolver.
+type Resolver interface {
+	// LookupHost returns the addresses of host.
+	LookupHost(ctx context.Context, host string) ([]string, error)
+
+	// LookupCNAME returns the canonical name of host.
+	LookupCNAME(ctx context.Context, host string) (string, error)
+}
+
+var _ Resolver = &net.Resolver{}
+
+// NewResolver returns a resolver that sends its queries to nameserver, which is
+// a host with an optional port, or the system's resolver if nameserver is
+// empty.
+func NewResolver(nameserver string) Resolver {
+	if len(nameserver) == 0 {
+		return net.DefaultResolver
+	}
+	if _, _, err := net.SplitHostPort(nameserver); err != nil {
+		nameserver = net.JoinHostPort(nameserver, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, nameserver)
+		},
+	}
+}
+
+// ResolutionMismatchError is returned by VerifyResolution when a name resolves
+// but not to the expected target.
+type ResolutionMismatchError struct {
+	// Name is the name that was resolved.
+	Name string
+	// Target is the expected target.
+	Target string
+	// Addresses are the addresses to which the name resolved.
+	Addresses []string
+}
+
+func (e *ResolutionMismatchError) Error() string {
+	return fmt.Sprintf("%s resolves to %s, not to %s", e.Name, strings.Join(e.Addresses, ", "), e.Target)
+}
+
+// VerifyResolution returns nil if name resolves to target using resolver, or an
+// error explaining why it does not. Target is an IP address or a hostname; a
+// hostname matches if name is an alias for it or if name and target share an
+// address, as they do when name is an ALIAS record for target. If a lookup
+// fails, the resolver's error is returned as is, which for *net.Resolver is a
+// *net.DNSError.
+func VerifyResolution(ctx context.Context, resolver Resolver, name, target string) error {
+	addrs, err := resolver.LookupHost(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(target); ip != nil {
+		if containsIP(addrs, ip) {
+			return nil
+		}
+		return &ResolutionMismatchError{Name: name, Target: target, Addresses: addrs}
+	}
+
+	if cname, err := resolver.LookupCNAME(ctx, name); err == nil && sameName(cname, target) {
+		return nil
+	}
+	targetAddrs, err := resolver.LookupHost(ctx, target)
+	if err != nil {
+		return err
+	}
+	for _, addr := range targetAddrs {
+		if ip := net.ParseIP(addr); ip != nil && containsIP(addrs, ip) {
+			return nil
+		}
+	}
+	return &ResolutionMismatchError{Name: name, Target: target, Addresses: addrs}
+}
+
+// containsIP returns whether addrs contains ip.
+func containsIP(addrs []string, ip net.IP) bool {
+	for _, addr := range addrs {
+		if ip.Equal(net.ParseIP(addr)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameName returns whether a and b are the same domain name, ignoring case and
+// any trailing dot.
+func sameName(a, b string) bool {
+	return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+}
@@ -0,0 +1,145 @@
+package dns_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	miekgdns "github.com/miekg/dns"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+)
+
+// startNameserver starts a nameserver on a random local UDP port that answers
+// queries from records, which are in zone file format, and returns its address
+// and a function that stops it. Names under a wildcard are answered with the
+// wildcard's records.
+func startNameserver(t *testing.T, records ...string) (string, func()) {
+	rrs := []miekgdns.RR{}
+	for _, record := range records {
+		rr, err := miekgdns.NewRR(record)
+		if err != nil {
+			t.Fatalf("invalid record %q: %v", record, err)
+		}
+		rrs = append(rrs, rr)
+	}
+	handler := miekgdns.HandlerFunc(func(w miekgdns.ResponseWriter, req *miekgdns.Msg) {
+		resp := new(miekgdns.Msg)
+		resp.SetReply(req)
+		resp.Authoritative = true
+		for _, q := range req.Question {
+			resp.Answer = append(resp.Answer, answer(rrs, q.Name, q.Qtype)...)
+		}
+		if len(resp.Answer) == 0 {
+			resp.Rcode = miekgdns.RcodeNameError
+		}
+		w.WriteMsg(resp)
+	})
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	started := make(chan struct{})
+	server := &miekgdns.Server{
+		PacketConn:        pc,
+		Handler:           handler,
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go server.ActivateAndServe()
+	<-started
+	return pc.LocalAddr().String(), func() { server.Shutdown() }
+}
+
+// answer returns the records from rrs that answer a query for name and qtype,
+// following CNAME records as a recursive resolver would.
+func answer(rrs []miekgdns.RR, name string, qtype uint16) []miekgdns.RR {
+	answers := []miekgdns.RR{}
+	for _, rr := range rrs {
+		hdr := rr.Header()
+		if !matches(hdr.Name, name) || (hdr.Rrtype != qtype && hdr.Rrtype != miekgdns.TypeCNAME) {
+			continue
+		}
+		rr = miekgdns.Copy(rr)
+		rr.Header().Name = name
+		answers = append(answers, rr)
+		if cname, ok := rr.(*miekgdns.CNAME); ok && qtype != miekgdns.TypeCNAME {
+			answers = append(answers, answer(rrs, cname.Target, qtype)...)
+		}
+	}
+	return answers
+}
+
+// matches returns whether the owner name of a record, which may be a
+// wildcard, matches the queried name.
+func matches(owner, name string) bool {
+	owner, name = strings.ToLower(owner), strings.ToLower(name)
+	if strings.HasPrefix(owner, "*.") {
+		return strings.HasSuffix(name, owner[1:])
+	}
+	return owner == name
+}
+
+func TestVerifyResolution(t *testing.T) {
+	nameserver, stop := startNameserver(t,
+		"*.apps.example.com. 30 IN A 192.0.2.1",
+		"*.alias.example.com. 30 IN A 192.0.2.2",
+		"lb.example.net. 30 IN A 192.0.2.2",
+		"lb.example.net. 30 IN A 192.0.2.3",
+		"*.cname.example.com. 30 IN CNAME lb.example.org.",
+		"lb.example.org. 30 IN A 192.0.2.4",
+	)
+	defer stop()
+	resolver := dns.NewResolver(nameserver)
+
+	tests := []struct {
+		description string
+		name        string
+		target      string
+		expectErr   bool
+	}{
+		{
+			description: "A record for the target IP",
+			name:        "probe.apps.example.com",
+			target:      "192.0.2.1",
+		},
+		{
+			description: "A record for another IP",
+			name:        "probe.apps.example.com",
+			target:      "192.0.2.9",
+			expectErr:   true,
+		},
+		{
+			description: "alias sharing an address with the target hostname",
+			name:        "probe.alias.example.com",
+			target:      "lb.example.net",
+		},
+		{
+			description: "CNAME record for the target hostname",
+			name:        "probe.cname.example.com",
+			target:      "lb.example.org",
+		},
+		{
+			description: "CNAME record for another hostname",
+			name:        "probe.cname.example.com",
+			target:      "lb.example.net",
+			expectErr:   true,
+		},
+		{
+			description: "name that does not resolve",
+			name:        "probe.missing.example.com",
+			target:      "192.0.2.1",
+			expectErr:   true,
+		},
+	}
+	for _, tc := range tests {
+		err := dns.VerifyResolution(context.TODO(), resolver, tc.name, tc.target)
+		switch {
+		case tc.expectErr && err == nil:
+			t.Errorf("%q: expected an error", tc.description)
+		case !tc.expectErr && err != nil:
+			t.Errorf("%q: unexpected error: %v", tc.description, err)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package rfc2136
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	miekgdns "github.com/miekg/dns"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
+)
+
+var (
+	_   dns.Manager = &manager{}
+	log             = logf.Logger.WithName("dns")
+)
+
+const (
+	// defaultTTL is the TTL of records that are published when the config
+	// doesn't specify one.
+	defaultTTL uint32 = 30
+
+	// tsigFudge is the number of seconds of clock skew that is permitted
+	// between the operator and the nameserver when signing updates.
+	tsigFudge = 300
+)
+
+// Config is the necessary input to configure the manager for RFC 2136 dynamic
+// DNS updates.
+type Config struct {
+	// Nameserver is the host:port of the nameserver that accepts updates
+	// for the zones. If no port is given, port 53 is used.
+	Nameserver string
+	// Net is the transport used to talk to the nameserver, either "udp" or
+	// "tcp". If empty, "udp" is used.
+	Net string
+	// TSIGKeyName is the name of the key that is used to sign updates. If
+	// empty, updates are not signed.
+	TSIGKeyName string
+	// TSIGSecret is the base64 encoded secret of the TSIG key.
+	TSIGSecret string
+	// TSIGAlgorithm is the algorithm of the TSIG key, for example
+	// "hmac-sha256". If empty, "hmac-sha256" is used.
+	TSIGAlgorithm string
+	// TTL is the TTL of published records. If zero, a default is used.
+	TTL uint32
+	// DNS is public and private DNS zone configuration for the cluster. The
+	// ID of each zone is the name of the zone, for example "example.com".
+	DNS *configv1.DNS
+}
+
+// manager publishes records by sending DNS UPDATE messages as described in RFC
+// 2136 to a nameserver. A, AAAA, CNAME, and TXT records are published as they
+// are, and ALIAS records are published as CNAME records because standard DNS has
+// no ALIAS record type. A and AAAA records with the same name are published as
+// one record set with all of their addresses.
+type manager struct {
+	config Config
+	client *miekgdns.Client
+}
+
+func NewManager(config Config) (dns.Manager, error) {
+	if len(config.Nameserver) == 0 {
+		return nil, fmt.Errorf("nameserver is required")
+	}
+	config.Nameserver = nameserverAddress(config.Nameserver)
+	if config.TTL == 0 {
+		config.TTL = defaultTTL
+	}
+	client := &miekgdns.Client{Net: config.Net}
+	if len(config.TSIGKeyName) > 0 {
+		if len(config.TSIGSecret) == 0 {
+			return nil, fmt.Errorf("TSIG secret is required with TSIG key name %s", config.TSIGKeyName)
+		}
+		config.TSIGKeyName = miekgdns.Fqdn(strings.ToLower(config.TSIGKeyName))
+		config.TSIGAlgorithm = tsigAlgorithm(config.TSIGAlgorithm)
+		client.TsigSecret = map[string]string{config.TSIGKeyName: config.TSIGSecret}
+	}
+	return &manager{config: config, client: client}, nil
+}
+
+// nameserverAddress returns the host:port address of the given nameserver,
+// which is a host or an IPv4 or IPv6 address with or without a port. Port 53 is
+// used if the nameserver has no port.
+func nameserverAddress(nameserver string) string {
+	if _, _, err := net.SplitHostPort(nameserver); err == nil {
+		return nameserver
+	}
+	return net.JoinHostPort(strings.TrimSuffix(strings.TrimPrefix(nameserver, "["), "]"), "53")
+}
+
+// tsigAlgorithm returns the fully qualified name of the given TSIG algorithm.
+func tsigAlgorithm(algorithm string) string {
+	switch strings.TrimSuffix(strings.ToLower(algorithm), ".") {
+	case "", "hmac-sha256":
+		return miekgdns.HmacSHA256
+	case "hmac-sha1":
+		return miekgdns.HmacSHA1
+	case "hmac-sha512":
+		return miekgdns.HmacSHA512
+	case "hmac-md5", "hmac-md5.sig-alg.reg.int":
+		return miekgdns.HmacMD5
+	}
+	return miekgdns.Fqdn(algorithm)
+}
+
+func (m *manager) Ensure(record *dns.Record) error {
+	zone, rr, err := m.resourceRecord(record)
+	if err != nil {
+		return err
+	}
+
+	// Add an address to any existing record set of the same type and name,
+	// whose other addresses are removed by deleting their records, and
+	// replace the record set otherwise.
+	msg := new(miekgdns.Msg)
+	msg.SetUpdate(zone)
+	if record.Type != dns.ARecordType && record.Type != dns.AAAARecordType {
+		msg.RemoveRRset([]miekgdns.RR{rr})
+	}
+	msg.Insert([]miekgdns.RR{rr})
+	if err := m.exchange(msg); err != nil {
+		return fmt.Errorf("failed to update record %s in zone %s: %v", rr.Header().Name, zone, err)
+	}
+	log.Info("upserted DNS record", "record", record)
+	return nil
+}
+
+func (m *manager) Delete(record *dns.Record) error {
+	zone, rr, err := m.resourceRecord(record)
+	if err != nil {
+		return err
+	}
+
+	// Only the record with the given target is removed, so a record that
+	// someone else changed is left alone. Removing a record that doesn't
+	// exist is not an error.
+	msg := new(miekgdns.Msg)
+	msg.SetUpdate(zone)
+	msg.Remove([]miekgdns.RR{rr})
+	if err := m.exchange(msg); err != nil {
+		return fmt.Errorf("failed to delete record %s in zone %s: %v", rr.Header().Name, zone, err)
+	}
+	log.Info("deleted DNS record", "record", record)
+	return nil
+}
+
+func (m *manager) Get(record *dns.Record) (*dns.Record, error) {
+	zone, rr, err := m.resourceRecord(record)
+	if err != nil {
+		return nil, err
+	}
+
+	name, rrtype := rr.Header().Name, rr.Header().Rrtype
+	msg := new(miekgdns.Msg)
+	msg.SetQuestion(name, rrtype)
+	msg.RecursionDesired = false
+	resp, _, err := m.client.Exchange(msg, m.config.Nameserver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query record %s in zone %s: %v", name, zone, err)
+	}
+	if resp.Rcode != miekgdns.RcodeSuccess && resp.Rcode != miekgdns.RcodeNameError {
+		return nil, fmt.Errorf("failed to query record %s in zone %s: %s", name, zone, miekgdns.RcodeToString[resp.Rcode])
+	}
+
+	// A record set with several addresses matches each of them, so prefer
+	// the answer with the record's own address.
+	answers := append([]miekgdns.RR{}, resp.Answer...)
+	sort.SliceStable(answers, func(i, j int) bool {
+		return sameAddress(answers[i], rr) && !sameAddress(answers[j], rr)
+	})
+	for _, answer := range answers {
+		if !strings.EqualFold(answer.Header().Name, name) {
+			continue
+		}
+		switch a := answer.(type) {
+		case *miekgdns.A:
+			if record.Type == dns.ARecordType {
+				return &dns.Record{
+					Zone:    record.Zone,
+					Type:    dns.ARecordType,
+					ARecord: &dns.ARecord{Domain: record.ARecord.Domain, Address: a.A.String()},
+				}, nil
+			}
+		case *miekgdns.AAAA:
+			if record.Type == dns.AAAARecordType {
+				return &dns.Record{
+					Zone:       record.Zone,
+					Type:       dns.AAAARecordType,
+					AAAARecord: &dns.AAAARecord{Domain: record.AAAARecord.Domain, Address: a.AAAA.String()},
+				}, nil
+			}
+		case *miekgdns.CNAME:
+			if record.Type == dns.ALIASRecord {
+				return &dns.Record{
+					Zone:  record.Zone,
+					Type:  dns.ALIASRecord,
+					Alias: &dns.AliasRecord{Domain: record.Alias.Domain, Target: strings.TrimSuffix(a.Target, ".")},
+				}, nil
+			}
+			if record.Type == dns.CNAMERecordType {
+				return &dns.Record{
+					Zone:        record.Zone,
+					Type:        dns.CNAMERecordType,
+					CNAMERecord: &dns.CNAMERecord{Domain: record.CNAMERecord.Domain, Target: strings.TrimSuffix(a.Target, ".")},
+				}, nil
+			}
+		case *miekgdns.TXT:
+			if record.Type == dns.TXTRecordType {
+				return &dns.Record{
+					Zone:      record.Zone,
+					Type:      dns.TXTRecordType,
+					TXTRecord: &dns.TXTRecord{Domain: record.TXTRecord.Domain, Text: strings.Join(a.Txt, "")},
+				}, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// sameAddress returns true if a and b are A or AAAA records with the same
+// address.
+func sameAddress(a, b miekgdns.RR) bool {
+	switch a := a.(type) {
+	case *miekgdns.A:
+		b, ok := b.(*miekgdns.A)
+		return ok && a.A.Equal(b.A)
+	case *miekgdns.AAAA:
+		b, ok := b.(*miekgdns.AAAA)
+		return ok && a.AAAA.Equal(b.AAAA)
+	}
+	return false
+}
+
+// SupportsRecordType returns true for the record types that resourceRecord
+// supports.
+func (m *manager) SupportsRecordType(zone configv1.DNSZone, recordType dns.RecordType) bool {
+	switch recordType {
+	case dns.ARecordType, dns.AAAARecordType, dns.ALIASRecord, dns.CNAMERecordType, dns.TXTRecordType:
+		return true
+	}
+	return false
+}
+
+// resourceRecord returns the name of the zone of the given record and the
+// resource record that represents it.
+func (m *manager) resourceRecord(record *dns.Record) (string, miekgdns.RR, error) {
+	if len(record.Zone.ID) == 0 {
+		return "", nil, fmt.Errorf("zone ID is required and must be the name of the zone")
+	}
+	zone := miekgdns.Fqdn(record.Zone.ID)
+
+	hdr := miekgdns.RR_Header{
+		Class: miekgdns.ClassINET,
+		Ttl:   m.config.TTL,
+	}
+	var domain string
+	var rr miekgdns.RR
+	switch record.Type {
+	case dns.ARecordType:
+		if record.ARecord == nil {
+			return "", nil, fmt.Errorf("missing A record")
+		}
+		address := net.ParseIP(record.ARecord.Address).To4()
+		if address == nil {
+			return "", nil, fmt.Errorf("invalid IPv4 address %q", record.ARecord.Address)
+		}
+		domain = record.ARecord.Domain
+		hdr.Rrtype = miekgdns.TypeA
+		rr = &miekgdns.A{Hdr: hdr, A: address}
+	case dns.AAAARecordType:
+		if record.AAAARecord == nil {
+			return "", nil, fmt.Errorf("missing AAAA record")
+		}
+		address := net.ParseIP(record.AAAARecord.Address)
+		if address == nil || address.To4() != nil {
+			return "", nil, fmt.Errorf("invalid IPv6 address %q", record.AAAARecord.Address)
+		}
+		domain = record.AAAARecord.Domain
+		hdr.Rrtype = miekgdns.TypeAAAA
+		rr = &miekgdns.AAAA{Hdr: hdr, AAAA: address}
+	case dns.ALIASRecord:
+		if record.Alias == nil {
+			return "", nil, fmt.Errorf("missing alias record")
+		}
+		if len(record.Alias.Target) == 0 {
+			return "", nil, fmt.Errorf("target is required")
+		}
+		domain = record.Alias.Domain
+		hdr.Rrtype = miekgdns.TypeCNAME
+		rr = &miekgdns.CNAME{Hdr: hdr, Target: miekgdns.Fqdn(record.Alias.Target)}
+	case dns.CNAMERecordType:
+		if record.CNAMERecord == nil {
+			return "", nil, fmt.Errorf("missing CNAME record")
+		}
+		if len(record.CNAMERecord.Target) == 0 {
+			return "", nil, fmt.Errorf("target is required")
+		}
+		domain = record.CNAMERecord.Domain
+		hdr.Rrtype = miekgdns.TypeCNAME
+		rr = &miekgdns.CNAME{Hdr: hdr, Target: miekgdns.Fqdn(record.CNAMERecord.Target)}
+	case dns.TXTRecordType:
+		if record.TXTRecord == nil {
+			return "", nil, fmt.Errorf("missing TXT record")
+		}
+		domain = record.TXTRecord.Domain
+		hdr.Rrtype = miekgdns.TypeTXT
+		rr = &miekgdns.TXT{Hdr: hdr, Txt: []string{record.TXTRecord.Text}}
+	default:
+		return "", nil, &dns.UnsupportedRecordTypeError{Type: record.Type}
+	}
+	if len(domain) == 0 {
+		return "", nil, fmt.Errorf("domain is required")
+	}
+
+	name := miekgdns.Fqdn(domain)
+	if !miekgdns.IsSubDomain(zone, name) {
+		return "", nil, fmt.Errorf("domain %s is not in zone %s", domain, zone)
+	}
+	rr.Header().Name = name
+	return zone, rr, nil
+}
+
+// exchange signs msg if the manager is configured with a TSIG key, sends it
+// to the nameserver, and returns an error unless the update succeeded.
+func (m *manager) exchange(msg *miekgdns.Msg) error {
+	if len(m.config.TSIGKeyName) > 0 {
+		msg.SetTsig(m.config.TSIGKeyName, m.config.TSIGAlgorithm, tsigFudge, time.Now().Unix())
+	}
+	resp, _, err := m.client.Exchange(msg, m.config.Nameserver)
+	if err != nil {
+		return err
+	}
+	if resp.Rcode != miekgdns.RcodeSuccess {
+		return fmt.Errorf("nameserver responded with %s", miekgdns.RcodeToString[resp.Rcode])
+	}
+	return nil
+}
@@ -0,0 +1,418 @@
+package rfc2136
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	miekgdns "github.com/miekg/dns"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+)
+
+const (
+	tsigKeyName = "ingress-operator."
+	tsigSecret  = "c2VjcmV0LWZvci10ZXN0aW5nLW9ubHk="
+)
+
+// fakeNameserver is an in-process authoritative nameserver that applies DNS
+// UPDATE messages to an in-memory set of records.
+type fakeNameserver struct {
+	requireTSIG bool
+
+	lock    sync.Mutex
+	records map[string][]miekgdns.RR
+}
+
+func (s *fakeNameserver) ServeDNS(w miekgdns.ResponseWriter, req *miekgdns.Msg) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	resp := new(miekgdns.Msg)
+	resp.SetReply(req)
+	if req.Opcode == miekgdns.OpcodeUpdate {
+		if s.requireTSIG && (req.IsTsig() == nil || w.TsigStatus() != nil) {
+			resp.Rcode = miekgdns.RcodeRefused
+		} else {
+			s.update(req.Ns)
+		}
+	} else {
+		for _, q := range req.Question {
+			resp.Answer = append(resp.Answer, s.records[key(q.Name, q.Qtype)]...)
+		}
+	}
+	if t := req.IsTsig(); t != nil && w.TsigStatus() == nil {
+		resp.SetTsig(t.Hdr.Name, t.Algorithm, 300, int64(t.TimeSigned))
+	}
+	w.WriteMsg(resp)
+}
+
+func (s *fakeNameserver) update(rrs []miekgdns.RR) {
+	for _, rr := range rrs {
+		hdr := rr.Header()
+		k := key(hdr.Name, hdr.Rrtype)
+		switch hdr.Class {
+		case miekgdns.ClassANY:
+			delete(s.records, k)
+		case miekgdns.ClassNONE:
+			kept := []miekgdns.RR{}
+			for _, existing := range s.records[k] {
+				if !sameData(existing, rr) {
+					kept = append(kept, existing)
+				}
+			}
+			s.records[k] = kept
+		default:
+			// A duplicate of an existing record is ignored.
+			duplicate := false
+			for _, existing := range s.records[k] {
+				duplicate = duplicate || sameData(existing, rr)
+			}
+			if !duplicate {
+				s.records[k] = append(s.records[k], rr)
+			}
+		}
+	}
+}
+
+func (s *fakeNameserver) get(name string, rrtype uint16) []miekgdns.RR {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.records[key(name, rrtype)]
+}
+
+func key(name string, rrtype uint16) string {
+	return strings.ToLower(name) + "/" + miekgdns.TypeToString[rrtype]
+}
+
+func sameData(a, b miekgdns.RR) bool {
+	switch a := a.(type) {
+	case *miekgdns.A:
+		b, ok := b.(*miekgdns.A)
+		return ok && a.A.Equal(b.A)
+	case *miekgdns.AAAA:
+		b, ok := b.(*miekgdns.AAAA)
+		return ok && a.AAAA.Equal(b.AAAA)
+	case *miekgdns.CNAME:
+		b, ok := b.(*miekgdns.CNAME)
+		return ok && strings.EqualFold(a.Target, b.Target)
+	case *miekgdns.TXT:
+		b, ok := b.(*miekgdns.TXT)
+		return ok && strings.Join(a.Txt, "") == strings.Join(b.Txt, "")
+	}
+	return false
+}
+
+// startNameserver starts a fake nameserver on a random local UDP port and
+// returns it along with its address and a function that stops it.
+func startNameserver(t *testing.T, requireTSIG bool) (*fakeNameserver, string, func()) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	ns := &fakeNameserver{requireTSIG: requireTSIG, records: map[string][]miekgdns.RR{}}
+	started := make(chan struct{})
+	server := &miekgdns.Server{
+		PacketConn:        pc,
+		Handler:           ns,
+		TsigSecret:        map[string]string{tsigKeyName: tsigSecret},
+		NotifyStartedFunc: func() { close(started) },
+		// The default accept function rejects updates.
+		MsgAcceptFunc: func(miekgdns.Header) miekgdns.MsgAcceptAction { return miekgdns.MsgAccept },
+	}
+	go server.ActivateAndServe()
+	<-started
+	return ns, pc.LocalAddr().String(), func() { server.Shutdown() }
+}
+
+var zone = configv1.DNSZone{ID: "example.com"}
+
+func aRecord(address string) *dns.Record {
+	return &dns.Record{
+		Zone: zone,
+		Type: dns.ARecordType,
+		ARecord: &dns.ARecord{
+			Domain:  "*.apps.example.com",
+			Address: address,
+		},
+	}
+}
+
+func aliasRecord(target string) *dns.Record {
+	return &dns.Record{
+		Zone: zone,
+		Type: dns.ALIASRecord,
+		Alias: &dns.AliasRecord{
+			Domain: "*.apps.example.com",
+			Target: target,
+		},
+	}
+}
+
+func TestEnsureAndDeleteARecord(t *testing.T) {
+	ns, addr, stop := startNameserver(t, false)
+	defer stop()
+	mgr, err := NewManager(Config{Nameserver: addr})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	if err := mgr.Ensure(aRecord("192.0.2.1")); err != nil {
+		t.Fatalf("failed to ensure record: %v", err)
+	}
+	// Ensuring a record with a new address adds the address to the record
+	// set, and ensuring it again doesn't duplicate it.
+	for _, address := range []string{"192.0.2.2", "192.0.2.2"} {
+		if err := mgr.Ensure(aRecord(address)); err != nil {
+			t.Fatalf("failed to ensure record: %v", err)
+		}
+	}
+	rrs := ns.get("*.apps.example.com.", miekgdns.TypeA)
+	if len(rrs) != 2 || rrs[0].(*miekgdns.A).A.String() != "192.0.2.1" || rrs[1].(*miekgdns.A).A.String() != "192.0.2.2" {
+		t.Fatalf("expected A records with addresses 192.0.2.1 and 192.0.2.2, got %v", rrs)
+	}
+
+	for _, address := range []string{"192.0.2.1", "192.0.2.2"} {
+		current, err := mgr.Get(aRecord(address))
+		if err != nil {
+			t.Fatalf("failed to get record: %v", err)
+		}
+		if current == nil || current.ARecord.Address != address {
+			t.Fatalf("expected current record with address %s, got %v", address, current)
+		}
+	}
+
+	// Deleting a record removes only its address from the record set.
+	if err := mgr.Delete(aRecord("192.0.2.1")); err != nil {
+		t.Fatalf("failed to delete record: %v", err)
+	}
+	if rrs := ns.get("*.apps.example.com.", miekgdns.TypeA); len(rrs) != 1 || rrs[0].(*miekgdns.A).A.String() != "192.0.2.2" {
+		t.Fatalf("expected the record with address 192.0.2.2 to remain, got %v", rrs)
+	}
+	if err := mgr.Delete(aRecord("192.0.2.2")); err != nil {
+		t.Fatalf("failed to delete record: %v", err)
+	}
+	if rrs := ns.get("*.apps.example.com.", miekgdns.TypeA); len(rrs) != 0 {
+		t.Fatalf("expected record to be deleted, got %v", rrs)
+	}
+
+	current, err := mgr.Get(aRecord("192.0.2.2"))
+	if err != nil {
+		t.Fatalf("failed to get record: %v", err)
+	}
+	if current != nil {
+		t.Fatalf("expected no current record, got %v", current)
+	}
+}
+
+func TestEnsureAliasRecordAsCNAME(t *testing.T) {
+	ns, addr, stop := startNameserver(t, false)
+	defer stop()
+	mgr, err := NewManager(Config{Nameserver: addr})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	if err := mgr.Ensure(aliasRecord("lb.example.net")); err != nil {
+		t.Fatalf("failed to ensure record: %v", err)
+	}
+	rrs := ns.get("*.apps.example.com.", miekgdns.TypeCNAME)
+	if len(rrs) != 1 || rrs[0].(*miekgdns.CNAME).Target != "lb.example.net." {
+		t.Fatalf("expected a single CNAME record with target lb.example.net., got %v", rrs)
+	}
+
+	current, err := mgr.Get(aliasRecord("lb.example.net"))
+	if err != nil {
+		t.Fatalf("failed to get record: %v", err)
+	}
+	if current == nil || current.Alias.Target != "lb.example.net" {
+		t.Fatalf("expected current record with target lb.example.net, got %v", current)
+	}
+}
+
+func TestEnsureCNAMERecord(t *testing.T) {
+	ns, addr, stop := startNameserver(t, false)
+	defer stop()
+	mgr, err := NewManager(Config{Nameserver: addr})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	record := &dns.Record{
+		Zone: zone,
+		Type: dns.CNAMERecordType,
+		CNAMERecord: &dns.CNAMERecord{
+			Domain: "*.apps.example.com",
+			Target: "lb.example.net",
+		},
+	}
+	if err := mgr.Ensure(record); err != nil {
+		t.Fatalf("failed to ensure record: %v", err)
+	}
+	rrs := ns.get("*.apps.example.com.", miekgdns.TypeCNAME)
+	if len(rrs) != 1 || rrs[0].(*miekgdns.CNAME).Target != "lb.example.net." {
+		t.Fatalf("expected a single CNAME record with target lb.example.net., got %v", rrs)
+	}
+
+	current, err := mgr.Get(record)
+	if err != nil {
+		t.Fatalf("failed to get record: %v", err)
+	}
+	if current == nil || current.CNAMERecord == nil || current.CNAMERecord.Target != "lb.example.net" {
+		t.Fatalf("expected current record with target lb.example.net, got %v", current)
+	}
+}
+
+func TestEnsureAndDeleteTXTRecord(t *testing.T) {
+	ns, addr, stop := startNameserver(t, false)
+	defer stop()
+	mgr, err := NewManager(Config{Nameserver: addr})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	record := &dns.Record{
+		Zone: zone,
+		Type: dns.TXTRecordType,
+		TXTRecord: &dns.TXTRecord{
+			Domain: "_owner.apps.example.com",
+			Text:   "heritage=openshift-ingress-operator",
+		},
+	}
+	if err := mgr.Ensure(record); err != nil {
+		t.Fatalf("failed to ensure record: %v", err)
+	}
+	if rrs := ns.get("_owner.apps.example.com.", miekgdns.TypeTXT); len(rrs) != 1 {
+		t.Fatalf("expected a single TXT record, got %v", rrs)
+	}
+
+	current, err := mgr.Get(record)
+	if err != nil {
+		t.Fatalf("failed to get record: %v", err)
+	}
+	if current == nil || current.TXTRecord.Text != record.TXTRecord.Text {
+		t.Fatalf("expected current record with text %q, got %v", record.TXTRecord.Text, current)
+	}
+
+	if err := mgr.Delete(record); err != nil {
+		t.Fatalf("failed to delete record: %v", err)
+	}
+	if rrs := ns.get("_owner.apps.example.com.", miekgdns.TypeTXT); len(rrs) != 0 {
+		t.Fatalf("expected TXT record to be deleted, got %v", rrs)
+	}
+}
+
+func TestEnsureAndDeleteAAAARecord(t *testing.T) {
+	ns, addr, stop := startNameserver(t, false)
+	defer stop()
+	mgr, err := NewManager(Config{Nameserver: addr})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	record := &dns.Record{
+		Zone: zone,
+		Type: dns.AAAARecordType,
+		AAAARecord: &dns.AAAARecord{
+			Domain:  "*.apps.example.com",
+			Address: "2001:db8::1",
+		},
+	}
+	if err := mgr.Ensure(record); err != nil {
+		t.Fatalf("failed to ensure record: %v", err)
+	}
+	if rrs := ns.get("*.apps.example.com.", miekgdns.TypeAAAA); len(rrs) != 1 {
+		t.Fatalf("expected a single AAAA record, got %v", rrs)
+	}
+
+	current, err := mgr.Get(record)
+	if err != nil {
+		t.Fatalf("failed to get record: %v", err)
+	}
+	if current == nil || current.AAAARecord.Address != record.AAAARecord.Address {
+		t.Fatalf("expected current record with address %s, got %v", record.AAAARecord.Address, current)
+	}
+
+	if err := mgr.Delete(record); err != nil {
+		t.Fatalf("failed to delete record: %v", err)
+	}
+	if rrs := ns.get("*.apps.example.com.", miekgdns.TypeAAAA); len(rrs) != 0 {
+		t.Fatalf("expected AAAA record to be deleted, got %v", rrs)
+	}
+}
+
+func TestEnsureWithTSIG(t *testing.T) {
+	ns, addr, stop := startNameserver(t, true)
+	defer stop()
+
+	unsigned, err := NewManager(Config{Nameserver: addr})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	if err := unsigned.Ensure(aRecord("192.0.2.1")); err == nil {
+		t.Fatalf("expected unsigned update to be refused")
+	}
+
+	signed, err := NewManager(Config{
+		Nameserver:    addr,
+		TSIGKeyName:   "ingress-operator",
+		TSIGSecret:    tsigSecret,
+		TSIGAlgorithm: "hmac-sha256",
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	if err := signed.Ensure(aRecord("192.0.2.1")); err != nil {
+		t.Fatalf("failed to ensure record with TSIG: %v", err)
+	}
+	if rrs := ns.get("*.apps.example.com.", miekgdns.TypeA); len(rrs) != 1 {
+		t.Fatalf("expected record to be published, got %v", rrs)
+	}
+}
+
+func TestEnsureInvalidRecords(t *testing.T) {
+	mgr, err := NewManager(Config{Nameserver: "127.0.0.1:1"})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	outsideZone := aRecord("192.0.2.1")
+	outsideZone.ARecord.Domain = "*.apps.example.org"
+	tests := []struct {
+		name   string
+		record *dns.Record
+	}{
+		{"invalid address", aRecord("not-an-ip")},
+		{"IPv6 address in A record", aRecord("2001:db8::1")},
+		{"IPv4 address in AAAA record", &dns.Record{Zone: zone, Type: dns.AAAARecordType, AAAARecord: &dns.AAAARecord{Domain: "*.apps.example.com", Address: "192.0.2.1"}}},
+		{"domain outside of zone", outsideZone},
+		{"unsupported type", &dns.Record{Zone: zone, Type: dns.RecordType("MX")}},
+	}
+	for _, test := range tests {
+		if err := mgr.Ensure(test.record); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
+
+func TestNameserverAddress(t *testing.T) {
+	tests := []struct {
+		nameserver string
+		expect     string
+	}{
+		{"ns.example.com", "ns.example.com:53"},
+		{"ns.example.com:5353", "ns.example.com:5353"},
+		{"192.0.2.53", "192.0.2.53:53"},
+		{"192.0.2.53:5353", "192.0.2.53:5353"},
+		{"2001:db8::53", "[2001:db8::53]:53"},
+		{"[2001:db8::53]", "[2001:db8::53]:53"},
+		{"[2001:db8::53]:5353", "[2001:db8::53]:5353"},
+	}
+	for _, test := range tests {
+		if actual := nameserverAddress(test.nameserver); actual != test.expect {
+			t.Errorf("%s: expected %s, got %s", test.nameserver, test.expect, actual)
+		}
+	}
+}
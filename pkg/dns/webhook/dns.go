@@ -0,0 +1,333 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+var (
+	_   dns.Manager = &manager{}
+	log             = logf.Logger.WithName("dns")
+)
+
+const (
+	// defaultTimeout is the timeout of a single request to the endpoint.
+	defaultTimeout = 10 * time.Second
+
+	// defaultRetryTimeout is how long a request to the endpoint may take,
+	// including its retries, when the config doesn't specify a retry
+	// timeout. It is well under the DNS controller's resync period so that
+	// an unreachable endpoint doesn't hold up the controller; a request
+	// that fails is tried again when the DNSRecord is next reconciled.
+	defaultRetryTimeout = 15 * time.Second
+
+	// maxResponseBytes is the maximum size of a response body that is read.
+	maxResponseBytes = 1 << 20
+)
+
+// defaultBackoff is how requests are retried when the config doesn't specify
+// a backoff.
+var defaultBackoff = wait.Backoff{
+	Duration: 1 * time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    4,
+}
+
+// defaultRecordTypes are the record types that the endpoint is assumed to
+// support when the config doesn't specify any.
+var defaultRecordTypes = []dns.RecordType{
+	dns.ARecordType,
+	dns.AAAARecordType,
+	dns.ALIASRecord,
+	dns.CNAMERecordType,
+	dns.TXTRecordType,
+}
+
+// Config is the necessary input to configure the manager for a webhook.
+type Config struct {
+	// URL is the HTTPS endpoint to which operations are posted.
+	URL string
+	// BearerToken, if not empty, is sent in the Authorization header of
+	// every request.
+	BearerToken string
+	// ClientCertificate and ClientKey, if not empty, are a PEM encoded
+	// certificate and key that are used for mutual TLS authentication.
+	ClientCertificate []byte
+	ClientKey         []byte
+	// CABundle, if not empty, is a PEM encoded set of certificates that
+	// are trusted to verify the endpoint's certificate instead of the
+	// system trust store.
+	CABundle []byte
+	// Backoff is how requests are retried. If its Steps is zero, a
+	// default is used.
+	Backoff wait.Backoff
+	// RetryTimeout is how long a request may take, including its retries.
+	// No retry is started that would end after the timeout. If zero, a
+	// default is used.
+	RetryTimeout time.Duration
+	// RecordTypes are the record types that the endpoint supports. If it is
+	// empty, every record type is assumed to be supported.
+	RecordTypes []dns.RecordType
+	// DNS is public and private DNS zone configuration for the cluster.
+	DNS *configv1.DNS
+}
+
+// manager publishes records by posting them to a webhook endpoint that
+// implements the schema in types.go.
+type manager struct {
+	config     Config
+	httpClient *http.Client
+	userAgent  string
+}
+
+func NewManager(config Config, operatorReleaseVersion string) (dns.Manager, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook URL %q: %v", config.URL, err)
+	}
+	if u.Scheme != "https" {
+		return nil, fmt.Errorf("webhook URL %q must use https", config.URL)
+	}
+	if config.Backoff.Steps == 0 {
+		config.Backoff = defaultBackoff
+	}
+	if config.RetryTimeout == 0 {
+		config.RetryTimeout = defaultRetryTimeout
+	}
+	if len(config.RecordTypes) == 0 {
+		config.RecordTypes = defaultRecordTypes
+	}
+
+	tlsConfig := &tls.Config{}
+	if len(config.ClientCertificate) > 0 || len(config.ClientKey) > 0 {
+		cert, err := tls.X509KeyPair(config.ClientCertificate, config.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if len(config.CABundle) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(config.CABundle) {
+			return nil, fmt.Errorf("invalid CA bundle: no certificates found")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &manager{
+		config: config,
+		httpClient: &http.Client{
+			Timeout: defaultTimeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
+			},
+		},
+		userAgent: fmt.Sprintf("%s/%s", "openshift.io ingress-operator", operatorReleaseVersion),
+	}, nil
+}
+
+func (m *manager) Ensure(record *dns.Record) error {
+	if _, err := m.post(EnsureAction, record); err != nil {
+		return err
+	}
+	log.Info("upserted DNS record", "record", record)
+	return nil
+}
+
+func (m *manager) Delete(record *dns.Record) error {
+	if _, err := m.post(DeleteAction, record); err != nil {
+		return err
+	}
+	log.Info("deleted DNS record", "record", record)
+	return nil
+}
+
+func (m *manager) Get(record *dns.Record) (*dns.Record, error) {
+	resp, err := m.post(GetAction, record)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Record == nil {
+		return nil, nil
+	}
+	return recordFromWebhook(record, resp.Record)
+}
+
+func (m *manager) SupportsRecordType(zone configv1.DNSZone, recordType dns.RecordType) bool {
+	for _, t := range m.config.RecordTypes {
+		if t == recordType {
+			return true
+		}
+	}
+	return false
+}
+
+// post sends a request with the given action and record to the endpoint,
+// retrying with backoff until the retry timeout, and returns the decoded
+// response, if any.
+func (m *manager) post(action Action, record *dns.Record) (*Response, error) {
+	rec, err := recordToWebhook(record)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(Request{APIVersion: APIVersion, Action: action, Record: *rec})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.config.RetryTimeout)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+	backoff := m.config.Backoff
+	var resp *Response
+	for {
+		var retry bool
+		resp, retry, err = m.do(ctx, action, body)
+		if err == nil || !retry || backoff.Steps <= 1 {
+			break
+		}
+		delay := backoff.Step()
+		if time.Now().Add(delay).After(deadline) {
+			break
+		}
+		log.Info("retrying webhook request", "action", action, "record", record, "error", err)
+		time.Sleep(delay)
+	}
+	if dns.IsUnsupportedRecordType(err) {
+		return nil, &dns.UnsupportedRecordTypeError{Type: record.Type}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("webhook %s of record %v failed: %v", action, record, err)
+	}
+	return resp, nil
+}
+
+// do sends a single request. It returns the decoded response, if any, and
+// whether the request should be retried if it failed.
+func (m *manager) do(ctx context.Context, action Action, body []byte) (*Response, bool, error) {
+	req, err := http.NewRequest(http.MethodPost, m.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", m.userAgent)
+	if len(m.config.BearerToken) > 0 {
+		req.Header.Set("Authorization", "Bearer "+m.config.BearerToken)
+	}
+
+	httpResp, err := m.httpClient.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	defer httpResp.Body.Close()
+	data, err := ioutil.ReadAll(http.MaxBytesReader(nil, httpResp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	var resp *Response
+	if len(bytes.TrimSpace(data)) > 0 {
+		resp = &Response{}
+		if err := json.Unmarshal(data, resp); err != nil {
+			resp = nil
+		}
+	}
+
+	switch {
+	case httpResp.StatusCode == http.StatusNotFound && action == GetAction:
+		return nil, false, nil
+	case httpResp.StatusCode >= 200 && httpResp.StatusCode < 300:
+		if action == GetAction && len(bytes.TrimSpace(data)) > 0 && resp == nil {
+			return nil, false, fmt.Errorf("invalid response: %s", string(data))
+		}
+		return resp, false, nil
+	}
+
+	retry := httpResp.StatusCode == http.StatusTooManyRequests || httpResp.StatusCode >= 500
+	if resp != nil && resp.Error != nil {
+		if resp.Error.Reason == UnsupportedRecordTypeReason {
+			return nil, false, &dns.UnsupportedRecordTypeError{}
+		}
+		return nil, retry, fmt.Errorf("%s: %s: %s", httpResp.Status, resp.Error.Reason, resp.Error.Message)
+	}
+	return nil, retry, fmt.Errorf("%s", httpResp.Status)
+}
+
+// recordToWebhook returns the webhook representation of the given record.
+func recordToWebhook(record *dns.Record) (*Record, error) {
+	rec := &Record{
+		Zone: Zone{ID: record.Zone.ID, Tags: record.Zone.Tags},
+		Type: string(record.Type),
+	}
+	switch record.Type {
+	case dns.ALIASRecord:
+		if record.Alias == nil {
+			return nil, fmt.Errorf("missing alias record")
+		}
+		rec.Domain, rec.Target = record.Alias.Domain, record.Alias.Target
+	case dns.ARecordType:
+		if record.ARecord == nil {
+			return nil, fmt.Errorf("missing A record")
+		}
+		rec.Domain, rec.Target = record.ARecord.Domain, record.ARecord.Address
+	case dns.AAAARecordType:
+		if record.AAAARecord == nil {
+			return nil, fmt.Errorf("missing AAAA record")
+		}
+		rec.Domain, rec.Target = record.AAAARecord.Domain, record.AAAARecord.Address
+	case dns.CNAMERecordType:
+		if record.CNAMERecord == nil {
+			return nil, fmt.Errorf("missing CNAME record")
+		}
+		rec.Domain, rec.Target = record.CNAMERecord.Domain, record.CNAMERecord.Target
+	case dns.TXTRecordType:
+		if record.TXTRecord == nil {
+			return nil, fmt.Errorf("missing TXT record")
+		}
+		rec.Domain, rec.Target = record.TXTRecord.Domain, record.TXTRecord.Text
+	default:
+		return nil, &dns.UnsupportedRecordTypeError{Type: record.Type}
+	}
+	return rec, nil
+}
+
+// recordFromWebhook returns the record that the endpoint reported as current
+// for the given desired record.
+func recordFromWebhook(desired *dns.Record, rec *Record) (*dns.Record, error) {
+	current := &dns.Record{
+		Zone: desired.Zone,
+		Type: dns.RecordType(rec.Type),
+	}
+	switch current.Type {
+	case dns.ALIASRecord:
+		current.Alias = &dns.AliasRecord{Domain: rec.Domain, Target: rec.Target}
+	case dns.ARecordType:
+		current.ARecord = &dns.ARecord{Domain: rec.Domain, Address: rec.Target}
+	case dns.AAAARecordType:
+		current.AAAARecord = &dns.AAAARecord{Domain: rec.Domain, Address: rec.Target}
+	case dns.CNAMERecordType:
+		current.CNAMERecord = &dns.CNAMERecord{Domain: rec.Domain, Target: rec.Target}
+	case dns.TXTRecordType:
+		current.TXTRecord = &dns.TXTRecord{Domain: rec.Domain, Text: rec.Target}
+	default:
+		return nil, fmt.Errorf("webhook returned record with unsupported type %q", rec.Type)
+	}
+	return current, nil
+}
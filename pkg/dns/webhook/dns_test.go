@@ -0,0 +1,370 @@
+package webhook_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns/webhook"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+var testBackoff = wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 3}
+
+func aliasRecord() *dns.Record {
+	return &dns.Record{
+		Zone: configv1.DNSZone{Tags: map[string]string{"Name": "public"}},
+		Type: dns.ALIASRecord,
+		Alias: &dns.AliasRecord{
+			Domain: "*.apps.example.com",
+			Target: "lb.example.com",
+		},
+	}
+}
+
+// fakeEndpoint records the requests it receives and responds with the given
+// statuses in order, followed by 200.
+type fakeEndpoint struct {
+	lock     sync.Mutex
+	statuses []int
+	body     string
+	requests []webhook.Request
+	headers  []http.Header
+}
+
+func (e *fakeEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	var req webhook.Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	e.requests = append(e.requests, req)
+	e.headers = append(e.headers, r.Header)
+
+	status := http.StatusOK
+	if len(e.statuses) > 0 {
+		status, e.statuses = e.statuses[0], e.statuses[1:]
+	}
+	w.WriteHeader(status)
+	w.Write([]byte(e.body))
+}
+
+func caBundle(server *httptest.Server) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+}
+
+func newManager(t *testing.T, server *httptest.Server, config webhook.Config) dns.Manager {
+	config.URL = server.URL
+	config.CABundle = caBundle(server)
+	config.Backoff = testBackoff
+	mgr, err := webhook.NewManager(config, "test")
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	return mgr
+}
+
+func TestEnsureAndDelete(t *testing.T) {
+	endpoint := &fakeEndpoint{}
+	server := httptest.NewTLSServer(endpoint)
+	defer server.Close()
+	mgr := newManager(t, server, webhook.Config{BearerToken: "secret-token"})
+
+	if err := mgr.Ensure(aliasRecord()); err != nil {
+		t.Fatalf("failed to ensure record: %v", err)
+	}
+	if err := mgr.Delete(aliasRecord()); err != nil {
+		t.Fatalf("failed to delete record: %v", err)
+	}
+
+	expected := []webhook.Request{
+		{
+			APIVersion: "v1",
+			Action:     webhook.EnsureAction,
+			Record: webhook.Record{
+				Zone:   webhook.Zone{Tags: map[string]string{"Name": "public"}},
+				Type:   "ALIAS",
+				Domain: "*.apps.example.com",
+				Target: "lb.example.com",
+			},
+		},
+		{
+			APIVersion: "v1",
+			Action:     webhook.DeleteAction,
+			Record: webhook.Record{
+				Zone:   webhook.Zone{Tags: map[string]string{"Name": "public"}},
+				Type:   "ALIAS",
+				Domain: "*.apps.example.com",
+				Target: "lb.example.com",
+			},
+		},
+	}
+	if len(endpoint.requests) != len(expected) {
+		t.Fatalf("expected %d requests, got %d", len(expected), len(endpoint.requests))
+	}
+	for i := range expected {
+		actual, _ := json.Marshal(endpoint.requests[i])
+		want, _ := json.Marshal(expected[i])
+		if string(actual) != string(want) {
+			t.Errorf("expected request %s, got %s", want, actual)
+		}
+		if auth := endpoint.headers[i].Get("Authorization"); auth != "Bearer secret-token" {
+			t.Errorf("expected bearer token, got Authorization header %q", auth)
+		}
+		if ct := endpoint.headers[i].Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected JSON content type, got %q", ct)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		expect *dns.AliasRecord
+	}{
+		{
+			name:   "record exists",
+			status: http.StatusOK,
+			body:   `{"record": {"zone": {"tags": {"Name": "public"}}, "type": "ALIAS", "domain": "*.apps.example.com", "target": "other.example.com"}}`,
+			expect: &dns.AliasRecord{Domain: "*.apps.example.com", Target: "other.example.com"},
+		},
+		{
+			name:   "empty record",
+			status: http.StatusOK,
+			body:   `{}`,
+		},
+		{
+			name:   "not found",
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		endpoint := &fakeEndpoint{statuses: []int{test.status}, body: test.body}
+		server := httptest.NewTLSServer(endpoint)
+		mgr := newManager(t, server, webhook.Config{})
+
+		current, err := mgr.Get(aliasRecord())
+		server.Close()
+		if err != nil {
+			t.Errorf("%s: failed to get record: %v", test.name, err)
+			continue
+		}
+		switch {
+		case test.expect == nil && current != nil:
+			t.Errorf("%s: expected no record, got %v", test.name, current)
+		case test.expect != nil && (current == nil || *current.Alias != *test.expect):
+			t.Errorf("%s: expected %v, got %v", test.name, test.expect, current)
+		}
+	}
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name              string
+		statuses          []int
+		body              string
+		expectErr         bool
+		expectUnsupported bool
+		expectRequests    int
+	}{
+		{
+			name:           "retried until success",
+			statuses:       []int{http.StatusServiceUnavailable, http.StatusTooManyRequests},
+			expectRequests: 3,
+		},
+		{
+			name:           "retries exhausted",
+			statuses:       []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError},
+			expectErr:      true,
+			expectRequests: 3,
+		},
+		{
+			name:           "permanent failure",
+			statuses:       []int{http.StatusBadRequest},
+			expectErr:      true,
+			expectRequests: 1,
+		},
+		{
+			name:              "unsupported record type",
+			statuses:          []int{http.StatusUnprocessableEntity},
+			body:              `{"error": {"reason": "UnsupportedRecordType", "message": "only A records are supported"}}`,
+			expectErr:         true,
+			expectUnsupported: true,
+			expectRequests:    1,
+		},
+	}
+
+	for _, test := range tests {
+		endpoint := &fakeEndpoint{statuses: test.statuses, body: test.body}
+		server := httptest.NewTLSServer(endpoint)
+		mgr := newManager(t, server, webhook.Config{})
+
+		err := mgr.Ensure(aliasRecord())
+		server.Close()
+		if test.expectErr != (err != nil) {
+			t.Errorf("%s: expected error %v, got %v", test.name, test.expectErr, err)
+		}
+		if test.expectUnsupported != dns.IsUnsupportedRecordType(err) {
+			t.Errorf("%s: expected unsupported record type error %v, got %v", test.name, test.expectUnsupported, err)
+		}
+		if len(endpoint.requests) != test.expectRequests {
+			t.Errorf("%s: expected %d requests, got %d", test.name, test.expectRequests, len(endpoint.requests))
+		}
+	}
+}
+
+func TestRetryTimeout(t *testing.T) {
+	statuses := make([]int, 100)
+	for i := range statuses {
+		statuses[i] = http.StatusServiceUnavailable
+	}
+	endpoint := &fakeEndpoint{statuses: statuses}
+	server := httptest.NewTLSServer(endpoint)
+	defer server.Close()
+	mgr, err := webhook.NewManager(webhook.Config{
+		URL:          server.URL,
+		CABundle:     caBundle(server),
+		Backoff:      wait.Backoff{Duration: 20 * time.Millisecond, Factor: 1, Steps: 100},
+		RetryTimeout: 100 * time.Millisecond,
+	}, "test")
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	start := time.Now()
+	if err := mgr.Ensure(aliasRecord()); err == nil {
+		t.Errorf("expected an error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected retries to stop after the retry timeout, took %v", elapsed)
+	}
+	if len(endpoint.requests) >= len(statuses) {
+		t.Errorf("expected retries to stop before the backoff was exhausted, got %d requests", len(endpoint.requests))
+	}
+
+	stop := make(chan struct{})
+	hanging := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-stop
+	}))
+	defer hanging.Close()
+	defer close(stop)
+	mgr, err = webhook.NewManager(webhook.Config{
+		URL:          hanging.URL,
+		CABundle:     caBundle(hanging),
+		RetryTimeout: 100 * time.Millisecond,
+	}, "test")
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	start = time.Now()
+	if err := mgr.Ensure(aliasRecord()); err == nil {
+		t.Errorf("expected an error from an endpoint that doesn't respond")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the request to be abandoned after the retry timeout, took %v", elapsed)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	clientCert, clientKey, clientCA := generateClientCertificate(t)
+
+	endpoint := &fakeEndpoint{}
+	server := httptest.NewUnstartedServer(endpoint)
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCA,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	withoutCert := newManager(t, server, webhook.Config{})
+	if err := withoutCert.Ensure(aliasRecord()); err == nil {
+		t.Errorf("expected request without client certificate to fail")
+	}
+
+	withCert := newManager(t, server, webhook.Config{ClientCertificate: clientCert, ClientKey: clientKey})
+	if err := withCert.Ensure(aliasRecord()); err != nil {
+		t.Errorf("failed to ensure record with client certificate: %v", err)
+	}
+	if len(endpoint.requests) != 1 {
+		t.Errorf("expected 1 request, got %d", len(endpoint.requests))
+	}
+}
+
+func TestSupportsRecordType(t *testing.T) {
+	server := httptest.NewTLSServer(&fakeEndpoint{})
+	defer server.Close()
+
+	zone := aliasRecord().Zone
+	mgr := newManager(t, server, webhook.Config{})
+	for _, recordType := range []dns.RecordType{dns.ARecordType, dns.AAAARecordType, dns.ALIASRecord, dns.CNAMERecordType, dns.TXTRecordType} {
+		if !mgr.SupportsRecordType(zone, recordType) {
+			t.Errorf("expected record type %s to be supported by default", recordType)
+		}
+	}
+
+	mgr = newManager(t, server, webhook.Config{RecordTypes: []dns.RecordType{dns.CNAMERecordType}})
+	if mgr.SupportsRecordType(zone, dns.ALIASRecord) {
+		t.Errorf("expected record type %s not to be supported", dns.ALIASRecord)
+	}
+	if !mgr.SupportsRecordType(zone, dns.CNAMERecordType) {
+		t.Errorf("expected record type %s to be supported", dns.CNAMERecordType)
+	}
+}
+
+func TestNewManagerRequiresHTTPS(t *testing.T) {
+	if _, err := webhook.NewManager(webhook.Config{URL: "http://dns.example.com/"}, "test"); err == nil {
+		t.Errorf("expected an error for a non-https URL")
+	}
+}
+
+// generateClientCertificate returns a PEM encoded self-signed client
+// certificate and key, and a pool that trusts the certificate.
+func generateClientCertificate(t *testing.T) ([]byte, []byte, *x509.CertPool) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ingress-operator"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return certPEM, keyPEM, pool
+}
@@ -0,0 +1,98 @@
+package webhook
+
+// This file defines the versioned JSON schema of the messages that the webhook
+// manager exchanges with a webhook endpoint.
+//
+// For every operation, the manager sends an HTTP POST request to the endpoint
+// with a Request as its body and "Content-Type: application/json". The
+// endpoint responds as follows:
+//
+//   * Ensure: 2xx once the record exists with the given target. Ensuring a
+//     record that already exists must succeed.
+//   * Delete: 2xx once the record with the given target no longer exists.
+//     Deleting a record that doesn't exist must succeed.
+//   * Get: 200 with a Response whose record is the record that is currently
+//     published with the same zone, type, and domain, or 200 with an empty
+//     record (or 404) if there is no such record.
+//
+// A and AAAA records with the same zone and domain form one record set with
+// several addresses: Ensure adds the record's address to the record set, Delete
+// removes only the record's address, and Get should return the record's own
+// address if the record set has it.
+//
+// Responses with status 429 or 5xx, as well as connection errors, are retried
+// with exponential backoff for up to 15 seconds by default. Any other status
+// is a permanent failure. A failed response may include a Response with an
+// error; if its reason is "UnsupportedRecordType", the operator reports that
+// the endpoint doesn't support the record's type.
+
+// APIVersion is the version of the schema that the manager sends.
+const APIVersion = "v1"
+
+// Action is the operation that the endpoint is asked to perform.
+type Action string
+
+const (
+	// EnsureAction asks the endpoint to create or update the record.
+	EnsureAction Action = "Ensure"
+	// DeleteAction asks the endpoint to delete the record.
+	DeleteAction Action = "Delete"
+	// GetAction asks the endpoint to return the current record.
+	GetAction Action = "Get"
+)
+
+// Request is the body of every request that the manager sends.
+type Request struct {
+	// APIVersion is the version of the schema, currently "v1".
+	APIVersion string `json:"apiVersion"`
+	// Action is the operation to perform.
+	Action Action `json:"action"`
+	// Record is the record on which to perform the operation.
+	Record Record `json:"record"`
+}
+
+// Record is a DNS record in a single zone.
+type Record struct {
+	// Zone is the zone of the record as specified in the cluster DNS
+	// config.
+	Zone Zone `json:"zone"`
+	// Type is the record type, either "A", "AAAA", "ALIAS", "CNAME", or
+	// "TXT".
+	Type string `json:"type"`
+	// Domain is the record name, for example "*.apps.example.com".
+	Domain string `json:"domain"`
+	// Target is the IPv4 address of an A record, the IPv6 address of an
+	// AAAA record, the hostname that an ALIAS or CNAME record points to, or
+	// the text of a TXT record.
+	Target string `json:"target"`
+}
+
+// Zone identifies a zone either by ID or by tags.
+type Zone struct {
+	// ID is the ID of the zone, if specified.
+	ID string `json:"id,omitempty"`
+	// Tags are the tags of the zone, if specified.
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// Response is the body of a response from the endpoint. It is optional except
+// for successful Get operations.
+type Response struct {
+	// Record is the current record for a Get operation, if one exists.
+	Record *Record `json:"record,omitempty"`
+	// Error describes why an operation failed.
+	Error *Error `json:"error,omitempty"`
+}
+
+// Error describes a failed operation.
+type Error struct {
+	// Reason is a machine readable reason for the failure, for example
+	// "UnsupportedRecordType".
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable description of the failure.
+	Message string `json:"message,omitempty"`
+}
+
+// UnsupportedRecordTypeReason is the error reason with which an endpoint
+// reports that it doesn't support the type of a record.
+const UnsupportedRecordTypeReason = "UnsupportedRecordType"
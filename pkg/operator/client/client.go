@@ -5,6 +5,8 @@ import (
 
 	configv1 "github.com/openshift/api/config/v1"
 	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	iov1 "github.com/openshift/cluster-ingress-operator/pkg/api/v1"
 
 	kscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
@@ -33,6 +35,12 @@ func init() {
 	if err := configv1.Install(scheme); err != nil {
 		panic(err)
 	}
+	if err := iov1.Install(scheme); err != nil {
+		panic(err)
+	}
+	if err := routev1.Install(scheme); err != nil {
+		panic(err)
+	}
 }
 
 func GetScheme() *runtime.Scheme {
@@ -11,4 +11,23 @@ type Config struct {
 
 	// IngressControllerImage is the ingress controller image to manage.
 	IngressControllerImage string
+
+	// InfrastructureName is the name that uniquely identifies the cluster's
+	// infrastructure.
+	InfrastructureName string
+
+	// VerifyDNSResolution is whether the operator verifies that ingress
+	// domains resolve to their load balancers before reporting DNSReady.
+	// Verification is also enabled if DNSResolver is set.
+	VerifyDNSResolution bool
+
+	// DNSResolver is the nameserver, as a host with an optional port, through
+	// which the operator verifies that ingress domains resolve to their load
+	// balancers. If empty, the system's resolver is used.
+	DNSResolver string
+
+	// DNSRecordTTL, if positive, is the TTL in seconds of the records that
+	// the operator publishes on platforms whose DNS manager allows the TTL to
+	// be configured.
+	DNSRecordTTL int64
 }
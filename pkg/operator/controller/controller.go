@@ -3,8 +3,11 @@ package controller
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	iov1 "github.com/openshift/cluster-ingress-operator/pkg/api/v1"
 	"github.com/openshift/cluster-ingress-operator/pkg/dns"
 	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
 	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
@@ -18,12 +21,14 @@ import (
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -44,18 +49,25 @@ var log = logf.Logger.WithName("controller")
 // IngressController resources.
 //
 // The controller will be pre-configured to watch for IngressController resources
-// in the manager namespace.
-func New(mgr manager.Manager, config Config) (controller.Controller, error) {
+// in the manager namespace. Once an ingresscontroller publishes a DNS record
+// per route, a second controller, which only updates the DNS records for route
+// hosts, watches routes and namespaces through clusterCache, which must be able
+// to watch cluster-scoped resources. Likewise, nodes are watched through
+// clusterCache once an ingresscontroller publishes DNS records with the
+// addresses of its router pods' nodes.
+func New(mgr manager.Manager, clusterCache cache.Cache, config Config) (controller.Controller, error) {
 	reconciler := &reconciler{
-		Config:   config,
-		client:   mgr.GetClient(),
-		cache:    mgr.GetCache(),
-		recorder: mgr.GetEventRecorderFor("operator-controller"),
+		Config:       config,
+		client:       mgr.GetClient(),
+		cache:        mgr.GetCache(),
+		clusterCache: clusterCache,
+		recorder:     mgr.GetEventRecorderFor("operator-controller"),
 	}
 	c, err := controller.New("operator-controller", mgr, controller.Options{Reconciler: reconciler})
 	if err != nil {
 		return nil, err
 	}
+	reconciler.controller = c
 	if err := c.Watch(&source.Kind{Type: &operatorv1.IngressController{}}, &handler.EnqueueRequestForObject{}); err != nil {
 		return nil, err
 	}
@@ -65,9 +77,65 @@ func New(mgr manager.Manager, config Config) (controller.Controller, error) {
 	if err := c.Watch(&source.Kind{Type: &corev1.Service{}}, enqueueRequestForOwningIngressController(config.Namespace)); err != nil {
 		return nil, err
 	}
+	if err := c.Watch(&source.Kind{Type: &iov1.DNSRecord{}}, enqueueRequestForOwningIngressController(config.Namespace)); err != nil {
+		return nil, err
+	}
+	if err := c.Watch(&source.Kind{Type: &corev1.Pod{}}, reconciler.enqueueRequestForRouterPod()); err != nil {
+		return nil, err
+	}
+	reconciler.routeDNSController, err = controller.New("route-dns-controller", mgr, controller.Options{Reconciler: reconcile.Func(reconciler.reconcileRouteDNS)})
+	if err != nil {
+		return nil, err
+	}
+	if config.DNSManagerReloads != nil {
+		if err := c.Watch(&source.Channel{Source: config.DNSManagerReloads}, &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(reconciler.allIngressControllers)}); err != nil {
+			return nil, err
+		}
+	}
 	return c, nil
 }
 
+// ensureRouteWatches watches routes and namespaces through clusterCache unless
+// it already does. The watches are only started once an ingresscontroller
+// publishes a DNS record per route, as the cluster-wide informers hold every
+// route and namespace of the cluster in memory.
+func (r *reconciler) ensureRouteWatches() error {
+	r.routeWatchesLock.Lock()
+	defer r.routeWatchesLock.Unlock()
+	if r.routeWatchesStarted {
+		return nil
+	}
+	for _, o := range []runtime.Object{&routev1.Route{}, &corev1.Namespace{}} {
+		informer, err := r.clusterCache.GetInformer(o)
+		if err != nil {
+			return fmt.Errorf("failed to create informer for %T: %v", o, err)
+		}
+		if err := r.routeDNSController.Watch(&source.Informer{Informer: informer}, r.enqueueRequestsForPerRouteIngressControllers()); err != nil {
+			return fmt.Errorf("failed to watch %T: %v", o, err)
+		}
+	}
+	r.routeWatchesStarted = true
+	log.Info("started watching routes and namespaces")
+	return nil
+}
+
+// allIngressControllers returns a reconcile request for every
+// ingresscontroller, so that the operator status is updated.
+func (r *reconciler) allIngressControllers(o handler.MapObject) []reconcile.Request {
+	ingresses := &operatorv1.IngressControllerList{}
+	if err := r.cache.List(context.TODO(), ingresses, client.InNamespace(r.Namespace)); err != nil {
+		log.Error(err, "failed to list ingresscontrollers", "related", o.Meta.GetSelfLink())
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, ingress := range ingresses.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name},
+		})
+	}
+	return requests
+}
+
 func enqueueRequestForOwningIngressController(namespace string) handler.EventHandler {
 	return &handler.EnqueueRequestsFromMapFunc{
 		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
@@ -92,9 +160,20 @@ func enqueueRequestForOwningIngressController(namespace string) handler.EventHan
 // Config holds all the things necessary for the controller to run.
 type Config struct {
 	Namespace              string
-	DNSManager             dns.Manager
 	IngressControllerImage string
 	OperatorReleaseVersion string
+	// DNSManager is used to determine which types of DNS records can be
+	// published for an ingresscontroller. If it is a
+	// dns.ReloadableManager, the operator reports itself as degraded while
+	// the most recent reload has failed.
+	DNSManager dns.Manager
+	// DNSManagerReloads, if not nil, receives an event whenever the outcome
+	// of reloading the DNS manager changes.
+	DNSManagerReloads <-chan event.GenericEvent
+	// DNSResolver, if not nil, is used to verify that the wildcard domain of
+	// each ingresscontroller resolves to its load balancer before DNSReady
+	// is reported.
+	DNSResolver dns.Resolver
 }
 
 // reconciler handles the actual ingress reconciliation logic in response to
@@ -102,9 +181,33 @@ type Config struct {
 type reconciler struct {
 	Config
 
-	client   client.Client
-	cache    cache.Cache
-	recorder record.EventRecorder
+	client client.Client
+	cache  cache.Cache
+	// clusterCache is used to list routes and namespaces.
+	clusterCache cache.Cache
+	recorder     record.EventRecorder
+
+	// controller is the operator controller, to which ensureNodeWatch adds
+	// the watch on nodes.
+	controller controller.Controller
+	// nodeWatchLock guards nodeWatchStarted.
+	nodeWatchLock sync.Mutex
+	// nodeWatchStarted is true once nodes are watched.
+	nodeWatchStarted bool
+
+	// routeDNSController is the controller that reconciles route and
+	// namespace events, to which ensureRouteWatches adds the watches.
+	routeDNSController controller.Controller
+	// routeWatchesLock guards routeWatchesStarted.
+	routeWatchesLock sync.Mutex
+	// routeWatchesStarted is true once routes and namespaces are watched.
+	routeWatchesStarted bool
+
+	// dnsResolutionsLock guards dnsResolutions.
+	dnsResolutionsLock sync.Mutex
+	// dnsResolutions maps the UID of an ingresscontroller to the outcome
+	// of the most recent verification that its domain resolves.
+	dnsResolutions map[types.UID]dnsResolution
 }
 
 // Reconcile expects request to refer to a ingresscontroller in the operator
@@ -171,8 +274,10 @@ func (r *reconciler) Reconcile(request reconcile.Request) (reconcile.Result, err
 					errs = append(errs, fmt.Errorf("failed to enforce ingress finalizer %s/%s: %v", ingress.Namespace, ingress.Name, err))
 				} else {
 					// Handle everything else.
-					if err := r.ensureIngressController(ingress, dnsConfig, infraConfig); err != nil {
+					if res, err := r.ensureIngressController(ingress, dnsConfig, infraConfig); err != nil {
 						errs = append(errs, fmt.Errorf("failed to ensure ingresscontroller: %v", err))
+					} else {
+						result = res
 					}
 				}
 			}
@@ -311,7 +416,14 @@ func (r *reconciler) enforceIngressFinalizer(ingress *operatorv1.IngressControll
 // ensureIngressDeleted tries to delete ingress, and if successful, will remove
 // the finalizer.
 func (r *reconciler) ensureIngressDeleted(ingress *operatorv1.IngressController, dnsConfig *configv1.DNS, infraConfig *configv1.Infrastructure) error {
-	if err := r.finalizeLoadBalancerService(ingress, dnsConfig); err != nil {
+	if deleted, err := r.ensureDNSRecordsDeleted(ingress); err != nil {
+		return fmt.Errorf("failed to delete dnsrecords for %s: %v", ingress.Name, err)
+	} else if !deleted {
+		return fmt.Errorf("waiting for dnsrecords of %s to be finalized", ingress.Name)
+	}
+	log.Info("deleted dnsrecord for ingress", "namespace", ingress.Namespace, "name", ingress.Name)
+
+	if err := r.finalizeLoadBalancerService(ingress); err != nil {
 		return fmt.Errorf("failed to finalize load balancer service for %s: %v", ingress.Name, err)
 	}
 	log.Info("finalized load balancer service for ingress", "namespace", ingress.Namespace, "name", ingress.Name)
@@ -320,6 +432,7 @@ func (r *reconciler) ensureIngressDeleted(ingress *operatorv1.IngressController,
 		return fmt.Errorf("failed to delete deployment for ingress %s: %v", ingress.Name, err)
 	}
 	log.Info("deleted deployment for ingress", "namespace", ingress.Namespace, "name", ingress.Name)
+	r.forgetDNSResolution(ingress)
 
 	// Clean up the finalizer to allow the ingresscontroller to be deleted.
 	if slice.ContainsString(ingress.Finalizers, IngressControllerFinalizer) {
@@ -382,9 +495,12 @@ func (r *reconciler) ensureRouterNamespace() error {
 	return nil
 }
 
-// ensureIngressController ensures all necessary router resources exist for a given ingresscontroller.
-func (r *reconciler) ensureIngressController(ci *operatorv1.IngressController, dnsConfig *configv1.DNS, infraConfig *configv1.Infrastructure) error {
+// ensureIngressController ensures all necessary router resources exist for a
+// given ingresscontroller. It requests a requeue if the ingresscontroller's
+// wildcard domain does not yet resolve to its load balancer.
+func (r *reconciler) ensureIngressController(ci *operatorv1.IngressController, dnsConfig *configv1.DNS, infraConfig *configv1.Infrastructure) (reconcile.Result, error) {
 	errs := []error{}
+	result := reconcile.Result{}
 
 	if deployment, err := r.ensureRouterDeployment(ci, infraConfig); err != nil {
 		errs = append(errs, fmt.Errorf("failed to ensure router deployment for %s: %v", ci.Name, err))
@@ -398,13 +514,33 @@ func (r *reconciler) ensureIngressController(ci *operatorv1.IngressController, d
 			Controller: &trueVar,
 		}
 
+		var dnsRecord *iov1.DNSRecord
+		var routeDNSRecords []iov1.DNSRecord
+		var internalLBService *corev1.Service
 		lbService, err := r.ensureLoadBalancerService(ci, deploymentRef, infraConfig)
 		if err != nil {
 			errs = append(errs, fmt.Errorf("failed to ensure load balancer service for %s: %v", ci.Name, err))
 		} else if lbService != nil {
-			if err := r.ensureDNS(ci, lbService, dnsConfig); err != nil {
+			// Without an internal load balancer, the records in the
+			// private zone point at the external load balancer.
+			internalLBService, err = r.ensureInternalLoadBalancerService(ci, deploymentRef, infraConfig)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to ensure internal load balancer service for %s: %v", ci.Name, err))
+			}
+			dnsRecord, routeDNSRecords, err = r.ensureDNS(ci, lbService, internalLBService, dnsConfig, infraConfig)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to ensure DNS for %s: %v", ci.Name, err))
+			}
+		} else if hasHostNetworkDNS(ci) {
+			dnsRecord, routeDNSRecords, err = r.ensureDNS(ci, nil, nil, dnsConfig, infraConfig)
+			if err != nil {
 				errs = append(errs, fmt.Errorf("failed to ensure DNS for %s: %v", ci.Name, err))
 			}
+		} else if _, err := r.ensureDNSRecordsDeleted(ci); err != nil {
+			// The records of an ingresscontroller that no longer
+			// publishes the node addresses of its router pods are
+			// deleted.
+			errs = append(errs, fmt.Errorf("failed to delete DNS records for %s: %v", ci.Name, err))
 		}
 
 		if internalSvc, err := r.ensureInternalIngressControllerService(ci, deploymentRef); err != nil {
@@ -418,12 +554,29 @@ func (r *reconciler) ensureIngressController(ci *operatorv1.IngressController, d
 			errs = append(errs, fmt.Errorf("failed to list events in namespace %q: %v", "openshift-ingress", err))
 		}
 
-		if err := r.syncIngressControllerStatus(ci, deployment, lbService, operandEvents.Items); err != nil {
+		// A domain with a routing policy may resolve to another
+		// cluster's load balancer, and names without a route don't
+		// resolve if there is a record per route, so neither is
+		// verified.
+		var verifyResolution func() error
+		mode, _ := dnsRecordModeFor(ci)
+		if r.DNSResolver != nil && lbService != nil && !hasDNSRoutingPolicy(ci) && mode == wildcardDNSRecordMode {
+			verifyResolution = func() error {
+				retryAfter, err := r.verifyDNSResolutionCached(ci, lbService, internalLBService)
+				if err != nil {
+					// Nothing triggers another reconcile when the
+					// domain starts resolving, so poll for it.
+					result.RequeueAfter = retryAfter
+				}
+				return err
+			}
+		}
+		if err := r.syncIngressControllerStatus(ci, deployment, lbService, operandEvents.Items, combinedDNSRecord(dnsRecord, routeDNSRecords), dnsConfig, verifyResolution); err != nil {
 			errs = append(errs, fmt.Errorf("failed to sync ingresscontroller status: %v", err))
 		}
 	}
 
-	return utilerrors.NewAggregate(errs)
+	return result, utilerrors.NewAggregate(errs)
 }
 
 // ensureMetricsIntegration ensures that router prometheus metrics is integrated with openshift-monitoring for the given ingresscontroller.
@@ -1,28 +1,453 @@
 package controller
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/cluster-ingress-operator/pkg/api/v1"
 	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
 
 	corev1 "k8s.io/api/core/v1"
 
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+
 	configv1 "github.com/openshift/api/config/v1"
 )
 
-// ensureDNS will create DNS records for the given LB service. If service is
-// nil, nothing is done.
-func (r *reconciler) ensureDNS(ci *operatorv1.IngressController, service *corev1.Service, dnsConfig *configv1.DNS) error {
-	records := desiredDNSRecords(ci, dnsConfig, service)
-	for _, record := range records {
-		err := r.DNSManager.Ensure(record)
+const (
+	// PublicDNSZoneAnnotation is an annotation on an ingresscontroller that
+	// names the zone in which the operator publishes the ingresscontroller's
+	// public DNS records, instead of the public zone in the cluster DNS
+	// config. The value is either a zone ID or a JSON-encoded DNS zone, such
+	// as {"tags":{"Name":"shard.example.com"}}.
+	PublicDNSZoneAnnotation = "ingress.operator.openshift.io/public-dns-zone"
+
+	// PrivateDNSZoneAnnotation is an annotation on an ingresscontroller that
+	// names the zone in which the operator publishes the ingresscontroller's
+	// private DNS records, instead of the private zone in the cluster DNS
+	// config. The value has the same format as for PublicDNSZoneAnnotation.
+	PrivateDNSZoneAnnotation = "ingress.operator.openshift.io/private-dns-zone"
+
+	// DNSScopeAnnotation is an annotation on an ingresscontroller that
+	// selects whether the operator publishes the ingresscontroller's DNS
+	// records in the public zone, the private zone, or both. The value is
+	// "Public", "Private", or "Both", and the default is "Both".
+	DNSScopeAnnotation = "ingress.operator.openshift.io/dns-scope"
+
+	// DNSRoutingPolicyAnnotation is an annotation on an ingresscontroller
+	// that publishes the ingresscontroller's DNS records with a routing
+	// policy, so that several clusters can publish records for the same
+	// domain. The value is "Weighted", "Failover", or "Latency". The
+	// records are identified by the cluster's infrastructure name, and a
+	// Latency record's region is the cluster's AWS region. Routing policies
+	// are only supported on AWS.
+	DNSRoutingPolicyAnnotation = "ingress.operator.openshift.io/dns-routing-policy"
+
+	// DNSRoutingWeightAnnotation is an annotation on an ingresscontroller
+	// that sets the weight, from 0 to 255, of the ingresscontroller's
+	// Weighted DNS records. The default is 100.
+	DNSRoutingWeightAnnotation = "ingress.operator.openshift.io/dns-routing-weight"
+
+	// DNSRoutingFailoverAnnotation is an annotation on an ingresscontroller
+	// that sets the role of the ingresscontroller's Failover DNS records,
+	// which is "Primary" or "Secondary". The default is "Primary".
+	DNSRoutingFailoverAnnotation = "ingress.operator.openshift.io/dns-routing-failover"
+
+	// DNSHealthCheckAnnotation is an annotation on an ingresscontroller
+	// that, if "true", makes the DNS provider check the health of the
+	// router through its load balancer and answer queries with the
+	// ingresscontroller's records in the public zone only while the router
+	// accepts connections on its HTTPS port. It requires a routing policy.
+	DNSHealthCheckAnnotation = "ingress.operator.openshift.io/dns-health-check"
+)
+
+const (
+	// defaultDNSRoutingWeight is the weight of Weighted DNS records if
+	// DNSRoutingWeightAnnotation is absent.
+	defaultDNSRoutingWeight = 100
+
+	// dnsHealthCheckPort is the port of the load balancer to which DNS
+	// health checks connect. The health checks are TCP checks of the port
+	// that the load balancer already exposes, so that neither the router's
+	// stats nor its metrics need to be reachable from the internet.
+	dnsHealthCheckPort = 443
+)
+
+// dnsScope is a value of DNSScopeAnnotation.
+type dnsScope string
+
+const (
+	publicDNSScope  dnsScope = "Public"
+	privateDNSScope dnsScope = "Private"
+	bothDNSScope    dnsScope = "Both"
+)
+
+// ensureDNS ensures that a DNSRecord exists for the given LB service with the
+// DNS records that the ingresscontroller needs, including a record for its
+// canonical hostname, and returns the current DNSRecord along with the current
+// DNSRecords for route hosts, if the ingresscontroller publishes a record per
+// route. The DNS controller publishes the records in the DNSRecords. If
+// internalService is not nil, the records in the private zone point at its load
+// balancer instead. If the ingresscontroller is published with the HostNetwork
+// strategy, the records point at the nodes of its ready router pods, and the
+// services are nil. If the ingresscontroller's zones, routing policy, or router
+// pods can't be determined, or it has no ready router pods, the current
+// DNSRecords are left as is.
+func (r *reconciler) ensureDNS(ci *operatorv1.IngressController, service, internalService *corev1.Service, dnsConfig *configv1.DNS, infraConfig *configv1.Infrastructure) (*iov1.DNSRecord, []iov1.DNSRecord, error) {
+	current, err := r.currentWildcardDNSRecord(ci)
+	if err != nil {
+		return nil, nil, err
+	}
+	keep := func(err error) (*iov1.DNSRecord, []iov1.DNSRecord, error) {
+		routeRecords, _ := r.currentRouteDNSRecords(ci)
+		return current, routeRecords, err
+	}
+
+	privateZone, publicZone, err := dnsZones(ci, dnsConfig)
+	if err != nil {
+		return keep(err)
+	}
+	policy, err := dnsRoutingPolicy(ci, infraConfig)
+	if err != nil {
+		return keep(err)
+	}
+	mode, err := dnsRecordModeFor(ci)
+	if err != nil {
+		return keep(err)
+	}
+	// The records for route hosts are in separate DNSRecords, which
+	// ensureRouteDNSRecords derives from the records for the canonical
+	// hostname.
+	domains := []string{}
+	if mode == wildcardDNSRecordMode {
+		domains = append(domains, fmt.Sprintf("*.%s", ci.Status.Domain))
+	}
+	domains = withCanonicalHostname(ci, domains)
+	var records []*dns.Record
+	if hasHostNetworkDNS(ci) {
+		internal, external, err := r.routerHostAddresses(ci)
 		if err != nil {
-			return fmt.Errorf("failed to ensure DNS record %v for %s/%s: %v", record, ci.Namespace, ci.Name, err)
+			return keep(err)
+		}
+		if len(internal) == 0 && current != nil {
+			// Keep the last published addresses rather than
+			// deleting every record while no router pod is ready.
+			return keep(nil)
+		}
+		records = desiredHostNetworkDNSRecords(ci, privateZone, publicZone, internal, external)
+	} else {
+		records = desiredLoadBalancerDNSRecords(ci, privateZone, publicZone, service, internalService, r.DNSManager)
+	}
+	desired := desiredWildcardDNSRecord(ci, publicZone, policy, domains, records)
+
+	switch {
+	case current == nil:
+		if err := r.client.Create(context.TODO(), desired); err != nil {
+			return nil, nil, fmt.Errorf("failed to create dnsrecord %s/%s: %v", desired.Namespace, desired.Name, err)
+		}
+		log.Info("created dnsrecord", "namespace", desired.Namespace, "name", desired.Name)
+		current = desired
+	case !dnsRecordSpecsEqual(current.Spec, desired.Spec):
+		updated := current.DeepCopy()
+		updated.Spec = desired.Spec
+		if err := r.client.Update(context.TODO(), updated); err != nil {
+			return keep(fmt.Errorf("failed to update dnsrecord %s/%s: %v", updated.Namespace, updated.Name, err))
+		}
+		log.Info("updated dnsrecord", "namespace", updated.Namespace, "name", updated.Name)
+		current = updated
+	}
+	routeRecords, err := r.ensureRouteDNSRecords(ci, current)
+	return current, routeRecords, err
+}
+
+const (
+	// dnsResolutionTimeout is how long verifying that an ingress domain
+	// resolves may take.
+	dnsResolutionTimeout = 5 * time.Second
+
+	// dnsResolutionRetryPeriod is how often the operator checks again
+	// whether an ingress domain that did not resolve to its load balancer
+	// does now.
+	dnsResolutionRetryPeriod = 30 * time.Second
+)
+
+// dnsResolution is the outcome of the most recent verification that an
+// ingresscontroller's domain resolves to one of the given targets.
+type dnsResolution struct {
+	domain  string
+	targets []string
+	err     error
+	checked time.Time
+}
+
+// verifyDNSResolutionCached verifies that the ingresscontroller's wildcard
+// domain resolves as verifyDNSResolution does, except that the outcome of the
+// most recent verification is reused until it is dnsResolutionRetryPeriod old,
+// unless the domain or the load balancer changed since. This keeps the lookups,
+// which take up to dnsResolutionTimeout, from delaying every reconciliation.
+// Returns how long until the domain is verified again, along with the outcome.
+func (r *reconciler) verifyDNSResolutionCached(ci *operatorv1.IngressController, service, internalService *corev1.Service) (time.Duration, error) {
+	targets := loadBalancerTargets(service)
+	if internalService != nil {
+		targets = append(targets, loadBalancerTargets(internalService)...)
+	}
+
+	r.dnsResolutionsLock.Lock()
+	last, ok := r.dnsResolutions[ci.UID]
+	r.dnsResolutionsLock.Unlock()
+	if ok && last.domain == ci.Status.Domain && cmp.Equal(last.targets, targets) {
+		if age := time.Since(last.checked); age < dnsResolutionRetryPeriod {
+			return dnsResolutionRetryPeriod - age, last.err
+		}
+	}
+
+	err := r.verifyDNSResolution(ci, targets)
+	r.dnsResolutionsLock.Lock()
+	defer r.dnsResolutionsLock.Unlock()
+	if r.dnsResolutions == nil {
+		r.dnsResolutions = map[types.UID]dnsResolution{}
+	}
+	r.dnsResolutions[ci.UID] = dnsResolution{domain: ci.Status.Domain, targets: targets, err: err, checked: time.Now()}
+	return dnsResolutionRetryPeriod, err
+}
+
+// forgetDNSResolution drops the outcome of the most recent verification that
+// the ingresscontroller's domain resolves.
+func (r *reconciler) forgetDNSResolution(ci *operatorv1.IngressController) {
+	r.dnsResolutionsLock.Lock()
+	defer r.dnsResolutionsLock.Unlock()
+	delete(r.dnsResolutions, ci.UID)
+}
+
+// verifyDNSResolution verifies that a name under the ingresscontroller's
+// wildcard domain resolves to any of the given targets, which are the ingress
+// points of the load balancers of its LB service and, if it has one, of its
+// internal LB service, which is the answer that resolvers that see the private
+// zone get. The name has a random label so that negative answers cached from
+// earlier attempts don't delay the result. The returned error does not mention
+// the name, so that it can be reported in status without changing on every
+// attempt.
+func (r *reconciler) verifyDNSResolution(ci *operatorv1.IngressController, targets []string) error {
+	if len(targets) == 0 {
+		return fmt.Errorf("the load balancer has no ingress points")
+	}
+
+	wildcard := fmt.Sprintf("*.%s", ci.Status.Domain)
+	probe := fmt.Sprintf("dns-probe-%s.%s", utilrand.String(8), ci.Status.Domain)
+	ctx, cancel := context.WithTimeout(context.Background(), dnsResolutionTimeout)
+	defer cancel()
+	var err error
+	for _, target := range targets {
+		if err = dns.VerifyResolution(ctx, r.DNSResolver, probe, target); err == nil {
+			return nil
+		}
+	}
+	switch e := err.(type) {
+	case *dns.ResolutionMismatchError:
+		return fmt.Errorf("%s resolves to %s, not to %s", wildcard, strings.Join(e.Addresses, ", "), strings.Join(targets, " or "))
+	case *net.DNSError:
+		if e.Name != probe {
+			return fmt.Errorf("failed to resolve %s: %s", e.Name, e.Err)
+		}
+		return fmt.Errorf("failed to resolve %s: %s", wildcard, e.Err)
+	default:
+		log.Info("failed to resolve ingress domain", "namespace", ci.Namespace, "name", ci.Name, "probe", probe, "error", err)
+		return fmt.Errorf("failed to resolve %s", wildcard)
+	}
+}
+
+// loadBalancerTargets returns the hostname or IP address of each ingress point
+// of the given LB service's load balancer.
+func loadBalancerTargets(service *corev1.Service) []string {
+	var targets []string
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		switch {
+		case len(ingress.Hostname) > 0:
+			targets = append(targets, ingress.Hostname)
+		case len(ingress.IP) > 0:
+			targets = append(targets, ingress.IP)
+		}
+	}
+	return targets
+}
+
+// currentWildcardDNSRecord returns the current DNSRecord for the
+// ingresscontroller, or nil if none exists.
+func (r *reconciler) currentWildcardDNSRecord(ci *operatorv1.IngressController) (*iov1.DNSRecord, error) {
+	record := &iov1.DNSRecord{}
+	if err := r.client.Get(context.TODO(), WildcardDNSRecordName(ci), record); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get dnsrecord %s: %v", WildcardDNSRecordName(ci), err)
+	}
+	return record, nil
+}
+
+// ensureDNSRecordsDeleted deletes the DNSRecords for the ingresscontroller.
+// Returns true if no DNSRecord exists anymore; the DNS controller deletes the
+// published records before a DNSRecord is gone.
+func (r *reconciler) ensureDNSRecordsDeleted(ci *operatorv1.IngressController) (bool, error) {
+	wildcard, err := r.currentWildcardDNSRecord(ci)
+	if err != nil {
+		return false, err
+	}
+	routeRecords, err := r.currentRouteDNSRecords(ci)
+	if err != nil {
+		return false, err
+	}
+	records := routeRecords
+	if wildcard != nil {
+		records = append(records, *wildcard)
+	}
+	for i := range records {
+		record := &records[i]
+		if record.DeletionTimestamp != nil {
+			continue
+		}
+		if err := r.client.Delete(context.TODO(), record); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return false, fmt.Errorf("failed to delete dnsrecord %s/%s: %v", record.Namespace, record.Name, err)
+		}
+		log.Info("deleted dnsrecord", "namespace", record.Namespace, "name", record.Name)
+	}
+	return len(records) == 0, nil
+}
+
+// desiredWildcardDNSRecord returns the desired DNSRecord for the given
+// ingresscontroller with the given records for its wildcard domain. If domains
+// is nil, the DNSRecord has the records as they are, and otherwise it has a
+// copy of them for each of domains. If policy is not nil, every record has the
+// routing policy, but only the records in the public zone have its health
+// check, as the DNS provider can't reach the targets of private records. The
+// DNSRecord is owned by the ingresscontroller.
+func desiredWildcardDNSRecord(ci *operatorv1.IngressController, publicZone *configv1.DNSZone, policy *dns.RoutingPolicy, domains []string, records []*dns.Record) *iov1.DNSRecord {
+	record := newDNSRecord(ci, WildcardDNSRecordName(ci))
+	if domains != nil {
+		records = recordsForDomains(records, domains)
+	}
+	for _, r := range records {
+		if policy != nil {
+			p := *policy
+			if publicZone == nil || !cmp.Equal(r.Zone, *publicZone, cmpopts.EquateEmpty()) {
+				p.HealthCheck = nil
+			}
+			r.RoutingPolicy = &p
+		}
+		record.Spec.Records = append(record.Spec.Records, recordToAPI(r))
+	}
+	return record
+}
+
+// newDNSRecord returns a DNSRecord with the given name and no records that is
+// owned by the given ingresscontroller.
+func newDNSRecord(ci *operatorv1.IngressController, name types.NamespacedName) *iov1.DNSRecord {
+	trueVar := true
+	return &iov1.DNSRecord{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: name.Namespace,
+			Name:      name.Name,
+			Labels: map[string]string{
+				manifests.OwningIngressControllerLabel: ci.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: operatorv1.GroupVersion.String(),
+				Kind:       "IngressController",
+				Name:       ci.Name,
+				UID:        ci.UID,
+				Controller: &trueVar,
+			}},
+		},
+	}
+}
+
+// withCanonicalHostname returns the given domains with the ingresscontroller's
+// canonical hostname, so that the router has a name that resolves to it however
+// the ingresscontroller's other names are published.
+func withCanonicalHostname(ci *operatorv1.IngressController, domains []string) []string {
+	canonicalHostname := CanonicalHostname(ci)
+	if len(canonicalHostname) == 0 {
+		return domains
+	}
+	for _, domain := range domains {
+		if domain == canonicalHostname {
+			return domains
 		}
-		log.Info("ensured DNS record for ingresscontroller", "namespace", ci.Namespace, "name", ci.Name, "record", record)
 	}
-	return nil
+	return append(domains, canonicalHostname)
+}
+
+// desiredLoadBalancerDNSRecords returns the records for the given
+// ingresscontroller's wildcard domain in the given zones. The records in the
+// private zone point at the internal LB service once it is provisioned, and the
+// other records point at the LB service.
+func desiredLoadBalancerDNSRecords(ci *operatorv1.IngressController, privateZone, publicZone *configv1.DNSZone, service, internalService *corev1.Service, dnsManager dns.Manager) []*dns.Record {
+	if internalService != nil && isProvisioned(internalService) {
+		return append(desiredDNSRecords(ci, zoneList(privateZone), internalService, dnsManager),
+			desiredDNSRecords(ci, zoneList(publicZone), service, dnsManager)...)
+	}
+	return desiredDNSRecords(ci, zoneList(privateZone, publicZone), service, dnsManager)
+}
+
+// dnsRecordSpecsEqual compares two DNSRecordSpec values.  Returns true if the
+// provided values should be considered equal for the purpose of determining
+// whether an update is necessary, false otherwise.
+func dnsRecordSpecsEqual(a, b iov1.DNSRecordSpec) bool {
+	return cmp.Equal(a, b, cmpopts.EquateEmpty())
+}
+
+// recordToAPI returns the API representation of the given dns.Record.
+func recordToAPI(record *dns.Record) iov1.Record {
+	r := iov1.Record{
+		Zone: record.Zone,
+		Type: iov1.RecordType(record.Type),
+	}
+	switch record.Type {
+	case dns.ALIASRecord:
+		r.Type = iov1.ALIASRecordType
+		r.Domain = record.Alias.Domain
+		r.Target = record.Alias.Target
+	case dns.ARecordType:
+		r.Type = iov1.ARecordType
+		r.Domain = record.ARecord.Domain
+		r.Target = record.ARecord.Address
+	case dns.AAAARecordType:
+		r.Type = iov1.AAAARecordType
+		r.Domain = record.AAAARecord.Domain
+		r.Target = record.AAAARecord.Address
+	case dns.CNAMERecordType:
+		r.Type = iov1.CNAMERecordType
+		r.Domain = record.CNAMERecord.Domain
+		r.Target = record.CNAMERecord.Target
+	}
+	if p := record.RoutingPolicy; p != nil {
+		r.RoutingPolicy = &iov1.RoutingPolicy{
+			Type:          iov1.RoutingPolicyType(p.Type),
+			SetIdentifier: p.SetIdentifier,
+			Weight:        p.Weight,
+			Failover:      iov1.FailoverRole(p.Failover),
+			Region:        p.Region,
+		}
+		if p.HealthCheck != nil {
+			r.RoutingPolicy.HealthCheck = &iov1.HealthCheck{Port: p.HealthCheck.Port, Path: p.HealthCheck.Path}
+		}
+	}
+	return r
 }
 
 func newAliasRecord(domain, target string, zone configv1.DNSZone) *dns.Record {
@@ -36,6 +461,17 @@ func newAliasRecord(domain, target string, zone configv1.DNSZone) *dns.Record {
 	}
 }
 
+func newCNAMERecord(domain, target string, zone configv1.DNSZone) *dns.Record {
+	return &dns.Record{
+		Zone: zone,
+		Type: dns.CNAMERecordType,
+		CNAMERecord: &dns.CNAMERecord{
+			Domain: domain,
+			Target: target,
+		},
+	}
+}
+
 func newARecord(domain, target string, zone configv1.DNSZone) *dns.Record {
 	return &dns.Record{
 		Zone: zone,
@@ -47,10 +483,33 @@ func newARecord(domain, target string, zone configv1.DNSZone) *dns.Record {
 	}
 }
 
+func newAAAARecord(domain, target string, zone configv1.DNSZone) *dns.Record {
+	return &dns.Record{
+		Zone: zone,
+		Type: dns.AAAARecordType,
+		AAAARecord: &dns.AAAARecord{
+			Domain:  domain,
+			Address: target,
+		},
+	}
+}
+
+// newIPRecord returns an A record for an IPv4 address or an AAAA record for an
+// IPv6 address.
+func newIPRecord(domain string, ip net.IP, zone configv1.DNSZone) *dns.Record {
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return newARecord(domain, ipv4.String(), zone)
+	}
+	return newAAAARecord(domain, ip.String(), zone)
+}
+
 // desiredDNSRecords will return any necessary DNS records for the given inputs.
-// If an ingress domain is in use, records are desired in every specified zone
-// present in the cluster DNS configuration.
-func desiredDNSRecords(ci *operatorv1.IngressController, dnsConfig *configv1.DNS, service *corev1.Service) []*dns.Record {
+// If an ingress domain is in use, records are desired in every one of zones,
+// which are some or all of the ingresscontroller's zones. A load balancer
+// hostname yields an ALIAS record, or a CNAME record if dnsManager supports
+// CNAME records but not ALIAS records in the zone; an IPv4 address yields an A
+// record, and an IPv6 address yields an AAAA record.
+func desiredDNSRecords(ci *operatorv1.IngressController, zones []configv1.DNSZone, service *corev1.Service, dnsManager dns.Manager) []*dns.Record {
 	records := []*dns.Record{}
 
 	// If the ingresscontroller has no ingress domain, we cannot configure any
@@ -65,25 +524,193 @@ func desiredDNSRecords(ci *operatorv1.IngressController, dnsConfig *configv1.DNS
 	}
 
 	name := fmt.Sprintf("*.%s", ci.Status.Domain)
-	zones := []configv1.DNSZone{}
-	if dnsConfig.Spec.PrivateZone != nil {
-		zones = append(zones, *dnsConfig.Spec.PrivateZone)
-	}
-	if dnsConfig.Spec.PublicZone != nil {
-		zones = append(zones, *dnsConfig.Spec.PublicZone)
-	}
 	for _, ingress := range service.Status.LoadBalancer.Ingress {
 		if len(ingress.Hostname) > 0 {
 			for _, zone := range zones {
-				records = append(records, newAliasRecord(name, ingress.Hostname, zone))
+				if !dnsManager.SupportsRecordType(zone, dns.ALIASRecord) && dnsManager.SupportsRecordType(zone, dns.CNAMERecordType) {
+					records = append(records, newCNAMERecord(name, ingress.Hostname, zone))
+				} else {
+					records = append(records, newAliasRecord(name, ingress.Hostname, zone))
+				}
 			}
 		}
 		if len(ingress.IP) > 0 {
+			ip := net.ParseIP(ingress.IP)
+			if ip == nil {
+				log.Info("ignoring invalid load balancer ingress IP", "namespace", service.Namespace, "name", service.Name, "ip", ingress.IP)
+				continue
+			}
 			for _, zone := range zones {
-				records = append(records, newARecord(name, ingress.IP, zone))
+				records = append(records, newIPRecord(name, ip, zone))
 			}
 		}
 	}
 
 	return records
 }
+
+// dnsZones returns the private and the public zone in which the DNS records for
+// the given ingresscontroller are published, each of which is named by an
+// annotation on the ingresscontroller or else taken from the cluster DNS
+// config. A zone is nil if it is undefined or outside of the scope that the
+// ingresscontroller selects. An error is returned if an annotation is invalid.
+func dnsZones(ci *operatorv1.IngressController, dnsConfig *configv1.DNS) (*configv1.DNSZone, *configv1.DNSZone, error) {
+	var privateZone, publicZone *configv1.DNSZone
+	if dnsConfig != nil {
+		privateZone, publicZone = dnsConfig.Spec.PrivateZone, dnsConfig.Spec.PublicZone
+	}
+	if value, ok := ci.Annotations[PrivateDNSZoneAnnotation]; ok {
+		zone, err := parseDNSZone(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %s annotation: %v", PrivateDNSZoneAnnotation, err)
+		}
+		privateZone = zone
+	}
+	if value, ok := ci.Annotations[PublicDNSZoneAnnotation]; ok {
+		zone, err := parseDNSZone(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %s annotation: %v", PublicDNSZoneAnnotation, err)
+		}
+		publicZone = zone
+	}
+
+	scope := bothDNSScope
+	if value, ok := ci.Annotations[DNSScopeAnnotation]; ok {
+		scope = dnsScope(value)
+	}
+	switch scope {
+	case publicDNSScope:
+		privateZone = nil
+	case privateDNSScope:
+		publicZone = nil
+	case bothDNSScope:
+	default:
+		return nil, nil, fmt.Errorf("invalid %s annotation: %q is not one of %q, %q, or %q", DNSScopeAnnotation, scope, publicDNSScope, privateDNSScope, bothDNSScope)
+	}
+	return privateZone, publicZone, nil
+}
+
+// parseDNSRoutingPolicy returns the routing policy that the annotations on the
+// given ingresscontroller select, without the set identifier and region, which
+// depend on the cluster, or nil if the ingresscontroller has no
+// DNSRoutingPolicyAnnotation annotation. An error is returned if an annotation
+// is invalid.
+func parseDNSRoutingPolicy(ci *operatorv1.IngressController) (*dns.RoutingPolicy, error) {
+	value, ok := ci.Annotations[DNSRoutingPolicyAnnotation]
+	if !ok {
+		for _, annotation := range []string{DNSRoutingWeightAnnotation, DNSRoutingFailoverAnnotation, DNSHealthCheckAnnotation} {
+			if _, ok := ci.Annotations[annotation]; ok {
+				return nil, fmt.Errorf("the %s annotation requires the %s annotation", annotation, DNSRoutingPolicyAnnotation)
+			}
+		}
+		return nil, nil
+	}
+
+	policy := &dns.RoutingPolicy{Type: dns.RoutingPolicyType(value)}
+	switch policy.Type {
+	case dns.WeightedRoutingPolicy:
+		policy.Weight = defaultDNSRoutingWeight
+		if value, ok := ci.Annotations[DNSRoutingWeightAnnotation]; ok {
+			weight, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || weight < 0 || weight > 255 {
+				return nil, fmt.Errorf("invalid %s annotation: %q is not an integer from 0 to 255", DNSRoutingWeightAnnotation, value)
+			}
+			policy.Weight = weight
+		}
+	case dns.FailoverRoutingPolicy:
+		policy.Failover = dns.PrimaryFailoverRole
+		if value, ok := ci.Annotations[DNSRoutingFailoverAnnotation]; ok {
+			policy.Failover = dns.FailoverRole(value)
+			if policy.Failover != dns.PrimaryFailoverRole && policy.Failover != dns.SecondaryFailoverRole {
+				return nil, fmt.Errorf("invalid %s annotation: %q is not one of %q or %q", DNSRoutingFailoverAnnotation, value, dns.PrimaryFailoverRole, dns.SecondaryFailoverRole)
+			}
+		}
+	case dns.LatencyRoutingPolicy:
+	default:
+		return nil, fmt.Errorf("invalid %s annotation: %q is not one of %q, %q, or %q", DNSRoutingPolicyAnnotation, value, dns.WeightedRoutingPolicy, dns.FailoverRoutingPolicy, dns.LatencyRoutingPolicy)
+	}
+	if policy.Type != dns.WeightedRoutingPolicy {
+		if _, ok := ci.Annotations[DNSRoutingWeightAnnotation]; ok {
+			return nil, fmt.Errorf("the %s annotation requires the %s routing policy", DNSRoutingWeightAnnotation, dns.WeightedRoutingPolicy)
+		}
+	}
+	if policy.Type != dns.FailoverRoutingPolicy {
+		if _, ok := ci.Annotations[DNSRoutingFailoverAnnotation]; ok {
+			return nil, fmt.Errorf("the %s annotation requires the %s routing policy", DNSRoutingFailoverAnnotation, dns.FailoverRoutingPolicy)
+		}
+	}
+
+	if hasDNSHealthCheck(ci) {
+		policy.HealthCheck = &dns.HealthCheck{Port: dnsHealthCheckPort}
+	} else if value, ok := ci.Annotations[DNSHealthCheckAnnotation]; ok && value != "false" {
+		return nil, fmt.Errorf("invalid %s annotation: %q is not %q or %q", DNSHealthCheckAnnotation, value, "true", "false")
+	}
+	return policy, nil
+}
+
+// dnsRoutingPolicy returns the routing policy of the given ingresscontroller's
+// DNS records, or nil if the records have no routing policy. The records of a
+// cluster are identified by its infrastructure name. An error is returned if an
+// annotation is invalid or if the platform doesn't support routing policies.
+func dnsRoutingPolicy(ci *operatorv1.IngressController, infraConfig *configv1.Infrastructure) (*dns.RoutingPolicy, error) {
+	policy, err := parseDNSRoutingPolicy(ci)
+	if err != nil || policy == nil {
+		return nil, err
+	}
+	if infraConfig.Status.Platform != configv1.AWSPlatformType {
+		return nil, fmt.Errorf("DNS routing policies are not supported on platform %q", infraConfig.Status.Platform)
+	}
+	if len(infraConfig.Status.InfrastructureName) == 0 {
+		return nil, fmt.Errorf("the cluster has no infrastructure name to identify its DNS records")
+	}
+	policy.SetIdentifier = infraConfig.Status.InfrastructureName
+	if policy.Type == dns.LatencyRoutingPolicy {
+		if infraConfig.Status.PlatformStatus == nil || infraConfig.Status.PlatformStatus.AWS == nil || len(infraConfig.Status.PlatformStatus.AWS.Region) == 0 {
+			return nil, fmt.Errorf("the cluster has no AWS region for %s DNS records", dns.LatencyRoutingPolicy)
+		}
+		policy.Region = infraConfig.Status.PlatformStatus.AWS.Region
+	}
+	return policy, nil
+}
+
+// hasDNSRoutingPolicy returns true if the given ingresscontroller asks for a
+// routing policy for its DNS records.
+func hasDNSRoutingPolicy(ci *operatorv1.IngressController) bool {
+	_, ok := ci.Annotations[DNSRoutingPolicyAnnotation]
+	return ok
+}
+
+// hasDNSHealthCheck returns true if the given ingresscontroller asks for a
+// health check of its DNS records.
+func hasDNSHealthCheck(ci *operatorv1.IngressController) bool {
+	return hasDNSRoutingPolicy(ci) && ci.Annotations[DNSHealthCheckAnnotation] == "true"
+}
+
+// zoneList returns the given zones that are not nil.
+func zoneList(zones ...*configv1.DNSZone) []configv1.DNSZone {
+	list := []configv1.DNSZone{}
+	for _, zone := range zones {
+		if zone != nil {
+			list = append(list, *zone)
+		}
+	}
+	return list
+}
+
+// parseDNSZone parses the value of PublicDNSZoneAnnotation or
+// PrivateDNSZoneAnnotation.
+func parseDNSZone(value string) (*configv1.DNSZone, error) {
+	value = strings.TrimSpace(value)
+	zone := &configv1.DNSZone{}
+	if strings.HasPrefix(value, "{") {
+		if err := json.Unmarshal([]byte(value), zone); err != nil {
+			return nil, err
+		}
+	} else {
+		zone.ID = value
+	}
+	if len(zone.ID) == 0 && len(zone.Tags) == 0 {
+		return nil, fmt.Errorf("the zone has neither an ID nor tags")
+	}
+	return zone, nil
+}
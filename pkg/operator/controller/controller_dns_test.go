@@ -1,14 +1,18 @@
 package controller
 
 import (
+	"context"
 	"testing"
 
 	configv1 "github.com/openshift/api/config/v1"
 	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/cluster-ingress-operator/pkg/api/v1"
 	"github.com/openshift/cluster-ingress-operator/pkg/dns"
 
 	corev1 "k8s.io/api/core/v1"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 )
@@ -78,6 +82,16 @@ func TestDesiredDNSRecords(t *testing.T) {
 					Domain:  r.name,
 					Address: r.target,
 				}
+			case dns.AAAARecordType:
+				record.AAAARecord = &dns.AAAARecord{
+					Domain:  r.name,
+					Address: r.target,
+				}
+			case dns.CNAMERecordType:
+				record.CNAMERecord = &dns.CNAMERecord{
+					Domain: r.name,
+					Target: r.target,
+				}
 			}
 			dnsRecords = append(dnsRecords, record)
 		}
@@ -88,6 +102,11 @@ func TestDesiredDNSRecords(t *testing.T) {
 		domain      string
 		publish     operatorv1.EndpointPublishingStrategyType
 		dnsConfig   *configv1.DNS
+		// annotations are the annotations on the ingresscontroller.
+		annotations map[string]string
+		// recordTypes are the record types that the DNS manager
+		// supports; if nil, every record type is supported.
+		recordTypes []dns.RecordType
 		ingresses   []ingress
 		expect      []record
 	}{
@@ -156,6 +175,46 @@ func TestDesiredDNSRecords(t *testing.T) {
 				{typ: dns.ALIASRecord, name: "*.apps.openshift.example.com", target: "lb.cloud.example.com", zone: privateZone},
 			},
 		},
+		{
+			description: "global CNAME",
+			publish:     operatorv1.LoadBalancerServiceStrategyType,
+			domain:      "apps.openshift.example.com",
+			dnsConfig:   globalConfig,
+			recordTypes: []dns.RecordType{dns.ARecordType, dns.CNAMERecordType},
+			ingresses: []ingress{
+				{host: "lb.cloud.example.com"},
+			},
+			expect: []record{
+				{typ: dns.CNAMERecordType, name: "*.apps.openshift.example.com", target: "lb.cloud.example.com", zone: publicZone},
+				{typ: dns.CNAMERecordType, name: "*.apps.openshift.example.com", target: "lb.cloud.example.com", zone: privateZone},
+			},
+		},
+		{
+			description: "ALIAS preferred over CNAME",
+			publish:     operatorv1.LoadBalancerServiceStrategyType,
+			domain:      "apps.openshift.example.com",
+			dnsConfig:   publicConfig,
+			recordTypes: []dns.RecordType{dns.ALIASRecord, dns.CNAMERecordType},
+			ingresses: []ingress{
+				{host: "lb.cloud.example.com"},
+			},
+			expect: []record{
+				{typ: dns.ALIASRecord, name: "*.apps.openshift.example.com", target: "lb.cloud.example.com", zone: publicZone},
+			},
+		},
+		{
+			description: "neither ALIAS nor CNAME supported",
+			publish:     operatorv1.LoadBalancerServiceStrategyType,
+			domain:      "apps.openshift.example.com",
+			dnsConfig:   publicConfig,
+			recordTypes: []dns.RecordType{dns.ARecordType},
+			ingresses: []ingress{
+				{host: "lb.cloud.example.com"},
+			},
+			expect: []record{
+				{typ: dns.ALIASRecord, name: "*.apps.openshift.example.com", target: "lb.cloud.example.com", zone: publicZone},
+			},
+		},
 		{
 			description: "global A",
 			publish:     operatorv1.LoadBalancerServiceStrategyType,
@@ -169,11 +228,115 @@ func TestDesiredDNSRecords(t *testing.T) {
 				{typ: dns.ARecordType, name: "*.apps.openshift.example.com", target: "192.0.2.1", zone: privateZone},
 			},
 		},
+		{
+			description: "private only AAAA",
+			publish:     operatorv1.LoadBalancerServiceStrategyType,
+			domain:      "apps.openshift.example.com",
+			dnsConfig:   privateConfig,
+			ingresses: []ingress{
+				{ip: "2001:db8::1"},
+			},
+			expect: []record{
+				{typ: dns.AAAARecordType, name: "*.apps.openshift.example.com", target: "2001:db8::1", zone: privateZone},
+			},
+		},
+		{
+			description: "global dual-stack",
+			publish:     operatorv1.LoadBalancerServiceStrategyType,
+			domain:      "apps.openshift.example.com",
+			dnsConfig:   globalConfig,
+			ingresses: []ingress{
+				{ip: "192.0.2.1"},
+				{ip: "2001:DB8:0::1"},
+				{ip: "::ffff:192.0.2.2"},
+			},
+			expect: []record{
+				{typ: dns.ARecordType, name: "*.apps.openshift.example.com", target: "192.0.2.1", zone: publicZone},
+				{typ: dns.ARecordType, name: "*.apps.openshift.example.com", target: "192.0.2.1", zone: privateZone},
+				{typ: dns.AAAARecordType, name: "*.apps.openshift.example.com", target: "2001:db8::1", zone: publicZone},
+				{typ: dns.AAAARecordType, name: "*.apps.openshift.example.com", target: "2001:db8::1", zone: privateZone},
+				{typ: dns.ARecordType, name: "*.apps.openshift.example.com", target: "192.0.2.2", zone: publicZone},
+				{typ: dns.ARecordType, name: "*.apps.openshift.example.com", target: "192.0.2.2", zone: privateZone},
+			},
+		},
+		{
+			description: "annotated public zone",
+			publish:     operatorv1.LoadBalancerServiceStrategyType,
+			domain:      "apps.shard.example.com",
+			dnsConfig:   globalConfig,
+			annotations: map[string]string{
+				PublicDNSZoneAnnotation: "shard",
+			},
+			ingresses: []ingress{
+				{ip: "192.0.2.1"},
+			},
+			expect: []record{
+				{typ: dns.ARecordType, name: "*.apps.shard.example.com", target: "192.0.2.1", zone: configv1.DNSZone{ID: "shard"}},
+				{typ: dns.ARecordType, name: "*.apps.shard.example.com", target: "192.0.2.1", zone: privateZone},
+			},
+		},
+		{
+			description: "annotated public zone by tags with public scope",
+			publish:     operatorv1.LoadBalancerServiceStrategyType,
+			domain:      "apps.shard.example.com",
+			dnsConfig:   globalConfig,
+			annotations: map[string]string{
+				PublicDNSZoneAnnotation: `{"tags":{"Name":"shard"}}`,
+				DNSScopeAnnotation:      "Public",
+			},
+			ingresses: []ingress{
+				{ip: "192.0.2.1"},
+			},
+			expect: []record{
+				{typ: dns.ARecordType, name: "*.apps.shard.example.com", target: "192.0.2.1", zone: configv1.DNSZone{Tags: map[string]string{"Name": "shard"}}},
+			},
+		},
+		{
+			description: "private scope",
+			publish:     operatorv1.LoadBalancerServiceStrategyType,
+			domain:      "apps.openshift.example.com",
+			dnsConfig:   globalConfig,
+			annotations: map[string]string{
+				DNSScopeAnnotation: "Private",
+			},
+			ingresses: []ingress{
+				{ip: "192.0.2.1"},
+			},
+			expect: []record{
+				{typ: dns.ARecordType, name: "*.apps.openshift.example.com", target: "192.0.2.1", zone: privateZone},
+			},
+		},
+		{
+			description: "public scope without a public zone",
+			publish:     operatorv1.LoadBalancerServiceStrategyType,
+			domain:      "apps.openshift.example.com",
+			dnsConfig:   privateConfig,
+			annotations: map[string]string{
+				DNSScopeAnnotation: "Public",
+			},
+			ingresses: []ingress{
+				{ip: "192.0.2.1"},
+			},
+			expect: []record{},
+		},
+		{
+			description: "invalid IP",
+			publish:     operatorv1.LoadBalancerServiceStrategyType,
+			domain:      "apps.openshift.example.com",
+			dnsConfig:   globalConfig,
+			ingresses: []ingress{
+				{ip: "not-an-ip"},
+			},
+			expect: []record{},
+		},
 	}
 
 	for _, test := range tests {
 		t.Logf("testing %s", test.description)
 		controller := &operatorv1.IngressController{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: test.annotations,
+			},
 			Status: operatorv1.IngressControllerStatus{
 				Domain: test.domain,
 				EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
@@ -181,7 +344,16 @@ func TestDesiredDNSRecords(t *testing.T) {
 				},
 			},
 		}
-		actual := desiredDNSRecords(controller, test.dnsConfig, makeService(test.ingresses))
+		var dnsManager dns.Manager = &dns.NoopManager{}
+		if test.recordTypes != nil {
+			dnsManager = dns.NewFakeManager(test.recordTypes...)
+		}
+		private, public, err := dnsZones(controller, test.dnsConfig)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+		actual := desiredDNSRecords(controller, zoneList(private, public), makeService(test.ingresses), dnsManager)
 		expected := makeRecords(test.expect)
 		if !cmp.Equal(actual, expected, cmpopts.EquateEmpty(), cmpopts.SortSlices(cmpRecords)) {
 			t.Errorf("expected:")
@@ -196,6 +368,332 @@ func TestDesiredDNSRecords(t *testing.T) {
 	}
 }
 
+func TestDNSZonesInvalidAnnotations(t *testing.T) {
+	for _, annotations := range []map[string]string{
+		{PublicDNSZoneAnnotation: ""},
+		{PrivateDNSZoneAnnotation: `{"id":`},
+		{PrivateDNSZoneAnnotation: `{"tags":{}}`},
+		{DNSScopeAnnotation: "Internal"},
+	} {
+		ic := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Annotations: annotations}}
+		if _, _, err := dnsZones(ic, globalConfig); err == nil {
+			t.Errorf("expected an error for annotations %v", annotations)
+		}
+	}
+}
+
+func TestDesiredWildcardDNSRecordSplitHorizon(t *testing.T) {
+	ic := &operatorv1.IngressController{
+		Status: operatorv1.IngressControllerStatus{
+			Domain: "apps.openshift.example.com",
+			EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+				Type: operatorv1.LoadBalancerServiceStrategyType,
+			},
+		},
+	}
+	makeService := func(ip string) *corev1.Service {
+		service := &corev1.Service{}
+		if len(ip) > 0 {
+			service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: ip}}
+		}
+		return service
+	}
+	external := makeService("192.0.2.1")
+
+	tests := []struct {
+		description string
+		internal    *corev1.Service
+		expect      []iov1.Record
+	}{
+		{
+			description: "no internal load balancer",
+			expect: []iov1.Record{
+				{Zone: privateZone, Type: iov1.ARecordType, Domain: "*.apps.openshift.example.com", Target: "192.0.2.1"},
+				{Zone: publicZone, Type: iov1.ARecordType, Domain: "*.apps.openshift.example.com", Target: "192.0.2.1"},
+			},
+		},
+		{
+			description: "pending internal load balancer",
+			internal:    makeService(""),
+			expect: []iov1.Record{
+				{Zone: privateZone, Type: iov1.ARecordType, Domain: "*.apps.openshift.example.com", Target: "192.0.2.1"},
+				{Zone: publicZone, Type: iov1.ARecordType, Domain: "*.apps.openshift.example.com", Target: "192.0.2.1"},
+			},
+		},
+		{
+			description: "provisioned internal load balancer",
+			internal:    makeService("10.0.0.1"),
+			expect: []iov1.Record{
+				{Zone: privateZone, Type: iov1.ARecordType, Domain: "*.apps.openshift.example.com", Target: "10.0.0.1"},
+				{Zone: publicZone, Type: iov1.ARecordType, Domain: "*.apps.openshift.example.com", Target: "192.0.2.1"},
+			},
+		},
+	}
+	for _, test := range tests {
+		records := desiredLoadBalancerDNSRecords(ic, &privateZone, &publicZone, external, test.internal, &dns.NoopManager{})
+		record := desiredWildcardDNSRecord(ic, &publicZone, nil, nil, records)
+		if !cmp.Equal(record.Spec.Records, test.expect) {
+			t.Errorf("%s: expected records %v, got %v", test.description, test.expect, record.Spec.Records)
+		}
+	}
+}
+
+func TestWithCanonicalHostname(t *testing.T) {
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Status:     operatorv1.IngressControllerStatus{Domain: "apps.example.com"},
+	}
+
+	tests := []struct {
+		description string
+		domains     []string
+		expect      []string
+	}{
+		{
+			description: "wildcard",
+			domains:     []string{"*.apps.example.com"},
+			expect:      []string{"*.apps.example.com", "router-default.apps.example.com"},
+		},
+		{
+			description: "no routes",
+			domains:     []string{},
+			expect:      []string{"router-default.apps.example.com"},
+		},
+		{
+			description: "route with the canonical hostname",
+			domains:     []string{"router-default.apps.example.com", "web.apps.example.com"},
+			expect:      []string{"router-default.apps.example.com", "web.apps.example.com"},
+		},
+	}
+	for _, test := range tests {
+		if actual := withCanonicalHostname(ic, test.domains); !cmp.Equal(actual, test.expect) {
+			t.Errorf("%s: expected domains %v, got %v", test.description, test.expect, actual)
+		}
+	}
+
+	if actual := withCanonicalHostname(&operatorv1.IngressController{}, []string{"*."}); !cmp.Equal(actual, []string{"*."}) {
+		t.Errorf("expected no canonical hostname without a domain, got %v", actual)
+	}
+}
+
+func TestDesiredWildcardDNSRecordRoutingPolicy(t *testing.T) {
+	ic := &operatorv1.IngressController{
+		Status: operatorv1.IngressControllerStatus{
+			Domain: "apps.openshift.example.com",
+			EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+				Type: operatorv1.LoadBalancerServiceStrategyType,
+			},
+		},
+	}
+	service := &corev1.Service{}
+	service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "lb.example.com"}}
+	policy := &dns.RoutingPolicy{
+		Type:          dns.WeightedRoutingPolicy,
+		SetIdentifier: "cluster-1",
+		Weight:        100,
+		HealthCheck:   &dns.HealthCheck{Port: 443},
+	}
+
+	records := desiredLoadBalancerDNSRecords(ic, &privateZone, &publicZone, service, nil, &dns.NoopManager{})
+	record := desiredWildcardDNSRecord(ic, &publicZone, policy, nil, records)
+	expect := []iov1.Record{
+		{
+			Zone:          privateZone,
+			Type:          iov1.ALIASRecordType,
+			Domain:        "*.apps.openshift.example.com",
+			Target:        "lb.example.com",
+			RoutingPolicy: &iov1.RoutingPolicy{Type: iov1.WeightedRoutingPolicy, SetIdentifier: "cluster-1", Weight: 100},
+		},
+		{
+			Zone:          publicZone,
+			Type:          iov1.ALIASRecordType,
+			Domain:        "*.apps.openshift.example.com",
+			Target:        "lb.example.com",
+			RoutingPolicy: &iov1.RoutingPolicy{Type: iov1.WeightedRoutingPolicy, SetIdentifier: "cluster-1", Weight: 100, HealthCheck: &iov1.HealthCheck{Port: 443}},
+		},
+	}
+	if !cmp.Equal(record.Spec.Records, expect) {
+		t.Errorf("expected records %v, got %v", expect, record.Spec.Records)
+	}
+}
+
+func TestDNSRoutingPolicy(t *testing.T) {
+	infraConfig := &configv1.Infrastructure{
+		Status: configv1.InfrastructureStatus{
+			InfrastructureName: "cluster-1",
+			Platform:           configv1.AWSPlatformType,
+			PlatformStatus: &configv1.PlatformStatus{
+				Type: configv1.AWSPlatformType,
+				AWS:  &configv1.AWSPlatformStatus{Region: "us-east-1"},
+			},
+		},
+	}
+	healthCheck := &dns.HealthCheck{Port: 443}
+
+	tests := []struct {
+		description string
+		annotations map[string]string
+		platform    configv1.PlatformType
+		expect      *dns.RoutingPolicy
+		expectErr   bool
+	}{
+		{
+			description: "no annotations",
+		},
+		{
+			description: "weighted with the default weight",
+			annotations: map[string]string{DNSRoutingPolicyAnnotation: "Weighted"},
+			expect:      &dns.RoutingPolicy{Type: dns.WeightedRoutingPolicy, SetIdentifier: "cluster-1", Weight: 100},
+		},
+		{
+			description: "weighted with a weight and a health check",
+			annotations: map[string]string{DNSRoutingPolicyAnnotation: "Weighted", DNSRoutingWeightAnnotation: "0", DNSHealthCheckAnnotation: "true"},
+			expect:      &dns.RoutingPolicy{Type: dns.WeightedRoutingPolicy, SetIdentifier: "cluster-1", Weight: 0, HealthCheck: healthCheck},
+		},
+		{
+			description: "secondary failover",
+			annotations: map[string]string{DNSRoutingPolicyAnnotation: "Failover", DNSRoutingFailoverAnnotation: "Secondary", DNSHealthCheckAnnotation: "false"},
+			expect:      &dns.RoutingPolicy{Type: dns.FailoverRoutingPolicy, SetIdentifier: "cluster-1", Failover: dns.SecondaryFailoverRole},
+		},
+		{
+			description: "latency",
+			annotations: map[string]string{DNSRoutingPolicyAnnotation: "Latency"},
+			expect:      &dns.RoutingPolicy{Type: dns.LatencyRoutingPolicy, SetIdentifier: "cluster-1", Region: "us-east-1"},
+		},
+		{
+			description: "unknown routing policy",
+			annotations: map[string]string{DNSRoutingPolicyAnnotation: "Geolocation"},
+			expectErr:   true,
+		},
+		{
+			description: "weight out of range",
+			annotations: map[string]string{DNSRoutingPolicyAnnotation: "Weighted", DNSRoutingWeightAnnotation: "256"},
+			expectErr:   true,
+		},
+		{
+			description: "weight without the weighted routing policy",
+			annotations: map[string]string{DNSRoutingPolicyAnnotation: "Failover", DNSRoutingWeightAnnotation: "10"},
+			expectErr:   true,
+		},
+		{
+			description: "unknown failover role",
+			annotations: map[string]string{DNSRoutingPolicyAnnotation: "Failover", DNSRoutingFailoverAnnotation: "Tertiary"},
+			expectErr:   true,
+		},
+		{
+			description: "health check without a routing policy",
+			annotations: map[string]string{DNSHealthCheckAnnotation: "true"},
+			expectErr:   true,
+		},
+		{
+			description: "unsupported platform",
+			annotations: map[string]string{DNSRoutingPolicyAnnotation: "Weighted"},
+			platform:    configv1.GCPPlatformType,
+			expectErr:   true,
+		},
+	}
+	for _, test := range tests {
+		ic := &operatorv1.IngressController{ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations}}
+		infra := infraConfig.DeepCopy()
+		if len(test.platform) > 0 {
+			infra.Status.Platform = test.platform
+		}
+		policy, err := dnsRoutingPolicy(ic, infra)
+		switch {
+		case test.expectErr && err == nil:
+			t.Errorf("%s: expected an error", test.description)
+		case !test.expectErr && err != nil:
+			t.Errorf("%s: unexpected error: %v", test.description, err)
+		case !cmp.Equal(policy, test.expect):
+			t.Errorf("%s: expected routing policy %v, got %v", test.description, test.expect, policy)
+		}
+	}
+}
+
 func cmpRecords(a, b *dns.Record) bool {
-	return string(a.Zone.ID) < string(b.Zone.ID)
+	return a.String() < b.String()
+}
+
+func TestLoadBalancerTargets(t *testing.T) {
+	cases := []struct {
+		description string
+		ingresses   []corev1.LoadBalancerIngress
+		expected    []string
+	}{
+		{
+			description: "no ingress points",
+		},
+		{
+			description: "hostname",
+			ingresses:   []corev1.LoadBalancerIngress{{Hostname: "lb.example.com"}},
+			expected:    []string{"lb.example.com"},
+		},
+		{
+			description: "several addresses",
+			ingresses:   []corev1.LoadBalancerIngress{{IP: "192.0.2.1"}, {IP: "2001:db8::1"}, {}},
+			expected:    []string{"192.0.2.1", "2001:db8::1"},
+		},
+	}
+	for _, tc := range cases {
+		service := &corev1.Service{}
+		service.Status.LoadBalancer.Ingress = tc.ingresses
+		if actual := loadBalancerTargets(service); !cmp.Equal(actual, tc.expected) {
+			t.Errorf("%q: expected %v, got %v", tc.description, tc.expected, actual)
+		}
+	}
+}
+
+// fakeResolver is a dns.Resolver that resolves every name to its addresses and
+// counts the lookups.
+type fakeResolver struct {
+	addresses []string
+	lookups   int
+}
+
+func (r *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	r.lookups++
+	return r.addresses, nil
+}
+
+func (r *fakeResolver) LookupCNAME(ctx context.Context, host string) (string, error) {
+	r.lookups++
+	return host, nil
+}
+
+func TestVerifyDNSResolutionCached(t *testing.T) {
+	resolver := &fakeResolver{addresses: []string{"192.0.2.2"}}
+	r := &reconciler{Config: Config{DNSResolver: resolver}}
+	ci := ingressController("default", operatorv1.LoadBalancerServiceStrategyType)
+	ci.UID = "1"
+	ci.Status.Domain = "apps.example.com"
+	service := &corev1.Service{}
+	service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "192.0.2.1"}}
+	verify := func(description string, expectLookups int, expectErr bool) {
+		t.Helper()
+		resolver.lookups = 0
+		retryAfter, err := r.verifyDNSResolutionCached(ci, service, nil)
+		if resolver.lookups != expectLookups || (err != nil) != expectErr {
+			t.Errorf("%s: expected %d lookups and error %t, got %d lookups and error %v", description, expectLookups, expectErr, resolver.lookups, err)
+		}
+		if retryAfter <= 0 || retryAfter > dnsResolutionRetryPeriod {
+			t.Errorf("%s: expected to retry within %v, got %v", description, dnsResolutionRetryPeriod, retryAfter)
+		}
+	}
+
+	verify("first verification", 1, true)
+	verify("recent failure", 0, true)
+
+	// A change of the load balancer is verified right away.
+	service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "192.0.2.2"}}
+	verify("new load balancer", 1, false)
+	verify("recent success", 0, false)
+
+	// An outcome is verified again once it is old enough.
+	resolution := r.dnsResolutions[ci.UID]
+	resolution.checked = resolution.checked.Add(-dnsResolutionRetryPeriod)
+	r.dnsResolutions[ci.UID] = resolution
+	verify("expired success", 1, false)
+
+	r.forgetDNSResolution(ci)
+	verify("forgotten outcome", 1, false)
 }
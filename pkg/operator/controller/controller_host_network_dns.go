@@ -0,0 +1,261 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// HostNetworkDNSAnnotation is an annotation on an ingresscontroller that, if
+// "true", makes the operator manage DNS records for an ingresscontroller that
+// is published with the HostNetwork endpoint publishing strategy. The records
+// are A or AAAA records with the IP addresses of the nodes on which the
+// ingresscontroller has ready router pods, and they are updated as the pods
+// move between nodes. Records in the private zone have the nodes' internal
+// addresses, and records in the public zone have their external addresses.
+const HostNetworkDNSAnnotation = "ingress.operator.openshift.io/host-network-dns"
+
+// hasHostNetworkDNS returns true if the operator manages DNS records with the
+// node addresses of the given ingresscontroller's router pods.
+func hasHostNetworkDNS(ci *operatorv1.IngressController) bool {
+	return ci.Status.EndpointPublishingStrategy != nil &&
+		ci.Status.EndpointPublishingStrategy.Type == operatorv1.HostNetworkStrategyType &&
+		ci.Annotations[HostNetworkDNSAnnotation] == "true"
+}
+
+// routerHostAddresses returns the sorted internal and external IP addresses of
+// the nodes on which the given ingresscontroller has ready router pods. The
+// internal addresses are the pods' host IPs, and the external addresses are the
+// ExternalIP addresses of the nodes that have one.
+func (r *reconciler) routerHostAddresses(ci *operatorv1.IngressController) ([]string, []string, error) {
+	pods := &corev1.PodList{}
+	if err := r.cache.List(context.TODO(), pods, client.InNamespace(RouterDeploymentName(ci).Namespace), client.MatchingLabels(IngressControllerDeploymentPodSelector(ci).MatchLabels)); err != nil {
+		return nil, nil, fmt.Errorf("failed to list router pods: %v", err)
+	}
+	if r.clusterCache == nil {
+		return nil, nil, fmt.Errorf("nodes can't be watched")
+	}
+	if err := r.ensureNodeWatch(); err != nil {
+		return nil, nil, err
+	}
+	nodes := []corev1.Node{}
+	for _, name := range readyNodeNames(pods.Items) {
+		node := &corev1.Node{}
+		if err := r.clusterCache.Get(context.TODO(), types.NamespacedName{Name: name}, node); err != nil {
+			return nil, nil, fmt.Errorf("failed to get node %s: %v", name, err)
+		}
+		nodes = append(nodes, *node)
+	}
+	return readyHostIPs(pods.Items), externalIPs(nodes), nil
+}
+
+// isRouterPodReady returns true if the given router pod is ready, is not being
+// deleted, and has been scheduled to a node.
+func isRouterPodReady(pod corev1.Pod) bool {
+	return pod.DeletionTimestamp == nil && len(pod.Status.HostIP) > 0 && len(pod.Spec.NodeName) > 0 && isPodReady(pod)
+}
+
+// readyHostIPs returns the sorted, distinct host IPs of the given pods that are
+// ready and not being deleted.
+func readyHostIPs(pods []corev1.Pod) []string {
+	seen := map[string]bool{}
+	addresses := []string{}
+	for _, pod := range pods {
+		if !isRouterPodReady(pod) {
+			continue
+		}
+		if !seen[pod.Status.HostIP] {
+			seen[pod.Status.HostIP] = true
+			addresses = append(addresses, pod.Status.HostIP)
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// readyNodeNames returns the sorted, distinct names of the nodes of the given
+// pods that are ready and not being deleted.
+func readyNodeNames(pods []corev1.Pod) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, pod := range pods {
+		if !isRouterPodReady(pod) {
+			continue
+		}
+		if !seen[pod.Spec.NodeName] {
+			seen[pod.Spec.NodeName] = true
+			names = append(names, pod.Spec.NodeName)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// externalIPs returns the sorted, distinct ExternalIP addresses of the given
+// nodes.
+func externalIPs(nodes []corev1.Node) []string {
+	seen := map[string]bool{}
+	addresses := []string{}
+	for _, node := range nodes {
+		for _, address := range node.Status.Addresses {
+			if address.Type != corev1.NodeExternalIP || seen[address.Address] {
+				continue
+			}
+			seen[address.Address] = true
+			addresses = append(addresses, address.Address)
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// isPodReady returns true if the pod's Ready condition is true.
+func isPodReady(pod corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// desiredHostNetworkDNSRecords returns an A record for every IPv4 address and
+// an AAAA record for every IPv6 address for the given ingresscontroller's
+// wildcard domain, with the internal addresses in the private zone and the
+// external addresses in the public zone, if the zones are not nil. Records with
+// the same name make up one record set with several addresses.
+func desiredHostNetworkDNSRecords(ci *operatorv1.IngressController, privateZone, publicZone *configv1.DNSZone, internal, external []string) []*dns.Record {
+	records := []*dns.Record{}
+	if len(ci.Status.Domain) == 0 {
+		return records
+	}
+
+	name := fmt.Sprintf("*.%s", ci.Status.Domain)
+	for _, zoneAddresses := range []struct {
+		zone      *configv1.DNSZone
+		addresses []string
+	}{{privateZone, internal}, {publicZone, external}} {
+		if zoneAddresses.zone == nil {
+			continue
+		}
+		for _, address := range zoneAddresses.addresses {
+			ip := net.ParseIP(address)
+			if ip == nil {
+				log.Info("ignoring invalid router host IP", "namespace", ci.Namespace, "name", ci.Name, "ip", address)
+				continue
+			}
+			records = append(records, newIPRecord(name, ip, *zoneAddresses.zone))
+		}
+	}
+	return records
+}
+
+// enqueueRequestForRouterPod returns an event handler that queues a request for
+// the ingresscontroller of a router pod if the operator manages DNS records
+// with the node addresses of the ingresscontroller's router pods.
+func (r *reconciler) enqueueRequestForRouterPod() handler.EventHandler {
+	return &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			name, ok := a.Meta.GetLabels()[controllerDeploymentLabel]
+			if !ok {
+				return nil
+			}
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: r.Namespace, Name: name}}
+			ci := &operatorv1.IngressController{}
+			if err := r.cache.Get(context.TODO(), request.NamespacedName, ci); err != nil || !hasHostNetworkDNS(ci) {
+				return nil
+			}
+			return []reconcile.Request{request}
+		}),
+	}
+}
+
+// ensureNodeWatch watches nodes through clusterCache unless it already does, so
+// that the records of an ingresscontroller follow changes to the addresses of
+// its router pods' nodes. The watch is only started once an ingresscontroller
+// publishes records with node addresses, as the cluster-wide informer holds
+// every node of the cluster in memory.
+func (r *reconciler) ensureNodeWatch() error {
+	r.nodeWatchLock.Lock()
+	defer r.nodeWatchLock.Unlock()
+	if r.nodeWatchStarted {
+		return nil
+	}
+	informer, err := r.clusterCache.GetInformer(&corev1.Node{})
+	if err != nil {
+		return fmt.Errorf("failed to create informer for nodes: %v", err)
+	}
+	// Nodes are updated whenever they report their status, so only changes
+	// to their addresses are relevant.
+	addressesChanged := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldNode, ok := e.ObjectOld.(*corev1.Node)
+			if !ok {
+				return true
+			}
+			newNode, ok := e.ObjectNew.(*corev1.Node)
+			if !ok {
+				return true
+			}
+			return !reflect.DeepEqual(oldNode.Status.Addresses, newNode.Status.Addresses)
+		},
+	}
+	if err := r.controller.Watch(&source.Informer{Informer: informer}, r.enqueueRequestsForNode(), addressesChanged); err != nil {
+		return fmt.Errorf("failed to watch nodes: %v", err)
+	}
+	r.nodeWatchStarted = true
+	log.Info("started watching nodes")
+	return nil
+}
+
+// enqueueRequestsForNode returns an event handler that queues a request for
+// every ingresscontroller that has a router pod on a node if the operator
+// manages DNS records with the node addresses of the ingresscontroller's router
+// pods.
+func (r *reconciler) enqueueRequestsForNode() handler.EventHandler {
+	return &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			ingresses := &operatorv1.IngressControllerList{}
+			if err := r.cache.List(context.TODO(), ingresses, client.InNamespace(r.Namespace)); err != nil {
+				log.Error(err, "failed to list ingresscontrollers", "related", a.Meta.GetSelfLink())
+				return nil
+			}
+			requests := []reconcile.Request{}
+			for i := range ingresses.Items {
+				ci := &ingresses.Items[i]
+				if !hasHostNetworkDNS(ci) {
+					continue
+				}
+				pods := &corev1.PodList{}
+				if err := r.cache.List(context.TODO(), pods, client.InNamespace(RouterDeploymentName(ci).Namespace), client.MatchingLabels(IngressControllerDeploymentPodSelector(ci).MatchLabels)); err != nil {
+					log.Error(err, "failed to list router pods", "namespace", ci.Namespace, "name", ci.Name)
+					continue
+				}
+				for _, pod := range pods.Items {
+					if pod.Spec.NodeName == a.Meta.GetName() {
+						requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: ci.Namespace, Name: ci.Name}})
+						break
+					}
+				}
+			}
+			return requests
+		}),
+	}
+}
@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+
+	corev1 "k8s.io/api/core/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadyHostIPs(t *testing.T) {
+	pod := func(hostIP string, ready corev1.ConditionStatus) corev1.Pod {
+		nodeName := ""
+		if len(hostIP) > 0 {
+			nodeName = "node-" + hostIP
+		}
+		return corev1.Pod{
+			Spec: corev1.PodSpec{NodeName: nodeName},
+			Status: corev1.PodStatus{
+				HostIP:     hostIP,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}},
+			},
+		}
+	}
+	deleting := pod("192.0.2.4", corev1.ConditionTrue)
+	deleting.DeletionTimestamp = &metav1.Time{}
+	unscheduled := pod("", corev1.ConditionFalse)
+	unscheduled.Status.Conditions = nil
+
+	pods := []corev1.Pod{
+		pod("192.0.2.2", corev1.ConditionTrue),
+		pod("192.0.2.1", corev1.ConditionTrue),
+		pod("192.0.2.2", corev1.ConditionTrue),
+		pod("192.0.2.3", corev1.ConditionFalse),
+		pod("2001:db8::1", corev1.ConditionTrue),
+		deleting,
+		unscheduled,
+	}
+	expect := []string{"192.0.2.1", "192.0.2.2", "2001:db8::1"}
+	if actual := readyHostIPs(pods); !cmp.Equal(actual, expect) {
+		t.Errorf("expected addresses %v, got %v", expect, actual)
+	}
+	expectNodes := []string{"node-192.0.2.1", "node-192.0.2.2", "node-2001:db8::1"}
+	if actual := readyNodeNames(pods); !cmp.Equal(actual, expectNodes) {
+		t.Errorf("expected nodes %v, got %v", expectNodes, actual)
+	}
+}
+
+func TestExternalIPs(t *testing.T) {
+	node := func(addresses ...corev1.NodeAddress) corev1.Node {
+		return corev1.Node{Status: corev1.NodeStatus{Addresses: addresses}}
+	}
+	nodes := []corev1.Node{
+		node(corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.0.0.2"}, corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "198.51.100.2"}),
+		node(corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}, corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "198.51.100.1"}),
+		node(corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.0.0.3"}, corev1.NodeAddress{Type: corev1.NodeHostName, Address: "node-3"}),
+	}
+	expect := []string{"198.51.100.1", "198.51.100.2"}
+	if actual := externalIPs(nodes); !cmp.Equal(actual, expect) {
+		t.Errorf("expected addresses %v, got %v", expect, actual)
+	}
+}
+
+func TestDesiredHostNetworkDNSRecords(t *testing.T) {
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "default",
+			Annotations: map[string]string{HostNetworkDNSAnnotation: "true"},
+		},
+		Status: operatorv1.IngressControllerStatus{
+			Domain: "apps.example.com",
+			EndpointPublishingStrategy: &operatorv1.EndpointPublishingStrategy{
+				Type: operatorv1.HostNetworkStrategyType,
+			},
+		},
+	}
+	if !hasHostNetworkDNS(ic) {
+		t.Fatalf("expected the ingresscontroller to publish the node addresses of its router pods")
+	}
+
+	internal := []string{"10.0.0.1", "fd00::1", "not-an-ip"}
+	external := []string{"198.51.100.1", "2001:db8::1"}
+	expect := []*dns.Record{
+		newARecord("*.apps.example.com", "10.0.0.1", privateZone),
+		newAAAARecord("*.apps.example.com", "fd00::1", privateZone),
+		newARecord("*.apps.example.com", "198.51.100.1", publicZone),
+		newAAAARecord("*.apps.example.com", "2001:db8::1", publicZone),
+	}
+	actual := desiredHostNetworkDNSRecords(ic, &privateZone, &publicZone, internal, external)
+	if !cmp.Equal(actual, expect) {
+		t.Errorf("expected records %v, got %v", expect, actual)
+	}
+
+	// Without external addresses, only the private zone gets records.
+	expect = expect[:2]
+	if actual := desiredHostNetworkDNSRecords(ic, &privateZone, &publicZone, internal, nil); !cmp.Equal(actual, expect) {
+		t.Errorf("expected records %v, got %v", expect, actual)
+	}
+
+	ic.Status.Domain = ""
+	if actual := desiredHostNetworkDNSRecords(ic, &privateZone, &publicZone, internal, external); len(actual) != 0 {
+		t.Errorf("expected no records without a domain, got %v", actual)
+	}
+
+	ic.Status.Domain = "apps.example.com"
+	ic.Annotations[HostNetworkDNSAnnotation] = "false"
+	if hasHostNetworkDNS(ic) {
+		t.Errorf("expected the annotation to be required")
+	}
+}
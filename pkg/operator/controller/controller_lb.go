@@ -14,22 +14,53 @@ import (
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 )
 
 const (
-	// loadBalancerServiceFinalizer is applied to load balancer services to ensure
-	// we can manage deletion of associated DNS records.
+	// loadBalancerServiceFinalizer was applied to load balancer services to
+	// ensure we could manage deletion of associated DNS records. DNS records
+	// are now tracked by DNSRecord resources, but the finalizer is still
+	// removed from services that have it.
 	loadBalancerServiceFinalizer = "ingress.openshift.io/operator"
 
 	// awsLBProxyProtocolAnnotation is used to enable the PROXY protocol on any
 	// AWS load balancer services created.
 	awsLBProxyProtocolAnnotation = "service.beta.kubernetes.io/aws-load-balancer-proxy-protocol"
+
+	// awsInternalLBAnnotation is used to request an internal load balancer
+	// on AWS.
+	awsInternalLBAnnotation = "service.beta.kubernetes.io/aws-load-balancer-internal"
+
+	// azureInternalLBAnnotation is used to request an internal load
+	// balancer on Azure.
+	azureInternalLBAnnotation = "service.beta.kubernetes.io/azure-load-balancer-internal"
+
+	// gcpLBTypeAnnotation is used to request an internal load balancer on
+	// GCP.
+	gcpLBTypeAnnotation = "cloud.google.com/load-balancer-type"
+
+	// InternalLoadBalancerAnnotation is an annotation on an
+	// ingresscontroller that, if "true", makes the operator publish the
+	// ingresscontroller through an internal load balancer in addition to
+	// the external one. The DNS records in the private zone then point at
+	// the internal load balancer, and those in the public zone at the
+	// external load balancer.
+	InternalLoadBalancerAnnotation = "ingress.operator.openshift.io/internal-load-balancer"
 )
 
+// internalLBAnnotations are the service annotations that request an internal
+// load balancer on each platform that supports one.
+var internalLBAnnotations = map[configv1.PlatformType]map[string]string{
+	configv1.AWSPlatformType:   {awsInternalLBAnnotation: "0.0.0.0/0"},
+	configv1.AzurePlatformType: {azureInternalLBAnnotation: "true"},
+	configv1.GCPPlatformType:   {gcpLBTypeAnnotation: "Internal"},
+}
+
 // ensureLoadBalancerService creates an LB service if one is desired but absent.
 // Always returns the current LB service if one exists (whether it already
-// existed or was created during the course of the function).
+// existed or was created during the course of the function). A service with
+// the same name that the ingresscontroller's deployment doesn't own is an
+// error.
 func (r *reconciler) ensureLoadBalancerService(ci *operatorv1.IngressController, deploymentRef metav1.OwnerReference, infraConfig *configv1.Infrastructure) (*corev1.Service, error) {
 	desiredLBService, err := desiredLoadBalancerService(ci, deploymentRef, infraConfig)
 	if err != nil {
@@ -40,6 +71,9 @@ func (r *reconciler) ensureLoadBalancerService(ci *operatorv1.IngressController,
 	if err != nil {
 		return nil, err
 	}
+	if desiredLBService != nil && currentLBService != nil && !isOwnedBy(currentLBService, deploymentRef) {
+		return nil, fmt.Errorf("load balancer service %s/%s exists but is not owned by deployment %s", currentLBService.Namespace, currentLBService.Name, deploymentRef.Name)
+	}
 	if desiredLBService != nil && currentLBService == nil {
 		if err := r.client.Create(context.TODO(), desiredLBService); err != nil {
 			return nil, fmt.Errorf("failed to create load balancer service %s/%s: %v", desiredLBService.Namespace, desiredLBService.Name, err)
@@ -84,6 +118,102 @@ func desiredLoadBalancerService(ci *operatorv1.IngressController, deploymentRef
 	return service, nil
 }
 
+// ensureInternalLoadBalancerService creates an internal LB service if one is
+// desired but absent, and deletes it if it is present but no longer desired.
+// Returns the current internal LB service, or nil if none is desired. A service
+// with the same name that the ingresscontroller's deployment doesn't own is
+// neither deleted nor used.
+func (r *reconciler) ensureInternalLoadBalancerService(ci *operatorv1.IngressController, deploymentRef metav1.OwnerReference, infraConfig *configv1.Infrastructure) (*corev1.Service, error) {
+	desired, err := desiredInternalLoadBalancerService(ci, deploymentRef, infraConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := r.currentInternalLoadBalancerService(ci)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && !isOwnedBy(current, deploymentRef) {
+		if desired == nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("internal load balancer service %s/%s exists but is not owned by deployment %s", current.Namespace, current.Name, deploymentRef.Name)
+	}
+	switch {
+	case desired == nil && current != nil:
+		if err := r.client.Delete(context.TODO(), current); err != nil && !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to delete internal load balancer service %s/%s: %v", current.Namespace, current.Name, err)
+		}
+		log.Info("deleted internal load balancer service", "namespace", current.Namespace, "name", current.Name)
+		return nil, nil
+	case desired != nil && current == nil:
+		if err := r.client.Create(context.TODO(), desired); err != nil {
+			return nil, fmt.Errorf("failed to create internal load balancer service %s/%s: %v", desired.Namespace, desired.Name, err)
+		}
+		log.Info("created internal load balancer service", "namespace", desired.Namespace, "name", desired.Name)
+		return desired, nil
+	case desired == nil:
+		return nil, nil
+	}
+	return current, nil
+}
+
+// isOwnedBy returns whether the given object has the given owner.
+func isOwnedBy(object metav1.Object, owner metav1.OwnerReference) bool {
+	for _, ref := range object.GetOwnerReferences() {
+		if ref.UID == owner.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// desiredInternalLoadBalancerService returns the desired internal LB service
+// for an ingresscontroller, or nil if an internal LB service isn't desired. An
+// internal LB service is desired if the ingresscontroller has an LB service and
+// InternalLoadBalancerAnnotation is "true". An error is returned if the
+// platform doesn't support internal load balancers.
+func desiredInternalLoadBalancerService(ci *operatorv1.IngressController, deploymentRef metav1.OwnerReference, infraConfig *configv1.Infrastructure) (*corev1.Service, error) {
+	if ci.Annotations[InternalLoadBalancerAnnotation] != "true" {
+		return nil, nil
+	}
+	service, err := desiredLoadBalancerService(ci, deploymentRef, infraConfig)
+	if err != nil || service == nil {
+		return service, err
+	}
+	annotations, ok := internalLBAnnotations[infraConfig.Status.Platform]
+	if !ok {
+		return nil, fmt.Errorf("internal load balancers are not supported on platform %q", infraConfig.Status.Platform)
+	}
+
+	name := InternalLoadBalancerServiceName(ci)
+	service.Name = name.Name
+	service.Labels["router"] = name.Name
+	if service.Annotations == nil {
+		service.Annotations = map[string]string{}
+	}
+	for k, v := range annotations {
+		service.Annotations[k] = v
+	}
+	// DNS records for the service are tracked by the ingresscontroller's
+	// DNSRecord, so the legacy finalizer is not needed.
+	service.Finalizers = nil
+	return service, nil
+}
+
+// currentInternalLoadBalancerService returns any existing internal LB service
+// for the ingresscontroller.
+func (r *reconciler) currentInternalLoadBalancerService(ci *operatorv1.IngressController) (*corev1.Service, error) {
+	service := &corev1.Service{}
+	if err := r.client.Get(context.TODO(), InternalLoadBalancerServiceName(ci), service); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return service, nil
+}
+
 // currentLoadBalancerService returns any existing LB service for the
 // ingresscontroller.
 func (r *reconciler) currentLoadBalancerService(ci *operatorv1.IngressController) (*corev1.Service, error) {
@@ -97,10 +227,10 @@ func (r *reconciler) currentLoadBalancerService(ci *operatorv1.IngressController
 	return service, nil
 }
 
-// finalizeLoadBalancerService deletes any DNS entries associated with any
-// current LB service associated with the ingresscontroller and then finalizes the
-// service.
-func (r *reconciler) finalizeLoadBalancerService(ci *operatorv1.IngressController, dnsConfig *configv1.DNS) error {
+// finalizeLoadBalancerService removes the finalizer from any current LB
+// service associated with the ingresscontroller. DNS records for the service
+// are deleted by way of the ingresscontroller's DNSRecord.
+func (r *reconciler) finalizeLoadBalancerService(ci *operatorv1.IngressController) error {
 	service, err := r.currentLoadBalancerService(ci)
 	if err != nil {
 		return err
@@ -108,28 +238,6 @@ func (r *reconciler) finalizeLoadBalancerService(ci *operatorv1.IngressControlle
 	if service == nil {
 		return nil
 	}
-	// We cannot published DNS records for a load balancer till it has been
-	// provisioned.  Thus if the service's status does not _currently_
-	// indicate that a load balancer has been provisioned, that means we
-	// _probably_ have not published any DNS records (and if we have, then
-	// we have lost track of them).
-	//
-	// TODO: Instead of trying to infer whether DNS records exist by looking
-	// at the service, we should be maintaining state with any DNS records
-	// that we have created for the ingresscontroller, for example by using
-	// an annotation on the ingresscontroller.
-	records := desiredDNSRecords(ci, dnsConfig, service)
-	dnsErrors := []error{}
-	for _, record := range records {
-		if err := r.DNSManager.Delete(record); err != nil {
-			dnsErrors = append(dnsErrors, fmt.Errorf("failed to delete DNS record %v for ingress %s/%s: %v", record, ci.Namespace, ci.Name, err))
-		} else {
-			log.Info("deleted DNS record for ingress", "namespace", ci.Namespace, "name", ci.Name, "record", record)
-		}
-	}
-	if err := utilerrors.NewAggregate(dnsErrors); err != nil {
-		return err
-	}
 	// Mutate a copy to avoid assuming we know where the current one came from
 	// (i.e. it could have been from a cache).
 	updated := service.DeepCopy()
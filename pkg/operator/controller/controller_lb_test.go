@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fakeServiceClient is a client that stores services in memory. Its other
+// methods are not implemented.
+type fakeServiceClient struct {
+	client.Client
+	services map[types.NamespacedName]*corev1.Service
+}
+
+func (c *fakeServiceClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	service, ok := c.services[key]
+	if !ok {
+		return errors.NewNotFound(corev1.Resource("services"), key.Name)
+	}
+	service.DeepCopyInto(obj.(*corev1.Service))
+	return nil
+}
+
+func (c *fakeServiceClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOptionFunc) error {
+	service := obj.(*corev1.Service)
+	key := types.NamespacedName{Namespace: service.Namespace, Name: service.Name}
+	if _, ok := c.services[key]; ok {
+		return errors.NewAlreadyExists(corev1.Resource("services"), key.Name)
+	}
+	c.services[key] = service.DeepCopy()
+	return nil
+}
+
+func (c *fakeServiceClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOptionFunc) error {
+	service := obj.(*corev1.Service)
+	key := types.NamespacedName{Namespace: service.Namespace, Name: service.Name}
+	if _, ok := c.services[key]; !ok {
+		return errors.NewNotFound(corev1.Resource("services"), key.Name)
+	}
+	c.services[key] = service.DeepCopy()
+	return nil
+}
+
+func (c *fakeServiceClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOptionFunc) error {
+	service := obj.(*corev1.Service)
+	key := types.NamespacedName{Namespace: service.Namespace, Name: service.Name}
+	if _, ok := c.services[key]; !ok {
+		return errors.NewNotFound(corev1.Resource("services"), key.Name)
+	}
+	delete(c.services, key)
+	return nil
+}
+
+func TestLoadBalancerServicesOfCollidingIngressControllers(t *testing.T) {
+	cl := &fakeServiceClient{services: map[types.NamespacedName]*corev1.Service{}}
+	r := &reconciler{client: cl}
+	infraConfig := &configv1.Infrastructure{Status: configv1.InfrastructureStatus{Platform: configv1.AWSPlatformType}}
+	newIngressController := func(name, uid string) (*operatorv1.IngressController, metav1.OwnerReference) {
+		ic := ingressController(name, operatorv1.LoadBalancerServiceStrategyType)
+		ic.UID = types.UID(uid)
+		ic.Annotations = map[string]string{InternalLoadBalancerAnnotation: "true"}
+		return ic, metav1.OwnerReference{Kind: "Deployment", Name: "router-" + name, UID: types.UID("deployment-" + uid)}
+	}
+
+	foo, fooRef := newIngressController("foo", "1")
+	if _, err := r.ensureLoadBalancerService(foo, fooRef, infraConfig); err != nil {
+		t.Fatalf("failed to ensure load balancer service of foo: %v", err)
+	}
+	internal, err := r.ensureInternalLoadBalancerService(foo, fooRef, infraConfig)
+	if err != nil || internal == nil {
+		t.Fatalf("failed to ensure internal load balancer service of foo: %v", err)
+	}
+
+	// An ingresscontroller named after foo's internal load balancer
+	// service doesn't get foo's service.
+	other, otherRef := newIngressController("foo-internal-lb", "2")
+	if _, err := r.ensureLoadBalancerService(other, otherRef, infraConfig); err != nil {
+		t.Fatalf("failed to ensure load balancer service of %s: %v", other.Name, err)
+	}
+	if _, err := r.ensureInternalLoadBalancerService(other, otherRef, infraConfig); err != nil {
+		t.Fatalf("failed to ensure internal load balancer service of %s: %v", other.Name, err)
+	}
+	if len(cl.services) != 4 {
+		t.Errorf("expected 4 services, got %d", len(cl.services))
+	}
+
+	// An ingresscontroller whose load balancer service has the name of
+	// foo's internal load balancer service neither uses nor changes it.
+	colliding, collidingRef := newIngressController("internal-lb-1", "3")
+	if service, err := r.ensureLoadBalancerService(colliding, collidingRef, infraConfig); err == nil {
+		t.Errorf("expected an error for the service of another ingresscontroller, got %v", service)
+	}
+	current := cl.services[InternalLoadBalancerServiceName(foo)]
+	if current == nil || !isOwnedBy(current, fooRef) || current.Annotations[awsInternalLBAnnotation] == "" {
+		t.Errorf("expected foo's internal load balancer service to be unchanged, got %v", current)
+	}
+
+	// Nor does an ingresscontroller whose internal load balancer service
+	// has the name of another ingresscontroller's service delete it.
+	fooLB := LoadBalancerServiceName(foo)
+	cl.services[InternalLoadBalancerServiceName(colliding)] = cl.services[fooLB].DeepCopy()
+	cl.services[InternalLoadBalancerServiceName(colliding)].Name = InternalLoadBalancerServiceName(colliding).Name
+	colliding.Annotations = nil
+	if service, err := r.ensureInternalLoadBalancerService(colliding, collidingRef, infraConfig); err != nil || service != nil {
+		t.Errorf("expected no internal load balancer service, got %v, %v", service, err)
+	}
+	if _, ok := cl.services[InternalLoadBalancerServiceName(colliding)]; !ok {
+		t.Errorf("expected the service of another ingresscontroller not to be deleted")
+	}
+}
@@ -0,0 +1,436 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	iov1 "github.com/openshift/cluster-ingress-operator/pkg/api/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"k8s.io/client-go/util/workqueue"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// DNSRecordsAnnotation is an annotation on an ingresscontroller that
+	// selects which DNS records the operator publishes for the
+	// ingresscontroller. The value is "Wildcard", the default, for a
+	// wildcard record for the ingresscontroller's domain, or "PerRoute" for
+	// a record for the host of every route that the ingresscontroller has
+	// admitted, so that names without a route don't resolve. Only route
+	// hosts in the ingresscontroller's domain get records. The records
+	// for route hosts are spread across routeDNSRecordShards DNSRecords.
+	DNSRecordsAnnotation = "ingress.operator.openshift.io/dns-records"
+
+	// routeDNSRecordShards is the number of DNSRecords across which the
+	// records for the route hosts of an ingresscontroller are spread by a
+	// hash of the host, so that no DNSRecord grows beyond the size limit
+	// of a resource on clusters with many routes, and a route change only
+	// republishes the records of one DNSRecord.
+	routeDNSRecordShards = 16
+
+	// routeEventDelay is how long the operator waits after a route or
+	// namespace changes before it updates the DNS records of the
+	// ingresscontrollers that publish a record per route. Changes during
+	// the delay are handled together, which limits how often the records
+	// are updated on clusters with many routes.
+	routeEventDelay = 10 * time.Second
+)
+
+// dnsRecordMode is a value of DNSRecordsAnnotation.
+type dnsRecordMode string
+
+const (
+	wildcardDNSRecordMode dnsRecordMode = "Wildcard"
+	perRouteDNSRecordMode dnsRecordMode = "PerRoute"
+)
+
+// dnsRecordModeFor returns the DNS record mode that the given ingresscontroller
+// selects. An error is returned if the annotation is invalid.
+func dnsRecordModeFor(ci *operatorv1.IngressController) (dnsRecordMode, error) {
+	mode := wildcardDNSRecordMode
+	if value, ok := ci.Annotations[DNSRecordsAnnotation]; ok {
+		mode = dnsRecordMode(value)
+	}
+	switch mode {
+	case wildcardDNSRecordMode, perRouteDNSRecordMode:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid %s annotation: %q is not one of %q or %q", DNSRecordsAnnotation, mode, wildcardDNSRecordMode, perRouteDNSRecordMode)
+}
+
+// routeDNSHosts returns the hosts of the routes of the given ingresscontroller
+// that need DNS records.
+func (r *reconciler) routeDNSHosts(ci *operatorv1.IngressController) ([]string, error) {
+	if r.clusterCache == nil || r.routeDNSController == nil {
+		return nil, fmt.Errorf("routes can't be watched")
+	}
+	if err := r.ensureRouteWatches(); err != nil {
+		return nil, err
+	}
+	routes := &routev1.RouteList{}
+	if err := r.clusterCache.List(context.TODO(), routes); err != nil {
+		return nil, fmt.Errorf("failed to list routes: %v", err)
+	}
+	namespaces := &corev1.NamespaceList{}
+	if err := r.clusterCache.List(context.TODO(), namespaces); err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %v", err)
+	}
+	return routeHosts(ci, routes.Items, namespaces.Items)
+}
+
+// routeHosts returns the sorted DNS names of the hosts of the given routes that
+// the ingresscontroller has admitted and that its route and namespace
+// selectors select, limited to the ingresscontroller's domain. A route with
+// the Subdomain wildcard policy yields a wildcard name for the host's parent
+// domain.
+func routeHosts(ci *operatorv1.IngressController, routes []routev1.Route, namespaces []corev1.Namespace) ([]string, error) {
+	routeSelector := labels.Everything()
+	if ci.Spec.RouteSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(ci.Spec.RouteSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid route selector: %v", err)
+		}
+		routeSelector = selector
+	}
+	namespaceSelector := labels.Everything()
+	if ci.Spec.NamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(ci.Spec.NamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespace selector: %v", err)
+		}
+		namespaceSelector = selector
+	}
+	namespaceLabels := map[string]labels.Set{}
+	for _, ns := range namespaces {
+		namespaceLabels[ns.Name] = labels.Set(ns.Labels)
+	}
+
+	suffix := "." + strings.ToLower(ci.Status.Domain)
+	seen := map[string]bool{}
+	hosts := []string{}
+	for _, route := range routes {
+		if !routeSelector.Matches(labels.Set(route.Labels)) {
+			continue
+		}
+		if nsLabels, ok := namespaceLabels[route.Namespace]; !ok || !namespaceSelector.Matches(nsLabels) {
+			continue
+		}
+		for _, ingress := range route.Status.Ingress {
+			if ingress.RouterName != ci.Name || !isAdmitted(ingress) {
+				continue
+			}
+			host := strings.ToLower(strings.TrimSuffix(ingress.Host, "."))
+			if ingress.WildcardPolicy == routev1.WildcardPolicySubdomain {
+				if i := strings.Index(host, "."); i >= 0 {
+					host = "*" + host[i:]
+				}
+			}
+			if !strings.HasSuffix(host, suffix) || seen[host] {
+				continue
+			}
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts, nil
+}
+
+// isAdmitted returns true if the router has admitted the route.
+func isAdmitted(ingress routev1.RouteIngress) bool {
+	for _, cond := range ingress.Conditions {
+		if cond.Type == routev1.RouteAdmitted {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// recordsForDomains returns a copy of every one of the given records for every
+// one of the given domains.
+func recordsForDomains(records []*dns.Record, domains []string) []*dns.Record {
+	result := []*dns.Record{}
+	for _, domain := range domains {
+		for _, record := range records {
+			r := *record
+			switch {
+			case r.Alias != nil:
+				r.Alias = &dns.AliasRecord{Domain: domain, Target: r.Alias.Target}
+			case r.ARecord != nil:
+				r.ARecord = &dns.ARecord{Domain: domain, Address: r.ARecord.Address}
+			case r.AAAARecord != nil:
+				r.AAAARecord = &dns.AAAARecord{Domain: domain, Address: r.AAAARecord.Address}
+			case r.CNAMERecord != nil:
+				r.CNAMERecord = &dns.CNAMERecord{Domain: domain, Target: r.CNAMERecord.Target}
+			}
+			result = append(result, &r)
+		}
+	}
+	return result
+}
+
+// routeDNSRecordShard returns the shard of the DNSRecord that holds the records
+// for the given route host.
+func routeDNSRecordShard(host string) int {
+	h := fnv.New32a()
+	h.Write([]byte(host))
+	return int(h.Sum32() % routeDNSRecordShards)
+}
+
+// desiredRouteDNSRecords returns the desired DNSRecords for the given route
+// hosts of the ingresscontroller, which have a copy of the records for the
+// ingresscontroller's canonical hostname in the given wildcard DNSRecord for
+// every host. Only shards with hosts have a DNSRecord.
+func desiredRouteDNSRecords(ci *operatorv1.IngressController, wildcard *iov1.DNSRecord, hosts []string) []*iov1.DNSRecord {
+	canonicalHostname := CanonicalHostname(ci)
+	records := []iov1.Record{}
+	for _, record := range wildcard.Spec.Records {
+		if strings.EqualFold(record.Domain, canonicalHostname) {
+			records = append(records, record)
+		}
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	shards := map[int]*iov1.DNSRecord{}
+	for _, host := range hosts {
+		if strings.EqualFold(host, canonicalHostname) {
+			continue
+		}
+		shard := routeDNSRecordShard(host)
+		dnsRecord, ok := shards[shard]
+		if !ok {
+			dnsRecord = newDNSRecord(ci, RouteDNSRecordName(ci, shard))
+			shards[shard] = dnsRecord
+		}
+		for _, record := range records {
+			record.Domain = host
+			dnsRecord.Spec.Records = append(dnsRecord.Spec.Records, record)
+		}
+	}
+	result := []*iov1.DNSRecord{}
+	for shard := 0; shard < routeDNSRecordShards; shard++ {
+		if dnsRecord, ok := shards[shard]; ok {
+			result = append(result, dnsRecord)
+		}
+	}
+	return result
+}
+
+// ensureRouteDNSRecords ensures that the DNSRecords for the route hosts of the
+// given ingresscontroller have the records for its canonical hostname in the
+// given wildcard DNSRecord for every route host, if the ingresscontroller
+// publishes a record per route, and that there are no such DNSRecords
+// otherwise. It returns the current DNSRecords for route hosts.
+func (r *reconciler) ensureRouteDNSRecords(ci *operatorv1.IngressController, wildcard *iov1.DNSRecord) ([]iov1.DNSRecord, error) {
+	current, err := r.currentRouteDNSRecords(ci)
+	if err != nil {
+		return nil, err
+	}
+	desired := map[string]*iov1.DNSRecord{}
+	if mode, _ := dnsRecordModeFor(ci); mode == perRouteDNSRecordMode {
+		hosts, err := r.routeDNSHosts(ci)
+		if err != nil {
+			return current, err
+		}
+		for _, record := range desiredRouteDNSRecords(ci, wildcard, hosts) {
+			desired[record.Name] = record
+		}
+	}
+
+	errs := []error{}
+	result := []iov1.DNSRecord{}
+	for i := range current {
+		record := &current[i]
+		want, ok := desired[record.Name]
+		delete(desired, record.Name)
+		switch {
+		case !ok:
+			if record.DeletionTimestamp != nil {
+				continue
+			}
+			if err := r.client.Delete(context.TODO(), record); err != nil && !errors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("failed to delete dnsrecord %s/%s: %v", record.Namespace, record.Name, err))
+				result = append(result, *record)
+				continue
+			}
+			log.Info("deleted dnsrecord", "namespace", record.Namespace, "name", record.Name)
+		case !dnsRecordSpecsEqual(record.Spec, want.Spec):
+			updated := record.DeepCopy()
+			updated.Spec = want.Spec
+			if err := r.client.Update(context.TODO(), updated); err != nil {
+				errs = append(errs, fmt.Errorf("failed to update dnsrecord %s/%s: %v", updated.Namespace, updated.Name, err))
+				result = append(result, *record)
+				continue
+			}
+			log.Info("updated dnsrecord", "namespace", updated.Namespace, "name", updated.Name)
+			result = append(result, *updated)
+		default:
+			result = append(result, *record)
+		}
+	}
+	for shard := 0; shard < routeDNSRecordShards; shard++ {
+		record, ok := desired[RouteDNSRecordName(ci, shard).Name]
+		if !ok {
+			continue
+		}
+		if err := r.client.Create(context.TODO(), record); err != nil {
+			errs = append(errs, fmt.Errorf("failed to create dnsrecord %s/%s: %v", record.Namespace, record.Name, err))
+			continue
+		}
+		log.Info("created dnsrecord", "namespace", record.Namespace, "name", record.Name)
+		result = append(result, *record)
+	}
+	return result, utilerrors.NewAggregate(errs)
+}
+
+// currentRouteDNSRecords returns the current DNSRecords for the route hosts of
+// the given ingresscontroller.
+func (r *reconciler) currentRouteDNSRecords(ci *operatorv1.IngressController) ([]iov1.DNSRecord, error) {
+	records := &iov1.DNSRecordList{}
+	if err := r.client.List(context.TODO(), records, client.InNamespace(ci.Namespace), client.MatchingLabels(map[string]string{manifests.OwningIngressControllerLabel: ci.Name})); err != nil {
+		return nil, fmt.Errorf("failed to list dnsrecords for ingresscontroller %s: %v", ci.Name, err)
+	}
+	names := map[string]bool{}
+	for shard := 0; shard < routeDNSRecordShards; shard++ {
+		names[RouteDNSRecordName(ci, shard).Name] = true
+	}
+	current := []iov1.DNSRecord{}
+	for _, record := range records.Items {
+		if names[record.Name] {
+			current = append(current, record)
+		}
+	}
+	return current, nil
+}
+
+// combinedDNSRecord returns a DNSRecord that has the records and zone statuses
+// of the given wildcard DNSRecord and DNSRecords for route hosts, for computing
+// the ingresscontroller's DNS status. It is pending unless every one of them
+// is up to date, and a zone has a condition if any of them has it. Returns
+// nil if wildcard is nil.
+func combinedDNSRecord(wildcard *iov1.DNSRecord, routeRecords []iov1.DNSRecord) *iov1.DNSRecord {
+	if wildcard == nil || len(routeRecords) == 0 {
+		return wildcard
+	}
+	combined := wildcard.DeepCopy()
+	for _, record := range routeRecords {
+		combined.Spec.Records = append(combined.Spec.Records, record.Spec.Records...)
+		if record.Status.ObservedGeneration != record.Generation {
+			combined.Status.ObservedGeneration = combined.Generation - 1
+		}
+		for _, zone := range record.Status.Zones {
+			i := 0
+			for i < len(combined.Status.Zones) && !cmp.Equal(combined.Status.Zones[i].DNSZone, zone.DNSZone, cmpopts.EquateEmpty()) {
+				i++
+			}
+			if i == len(combined.Status.Zones) {
+				combined.Status.Zones = append(combined.Status.Zones, iov1.DNSZoneStatus{DNSZone: zone.DNSZone})
+			}
+			for _, cond := range zone.Conditions {
+				if cond.Status == string(operatorv1.ConditionTrue) {
+					combined.Status.Zones[i].Conditions = append(combined.Status.Zones[i].Conditions, cond)
+				}
+			}
+		}
+	}
+	return combined
+}
+
+// reconcileRouteDNS updates the DNSRecords for the route hosts of the
+// ingresscontroller in the request from its current wildcard DNSRecord. Route
+// and namespace events are reconciled here rather than by Reconcile, so that
+// they don't cause every resource of the ingresscontroller to be ensured.
+func (r *reconciler) reconcileRouteDNS(request reconcile.Request) (reconcile.Result, error) {
+	ci := &operatorv1.IngressController{}
+	if err := r.cache.Get(context.TODO(), request.NamespacedName, ci); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("failed to get ingresscontroller %q: %v", request, err)
+	}
+	if ci.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+	wildcard, err := r.currentWildcardDNSRecord(ci)
+	if err != nil || wildcard == nil {
+		// Reconcile creates the DNSRecords along with the wildcard
+		// DNSRecord.
+		return reconcile.Result{}, err
+	}
+	if _, err := r.ensureRouteDNSRecords(ci, wildcard); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to ensure DNS records for routes of %s: %v", ci.Name, err)
+	}
+	return reconcile.Result{}, nil
+}
+
+// enqueueRequestsForPerRouteIngressControllers returns an event handler that
+// queues a request for every ingresscontroller that publishes a record per
+// route after routeEventDelay. A request that is already waiting isn't
+// delayed further, so a burst of events yields a single reconciliation.
+func (r *reconciler) enqueueRequestsForPerRouteIngressControllers() handler.EventHandler {
+	enqueue := func(q workqueue.RateLimitingInterface) {
+		ingresses := &operatorv1.IngressControllerList{}
+		if err := r.cache.List(context.TODO(), ingresses, client.InNamespace(r.Namespace)); err != nil {
+			log.Error(err, "failed to list ingresscontrollers")
+			return
+		}
+		for _, ingress := range ingresses.Items {
+			if mode, _ := dnsRecordModeFor(&ingress); mode != perRouteDNSRecordMode {
+				continue
+			}
+			q.AddAfter(reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name},
+			}, routeEventDelay)
+		}
+	}
+	return handler.Funcs{
+		CreateFunc: func(e event.CreateEvent, q workqueue.RateLimitingInterface) { enqueue(q) },
+		UpdateFunc: func(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+			if routeDNSChanged(e.ObjectOld, e.ObjectNew) {
+				enqueue(q)
+			}
+		},
+		DeleteFunc: func(e event.DeleteEvent, q workqueue.RateLimitingInterface) { enqueue(q) },
+	}
+}
+
+// routeDNSChanged returns true if an update of a route or namespace may change
+// the DNS records of ingresscontrollers that publish a record per route, which
+// depend on the labels of both and on the hosts and admission status of
+// routes.
+func routeDNSChanged(old, new interface{}) bool {
+	switch o := old.(type) {
+	case *routev1.Route:
+		n, ok := new.(*routev1.Route)
+		return !ok || !cmp.Equal(o.Labels, n.Labels, cmpopts.EquateEmpty()) || !cmp.Equal(o.Status.Ingress, n.Status.Ingress, cmpopts.EquateEmpty(), cmpopts.IgnoreFields(routev1.RouteIngressCondition{}, "LastTransitionTime"))
+	case *corev1.Namespace:
+		n, ok := new.(*corev1.Namespace)
+		return !ok || !cmp.Equal(o.Labels, n.Labels, cmpopts.EquateEmpty())
+	}
+	return true
+}
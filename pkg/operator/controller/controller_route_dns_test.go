@@ -0,0 +1,249 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	iov1 "github.com/openshift/cluster-ingress-operator/pkg/api/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+
+	corev1 "k8s.io/api/core/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRouteHosts(t *testing.T) {
+	route := func(namespace, name string, labels map[string]string, ingresses ...routev1.RouteIngress) routev1.Route {
+		return routev1.Route{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+			Status:     routev1.RouteStatus{Ingress: ingresses},
+		}
+	}
+	admitted := func(router, host string) routev1.RouteIngress {
+		return routev1.RouteIngress{
+			Host:       host,
+			RouterName: router,
+			Conditions: []routev1.RouteIngressCondition{{Type: routev1.RouteAdmitted, Status: corev1.ConditionTrue}},
+		}
+	}
+	rejected := func(router, host string) routev1.RouteIngress {
+		return routev1.RouteIngress{
+			Host:       host,
+			RouterName: router,
+			Conditions: []routev1.RouteIngressCondition{{Type: routev1.RouteAdmitted, Status: corev1.ConditionFalse}},
+		}
+	}
+	wildcard := admitted("default", "wildcard.shop.apps.example.com")
+	wildcard.WildcardPolicy = routev1.WildcardPolicySubdomain
+
+	namespaces := []corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "app", Labels: map[string]string{"shard": "a"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+	}
+	routes := []routev1.Route{
+		route("app", "web", map[string]string{"type": "public"}, admitted("default", "web.apps.example.com"), admitted("sharded", "web.apps.example.com")),
+		route("app", "api", nil, admitted("default", "API.apps.example.com.")),
+		route("app", "dup", nil, admitted("default", "web.apps.example.com")),
+		route("app", "shop", nil, wildcard),
+		route("app", "rejected", nil, rejected("default", "rejected.apps.example.com")),
+		route("app", "vanity", nil, admitted("default", "www.example.org")),
+		route("other", "db", nil, admitted("default", "db.apps.example.com")),
+		route("missing", "orphan", nil, admitted("default", "orphan.apps.example.com")),
+	}
+
+	tests := []struct {
+		description       string
+		routeSelector     *metav1.LabelSelector
+		namespaceSelector *metav1.LabelSelector
+		expect            []string
+	}{
+		{
+			description: "no selectors",
+			expect:      []string{"*.shop.apps.example.com", "api.apps.example.com", "db.apps.example.com", "web.apps.example.com"},
+		},
+		{
+			description:   "route selector",
+			routeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"type": "public"}},
+			expect:        []string{"web.apps.example.com"},
+		},
+		{
+			description:       "namespace selector",
+			namespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"shard": "a"}},
+			expect:            []string{"*.shop.apps.example.com", "api.apps.example.com", "web.apps.example.com"},
+		},
+	}
+	for _, test := range tests {
+		ic := &operatorv1.IngressController{
+			ObjectMeta: metav1.ObjectMeta{Name: "default"},
+			Spec: operatorv1.IngressControllerSpec{
+				RouteSelector:     test.routeSelector,
+				NamespaceSelector: test.namespaceSelector,
+			},
+			Status: operatorv1.IngressControllerStatus{Domain: "apps.example.com"},
+		}
+		hosts, err := routeHosts(ic, routes, namespaces)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.description, err)
+			continue
+		}
+		if !cmp.Equal(hosts, test.expect) {
+			t.Errorf("%s: expected hosts %v, got %v", test.description, test.expect, hosts)
+		}
+	}
+}
+
+func TestRecordsForDomains(t *testing.T) {
+	records := []*dns.Record{
+		newAliasRecord("*.apps.example.com", "lb.example.com", privateZone),
+		newARecord("*.apps.example.com", "192.0.2.1", publicZone),
+	}
+	expect := []*dns.Record{
+		newAliasRecord("api.apps.example.com", "lb.example.com", privateZone),
+		newARecord("api.apps.example.com", "192.0.2.1", publicZone),
+		newAliasRecord("web.apps.example.com", "lb.example.com", privateZone),
+		newARecord("web.apps.example.com", "192.0.2.1", publicZone),
+	}
+	actual := recordsForDomains(records, []string{"api.apps.example.com", "web.apps.example.com"})
+	if !cmp.Equal(actual, expect) {
+		t.Errorf("expected records %v, got %v", expect, actual)
+	}
+	if records[0].Alias.Domain != "*.apps.example.com" {
+		t.Errorf("expected the original records to be left alone, got %v", records[0])
+	}
+}
+
+func TestDesiredRouteDNSRecords(t *testing.T) {
+	ci := ingressController("default", operatorv1.LoadBalancerServiceStrategyType)
+	ci.Status.Domain = "apps.example.com"
+	canonicalHostname := CanonicalHostname(ci)
+	wildcard := desiredWildcardDNSRecord(ci, &publicZone, nil, []string{canonicalHostname}, []*dns.Record{
+		newAliasRecord("*.apps.example.com", "lb.example.com", privateZone),
+		newAliasRecord("*.apps.example.com", "lb.example.com", publicZone),
+	})
+	hosts := []string{canonicalHostname}
+	for i := 0; i < 1000; i++ {
+		hosts = append(hosts, fmt.Sprintf("route-%d.apps.example.com", i))
+	}
+
+	records := desiredRouteDNSRecords(ci, wildcard, hosts)
+	if len(records) != routeDNSRecordShards {
+		t.Fatalf("expected %d dnsrecords, got %d", routeDNSRecordShards, len(records))
+	}
+	seen := map[string]bool{}
+	for _, record := range records {
+		if len(record.Spec.Records) > 4*len(hosts)/routeDNSRecordShards {
+			t.Errorf("expected the hosts to be spread across dnsrecords, got %d records in %s", len(record.Spec.Records), record.Name)
+		}
+		for _, rec := range record.Spec.Records {
+			if record.Name != RouteDNSRecordName(ci, routeDNSRecordShard(rec.Domain)).Name {
+				t.Errorf("expected the record for %s not to be in %s", rec.Domain, record.Name)
+			}
+			if rec.Target != "lb.example.com" || rec.Type != iov1.ALIASRecordType {
+				t.Errorf("expected an alias record for the load balancer, got %v", rec)
+			}
+			seen[rec.Domain] = true
+		}
+	}
+	if seen[canonicalHostname] || len(seen) != len(hosts)-1 {
+		t.Errorf("expected records for every route host but the canonical hostname, got %d hosts", len(seen))
+	}
+
+	// Every host is added to the same dnsrecord every time.
+	again := desiredRouteDNSRecords(ci, wildcard, hosts[:len(hosts)-1])
+	last := RouteDNSRecordName(ci, routeDNSRecordShard(hosts[len(hosts)-1])).Name
+	for i := range again {
+		if again[i].Name != last && !cmp.Equal(again[i], records[i]) {
+			t.Errorf("expected dnsrecord %s to be unchanged", again[i].Name)
+		}
+	}
+
+	if records := desiredRouteDNSRecords(ci, wildcard, nil); len(records) != 0 {
+		t.Errorf("expected no dnsrecords without routes, got %d", len(records))
+	}
+}
+
+func TestCombinedDNSRecord(t *testing.T) {
+	zoneStatus := func(zone configv1.DNSZone, conditionType string, status operatorv1.ConditionStatus) iov1.DNSZoneStatus {
+		return iov1.DNSZoneStatus{
+			DNSZone:    zone,
+			Conditions: []iov1.DNSZoneCondition{{Type: conditionType, Status: string(status)}},
+		}
+	}
+	dnsRecord := func(generation, observedGeneration int64, zones ...iov1.DNSZoneStatus) iov1.DNSRecord {
+		return iov1.DNSRecord{
+			ObjectMeta: metav1.ObjectMeta{Generation: generation},
+			Spec:       iov1.DNSRecordSpec{Records: []iov1.Record{{Domain: "host.apps.example.com"}}},
+			Status:     iov1.DNSRecordStatus{ObservedGeneration: observedGeneration, Zones: zones},
+		}
+	}
+	wildcard := dnsRecord(2, 2, zoneStatus(publicZone, iov1.DNSRecordFailedConditionType, operatorv1.ConditionFalse))
+
+	if combined := combinedDNSRecord(&wildcard, nil); combined != &wildcard {
+		t.Errorf("expected the wildcard dnsrecord without dnsrecords for routes, got %v", combined)
+	}
+
+	combined := combinedDNSRecord(&wildcard, []iov1.DNSRecord{
+		dnsRecord(1, 1, zoneStatus(publicZone, iov1.DNSRecordFailedConditionType, operatorv1.ConditionTrue)),
+		dnsRecord(3, 3, zoneStatus(privateZone, iov1.DNSRecordPendingConditionType, operatorv1.ConditionTrue)),
+	})
+	if len(combined.Spec.Records) != 3 || combined.Status.ObservedGeneration != combined.Generation {
+		t.Errorf("expected every record and an up to date dnsrecord, got %v", combined)
+	}
+	expectZones := []iov1.DNSZoneStatus{
+		{DNSZone: publicZone, Conditions: []iov1.DNSZoneCondition{
+			{Type: iov1.DNSRecordFailedConditionType, Status: string(operatorv1.ConditionFalse)},
+			{Type: iov1.DNSRecordFailedConditionType, Status: string(operatorv1.ConditionTrue)},
+		}},
+		zoneStatus(privateZone, iov1.DNSRecordPendingConditionType, operatorv1.ConditionTrue),
+	}
+	if !cmp.Equal(combined.Status.Zones, expectZones) {
+		t.Errorf("expected zones %v, got %v", expectZones, combined.Status.Zones)
+	}
+	if len(wildcard.Spec.Records) != 1 || len(wildcard.Status.Zones[0].Conditions) != 1 {
+		t.Errorf("expected the wildcard dnsrecord to be left alone, got %v", wildcard)
+	}
+
+	// A dnsrecord for routes that is being published makes the combined
+	// dnsrecord pending.
+	combined = combinedDNSRecord(&wildcard, []iov1.DNSRecord{dnsRecord(2, 1)})
+	if combined.Status.ObservedGeneration == combined.Generation {
+		t.Errorf("expected the combined dnsrecord not to be up to date, got %v", combined)
+	}
+}
+
+func TestRouteDNSChanged(t *testing.T) {
+	route := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"type": "public"}},
+		Spec:       routev1.RouteSpec{Host: "web.apps.example.com"},
+	}
+	relabeled := route.DeepCopy()
+	relabeled.Labels["type"] = "private"
+	admitted := route.DeepCopy()
+	admitted.Status.Ingress = []routev1.RouteIngress{{Host: "web.apps.example.com", RouterName: "default"}}
+	respec := route.DeepCopy()
+	respec.Spec.Path = "/v2"
+	namespace := &corev1.Namespace{}
+	labeled := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"shard": "a"}}}
+
+	tests := []struct {
+		description string
+		old, new    interface{}
+		expect      bool
+	}{
+		{"route labels", route, relabeled, true},
+		{"route admission", route, admitted, true},
+		{"route path", route, respec, false},
+		{"namespace labels", namespace, labeled, true},
+		{"namespace without changes", namespace, namespace.DeepCopy(), false},
+	}
+	for _, test := range tests {
+		if actual := routeDNSChanged(test.old, test.new); actual != test.expect {
+			t.Errorf("%s: expected %v, got %v", test.description, test.expect, actual)
+		}
+	}
+}
@@ -187,8 +187,8 @@ func TestDesiredRouterDeployment(t *testing.T) {
 	}
 	if canonicalHostname == "" {
 		t.Error("router Deployment has no canonical hostname")
-	} else if canonicalHostname != ci.Status.Domain {
-		t.Errorf("router Deployment has unexpected canonical hostname: %q, expected %q", canonicalHostname, ci.Status.Domain)
+	} else if expected := "router-" + ci.Name + ".example.com"; canonicalHostname != expected {
+		t.Errorf("router Deployment has unexpected canonical hostname: %q, expected %q", canonicalHostname, expected)
 	}
 
 	secretName := fmt.Sprintf("secret-%v", time.Now().UnixNano())
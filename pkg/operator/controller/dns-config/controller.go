@@ -0,0 +1,287 @@
+// The DNS config controller is responsible for:
+//
+//   1. Rebuilding the DNS manager when the cloud credentials, the DNS provider
+//      configuration, or the cluster DNS or infrastructure config changes
+//   2. Replacing the DNS manager that the other controllers use with the
+//      rebuilt one if the DNS provider accepts its credentials
+//   3. Keeping the current DNS manager and recording the error otherwise, so
+//      that the operator reports itself as degraded
+package dnsconfig
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	runtimecontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "dns-config-controller"
+
+	// clusterConfigName is the name of the cluster DNS and infrastructure
+	// configs.
+	clusterConfigName = "cluster"
+)
+
+var log = logf.Logger.WithName(controllerName)
+
+// reloadRequest is the only request that the controller reconciles. Every
+// watched resource maps to it, so that several changes in quick succession
+// cause a single reload.
+var reloadRequest = reconcile.Request{NamespacedName: types.NamespacedName{Name: "dns-manager"}}
+
+// Config holds all the things necessary for the controller to run.
+type Config struct {
+	Namespace string
+	// SecretNames are the names of the secrets in the operator namespace,
+	// such as the cloud credentials, from which the DNS manager is built.
+	SecretNames []string
+	// ConfigMapNames are the names of the configmaps in the operator
+	// namespace from which the DNS manager is built.
+	ConfigMapNames []string
+	// SecretNamesFromConfigMap, if not nil, returns the names of the
+	// further secrets in the operator namespace, such as the secrets of
+	// DNS backends, that the given configmap refers to.
+	SecretNamesFromConfigMap func(configMap *corev1.ConfigMap) []string
+	// InitialVersion, if not empty, is the version of the watched
+	// resources, as returned by ConfigVersion, from which the current
+	// delegate of DNSManager was built.
+	InitialVersion string
+	// DNSManager is the DNS manager that the other controllers use, whose
+	// delegate the controller replaces.
+	DNSManager *dns.ReloadableManager
+	// NewDNSManager builds a DNS manager from the current configuration.
+	NewDNSManager func() (dns.Manager, error)
+	// Reloads, if not nil, receives an event whenever a reload fails or
+	// a reload succeeds after a failure.
+	Reloads chan<- event.GenericEvent
+}
+
+// New creates the DNS config controller from configuration. The controller
+// watches the configured secrets and configmaps in the operator namespace
+// through the manager's cache, and the cluster DNS and infrastructure configs
+// through clusterCache, which must be able to watch cluster-scoped resources.
+// The secrets that the configmaps refer to are watched as well.
+func New(mgr manager.Manager, clusterCache cache.Cache, config Config) (runtimecontroller.Controller, error) {
+	reconciler := &reconciler{
+		config:  config,
+		client:  mgr.GetClient(),
+		version: config.InitialVersion,
+	}
+	c, err := runtimecontroller.New(controllerName, mgr, runtimecontroller.Options{Reconciler: reconciler})
+	if err != nil {
+		return nil, err
+	}
+	toReloadRequest := &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(handler.MapObject) []reconcile.Request {
+			return []reconcile.Request{reloadRequest}
+		}),
+	}
+	watches := []struct {
+		cache     cache.Cache
+		object    runtime.Object
+		namespace string
+		isWatched func(name string) bool
+	}{
+		{mgr.GetCache(), &corev1.Secret{}, config.Namespace, reconciler.isWatchedSecret},
+		{mgr.GetCache(), &corev1.ConfigMap{}, config.Namespace, sets.NewString(config.ConfigMapNames...).Has},
+		{clusterCache, &configv1.DNS{}, "", sets.NewString(clusterConfigName).Has},
+		{clusterCache, &configv1.Infrastructure{}, "", sets.NewString(clusterConfigName).Has},
+	}
+	for _, w := range watches {
+		informer, err := w.cache.GetInformer(w.object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create informer for %T: %v", w.object, err)
+		}
+		if err := c.Watch(&source.Informer{Informer: informer}, toReloadRequest, isWatched(w.namespace, w.isWatched)); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// isWatched returns a predicate that accepts events for the resources in the
+// given namespace whose names isWatchedName accepts.
+func isWatched(namespace string, isWatchedName func(name string) bool) predicate.Funcs {
+	watched := func(meta metav1.Object) bool {
+		return meta.GetNamespace() == namespace && isWatchedName(meta.GetName())
+	}
+	return predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return watched(e.Meta) },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return watched(e.Meta) },
+		UpdateFunc:  func(e event.UpdateEvent) bool { return watched(e.MetaNew) },
+		GenericFunc: func(e event.GenericEvent) bool { return watched(e.Meta) },
+	}
+}
+
+type reconciler struct {
+	config Config
+
+	client client.Client
+
+	// version identifies the versions of the watched resources from which
+	// the current DNS manager was built.
+	version string
+
+	// lock protects access to referencedSecretNames, which the watch
+	// predicate reads.
+	lock sync.Mutex
+	// referencedSecretNames are the names of the secrets that the watched
+	// configmaps referred to when they were last read.
+	referencedSecretNames sets.String
+}
+
+// Reconcile rebuilds the DNS manager if any of the watched resources changed
+// since the current DNS manager was built.
+func (r *reconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	version, referencedSecretNames, err := configVersion(r.client, r.config)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	r.lock.Lock()
+	r.referencedSecretNames = referencedSecretNames
+	r.lock.Unlock()
+	return reconcile.Result{}, r.reload(version)
+}
+
+// isWatchedSecret returns true if the secret with the given name is one of the
+// configured secrets or is referred to by one of the watched configmaps.
+func (r *reconciler) isWatchedSecret(name string) bool {
+	for _, secretName := range r.config.SecretNames {
+		if name == secretName {
+			return true
+		}
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.referencedSecretNames.Has(name)
+}
+
+// reload rebuilds the DNS manager unless the current DNS manager was built
+// from the given version of the watched resources. If the new DNS manager
+// can't be built or its credentials are rejected, the current DNS manager is
+// kept and the error is recorded.
+func (r *reconciler) reload(version string) error {
+	if version == r.version {
+		return nil
+	}
+
+	log.Info("rebuilding DNS manager", "version", version)
+	failedBefore := r.config.DNSManager.ReloadError() != nil
+	manager, err := r.config.NewDNSManager()
+	if err == nil {
+		if validator, ok := manager.(dns.CredentialsValidator); ok {
+			err = validator.ValidateCredentials()
+		}
+	}
+	if err != nil {
+		// Leave the version alone so that the reload is retried.
+		err = fmt.Errorf("failed to rebuild DNS manager: %v", err)
+		r.config.DNSManager.SetReloadError(err)
+		r.notify()
+		return err
+	}
+
+	r.config.DNSManager.Reload(manager)
+	r.version = version
+	log.Info("reloaded DNS manager", "version", version)
+	if failedBefore {
+		r.notify()
+	}
+	return nil
+}
+
+// watchedResource is the name of a watched resource and an empty object of
+// the resource's type.
+type watchedResource struct {
+	name   types.NamespacedName
+	object runtime.Object
+}
+
+// ConfigVersion returns a string that changes whenever any of the resources
+// that the controller configured by config watches changes. Callers that build
+// the initial DNS manager should get the version before building it and pass
+// it as the InitialVersion, so that the controller doesn't rebuild the DNS
+// manager unless something changed in between.
+func ConfigVersion(cl client.Client, config Config) (string, error) {
+	version, _, err := configVersion(cl, config)
+	return version, err
+}
+
+// configVersion returns the version of the watched resources, and the names of
+// the secrets that the watched configmaps refer to.
+func configVersion(cl client.Client, config Config) (string, sets.String, error) {
+	resources := []watchedResource{
+		{types.NamespacedName{Name: clusterConfigName}, &configv1.DNS{}},
+		{types.NamespacedName{Name: clusterConfigName}, &configv1.Infrastructure{}},
+	}
+	for _, name := range config.ConfigMapNames {
+		resources = append(resources, watchedResource{types.NamespacedName{Namespace: config.Namespace, Name: name}, &corev1.ConfigMap{}})
+	}
+	for _, name := range config.SecretNames {
+		resources = append(resources, watchedResource{types.NamespacedName{Namespace: config.Namespace, Name: name}, &corev1.Secret{}})
+	}
+
+	// The secrets that the configmaps refer to are appended to resources
+	// as the configmaps are read.
+	secretNames := sets.NewString(config.SecretNames...)
+	referencedSecretNames := sets.NewString()
+	versions := []string{}
+	for i := 0; i < len(resources); i++ {
+		resource := resources[i]
+		version := "-"
+		if err := cl.Get(context.TODO(), resource.name, resource.object); err != nil {
+			if !errors.IsNotFound(err) {
+				return "", nil, fmt.Errorf("failed to get %T %s: %v", resource.object, resource.name, err)
+			}
+		} else if accessor, ok := resource.object.(metav1.ObjectMetaAccessor); ok {
+			version = accessor.GetObjectMeta().GetResourceVersion()
+			if configMap, ok := resource.object.(*corev1.ConfigMap); ok && config.SecretNamesFromConfigMap != nil {
+				for _, name := range config.SecretNamesFromConfigMap(configMap) {
+					if secretNames.Has(name) || referencedSecretNames.Has(name) {
+						continue
+					}
+					referencedSecretNames.Insert(name)
+					resources = append(resources, watchedResource{types.NamespacedName{Namespace: config.Namespace, Name: name}, &corev1.Secret{}})
+				}
+			}
+		}
+		versions = append(versions, resource.name.Name+"="+version)
+	}
+	return strings.Join(versions, ","), referencedSecretNames, nil
+}
+
+// notify sends an event on the reloads channel, if any, without blocking.
+func (r *reconciler) notify() {
+	if r.config.Reloads == nil {
+		return
+	}
+	meta := &metav1.ObjectMeta{Namespace: r.config.Namespace, Name: reloadRequest.Name}
+	select {
+	case r.config.Reloads <- event.GenericEvent{Meta: meta, Object: &corev1.ConfigMap{ObjectMeta: *meta}}:
+	default:
+	}
+}
@@ -0,0 +1,195 @@
+package dnsconfig
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	corev1 "k8s.io/api/core/v1"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// fakeManager is a dns.FakeManager whose credentials are valid unless it has an
+// error.
+type fakeManager struct {
+	*dns.FakeManager
+	err error
+}
+
+func (m *fakeManager) ValidateCredentials() error {
+	return m.err
+}
+
+func TestReload(t *testing.T) {
+	reloadable := dns.NewReloadableManager(&fakeManager{FakeManager: dns.NewFakeManager(dns.ARecordType)})
+	reloads := make(chan event.GenericEvent, 10)
+	var next dns.Manager
+	var newErr error
+	builds := 0
+	r := &reconciler{
+		config: Config{
+			Namespace:  "openshift-ingress-operator",
+			DNSManager: reloadable,
+			NewDNSManager: func() (dns.Manager, error) {
+				builds++
+				return next, newErr
+			},
+			Reloads: reloads,
+		},
+	}
+	supports := func(recordType dns.RecordType) bool {
+		return reloadable.SupportsRecordType(configv1.DNSZone{}, recordType)
+	}
+
+	// A successful reload replaces the manager.
+	next = &fakeManager{FakeManager: dns.NewFakeManager(dns.CNAMERecordType)}
+	if err := r.reload("1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !supports(dns.CNAMERecordType) {
+		t.Errorf("expected the manager to be replaced")
+	}
+	if len(reloads) != 0 {
+		t.Errorf("expected no notification for a successful reload")
+	}
+
+	// Nothing is rebuilt if nothing changed.
+	if err := r.reload("1"); err != nil || builds != 1 {
+		t.Errorf("expected no rebuild for an unchanged version, got %d builds and error %v", builds, err)
+	}
+
+	// Rejected credentials keep the current manager and are reported.
+	next = &fakeManager{FakeManager: dns.NewFakeManager(dns.AAAARecordType), err: errors.New("invalid credentials")}
+	if err := r.reload("2"); err == nil {
+		t.Errorf("expected an error for invalid credentials")
+	}
+	if !supports(dns.CNAMERecordType) || reloadable.ReloadError() == nil {
+		t.Errorf("expected the current manager to be kept and the error to be recorded")
+	}
+	if len(reloads) != 1 {
+		t.Errorf("expected a notification for a failed reload, got %d", len(reloads))
+	}
+
+	// A failure to build the manager is reported, and the reload is
+	// retried for the same version.
+	next, newErr = nil, errors.New("missing secret")
+	if err := r.reload("2"); err == nil || builds != 3 {
+		t.Errorf("expected the reload to be retried and to fail, got %d builds and error %v", builds, err)
+	}
+
+	// A successful reload clears the error.
+	next, newErr = &fakeManager{FakeManager: dns.NewFakeManager(dns.AAAARecordType)}, nil
+	if err := r.reload("3"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !supports(dns.AAAARecordType) || reloadable.ReloadError() != nil {
+		t.Errorf("expected the manager to be replaced and the error to be cleared")
+	}
+	if len(reloads) != 3 {
+		t.Errorf("expected a notification when a reload succeeds after a failure, got %d", len(reloads))
+	}
+}
+
+// fakeClient is a client that gets secrets and configmaps from memory and
+// finds no other resources. Its other methods are not implemented.
+type fakeClient struct {
+	client.Client
+	objects map[types.NamespacedName]runtime.Object
+}
+
+func (c *fakeClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	switch o := c.objects[key].(type) {
+	case *corev1.Secret:
+		if secret, ok := obj.(*corev1.Secret); ok {
+			o.DeepCopyInto(secret)
+			return nil
+		}
+	case *corev1.ConfigMap:
+		if configMap, ok := obj.(*corev1.ConfigMap); ok {
+			o.DeepCopyInto(configMap)
+			return nil
+		}
+	}
+	return kerrors.NewNotFound(corev1.Resource("unknown"), key.Name)
+}
+
+func TestReloadOnBackendSecretRotation(t *testing.T) {
+	const namespace = "openshift-ingress-operator"
+	objectMeta := func(name, resourceVersion string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Namespace: namespace, Name: name, ResourceVersion: resourceVersion}
+	}
+	backends := &corev1.ConfigMap{
+		ObjectMeta: objectMeta("dns-backends", "1"),
+		Data:       map[string]string{"secrets": "dns-external"},
+	}
+	cl := &fakeClient{objects: map[types.NamespacedName]runtime.Object{
+		{Namespace: namespace, Name: "dns-backends"}: backends,
+		{Namespace: namespace, Name: "dns-external"}: &corev1.Secret{ObjectMeta: objectMeta("dns-external", "1")},
+		{Namespace: namespace, Name: "unrelated"}:    &corev1.Secret{ObjectMeta: objectMeta("unrelated", "1")},
+	}}
+	builds := 0
+	config := Config{
+		Namespace:      namespace,
+		SecretNames:    []string{"cloud-credentials"},
+		ConfigMapNames: []string{"dns-backends"},
+		SecretNamesFromConfigMap: func(configMap *corev1.ConfigMap) []string {
+			return strings.Split(configMap.Data["secrets"], ",")
+		},
+		DNSManager: dns.NewReloadableManager(&fakeManager{FakeManager: dns.NewFakeManager()}),
+		NewDNSManager: func() (dns.Manager, error) {
+			builds++
+			return &fakeManager{FakeManager: dns.NewFakeManager()}, nil
+		},
+	}
+	initialVersion, err := ConfigVersion(cl, config)
+	if err != nil {
+		t.Fatalf("failed to get config version: %v", err)
+	}
+	config.InitialVersion = initialVersion
+	r := &reconciler{config: config, client: cl, version: config.InitialVersion}
+	reconcileWithBuilds := func(description string, expected int) {
+		t.Helper()
+		if _, err := r.Reconcile(reloadRequest); err != nil {
+			t.Fatalf("%s: unexpected error: %v", description, err)
+		}
+		if builds != expected {
+			t.Errorf("%s: expected %d builds, got %d", description, expected, builds)
+		}
+	}
+	watched := func(name string) bool {
+		return isWatched(namespace, r.isWatchedSecret).Update(event.UpdateEvent{MetaNew: &metav1.ObjectMeta{Namespace: namespace, Name: name}})
+	}
+
+	// The manager built with the initial version isn't rebuilt.
+	reconcileWithBuilds("initial reconcile", 0)
+
+	// Rotating the backend's secret rebuilds the manager.
+	if !watched("dns-external") || watched("unrelated") {
+		t.Errorf("expected only the backend's secret to be watched")
+	}
+	cl.objects[types.NamespacedName{Namespace: namespace, Name: "dns-external"}] = &corev1.Secret{ObjectMeta: objectMeta("dns-external", "2")}
+	reconcileWithBuilds("backend secret rotation", 1)
+
+	// A secret is watched once a backend refers to it.
+	backends.ResourceVersion = "2"
+	backends.Data["secrets"] = "dns-external,unrelated"
+	reconcileWithBuilds("new backend", 2)
+	if !watched("unrelated") {
+		t.Errorf("expected the new backend's secret to be watched")
+	}
+	cl.objects[types.NamespacedName{Namespace: namespace, Name: "unrelated"}] = &corev1.Secret{ObjectMeta: objectMeta("unrelated", "2")}
+	reconcileWithBuilds("new backend secret rotation", 3)
+	reconcileWithBuilds("no change", 3)
+}
@@ -0,0 +1,558 @@
+// The DNS controller is responsible for:
+//
+//   1. Publishing the records in each DNSRecord's spec to their zones
+//   2. Deleting published records that are removed from a DNSRecord's spec
+//   3. Deleting all published records when a DNSRecord is deleted
+//   4. Reporting the outcome in each zone on the DNSRecord's status
+//   5. Periodically repairing published records that have drifted from spec
+//   6. Refusing to modify or delete records that are owned by someone else
+//   7. Reporting whether published records have propagated, if the DNS
+//      provider tracks propagation
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	iov1 "github.com/openshift/cluster-ingress-operator/pkg/api/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+	logf "github.com/openshift/cluster-ingress-operator/pkg/log"
+	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
+	"github.com/openshift/cluster-ingress-operator/pkg/util/slice"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	runtimecontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "dns-controller"
+
+	// DNSRecordFinalizer is applied to a DNSRecord to ensure that the
+	// records it has published are deleted before the DNSRecord is.
+	DNSRecordFinalizer = "operator.openshift.io/ingress-dns"
+
+	// resyncPeriod is how often each DNSRecord is reconciled in order to
+	// detect and repair published records that no longer match spec, for
+	// example because someone deleted or changed them in the DNS provider.
+	resyncPeriod = 5 * time.Minute
+
+	// propagationPollPeriod is how often a DNSRecord is reconciled while
+	// some of its records have yet to propagate.
+	propagationPollPeriod = 15 * time.Second
+)
+
+var log = logf.Logger.WithName(controllerName)
+
+// Config holds all the things necessary for the controller to run.
+type Config struct {
+	Namespace  string
+	DNSManager dns.Manager
+	// InfrastructureName uniquely identifies the cluster in the ownership
+	// records that are published next to every record.
+	InfrastructureName string
+}
+
+// New creates the DNS controller from configuration. The controller watches
+// DNSRecord resources in the operator namespace and publishes their records
+// using the DNS manager.
+func New(mgr manager.Manager, config Config) (runtimecontroller.Controller, error) {
+	reconciler := &reconciler{
+		config:   config,
+		client:   mgr.GetClient(),
+		recorder: mgr.GetEventRecorderFor(controllerName),
+	}
+	c, err := runtimecontroller.New(controllerName, mgr, runtimecontroller.Options{Reconciler: reconciler})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Watch(&source.Kind{Type: &iov1.DNSRecord{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type reconciler struct {
+	config Config
+
+	client   client.Client
+	recorder record.EventRecorder
+}
+
+// Reconcile expects request to refer to a dnsrecord in the operator namespace,
+// and will publish or delete records so that the zones match the dnsrecord.
+func (r *reconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	log.Info("reconciling", "request", request)
+
+	record := &iov1.DNSRecord{}
+	if err := r.client.Get(context.TODO(), request.NamespacedName, record); err != nil {
+		if errors.IsNotFound(err) {
+			log.Info("dnsrecord not found; reconciliation will be skipped", "request", request)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("failed to get dnsrecord %q: %v", request, err)
+	}
+
+	if record.DeletionTimestamp != nil {
+		if err := r.finalizeDNSRecord(record); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to finalize dnsrecord %s/%s: %v", record.Namespace, record.Name, err)
+		}
+		return reconcile.Result{}, nil
+	}
+
+	if !slice.ContainsString(record.Finalizers, DNSRecordFinalizer) {
+		updated := record.DeepCopy()
+		updated.Finalizers = append(updated.Finalizers, DNSRecordFinalizer)
+		if err := r.client.Update(context.TODO(), updated); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to add finalizer to dnsrecord %s/%s: %v", record.Namespace, record.Name, err)
+		}
+		log.Info("added finalizer to dnsrecord", "namespace", record.Namespace, "name", record.Name)
+		record = updated
+	}
+
+	pending, err := r.publishRecords(record)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if pending {
+		return reconcile.Result{RequeueAfter: propagationPollPeriod}, nil
+	}
+	return reconcile.Result{RequeueAfter: resyncPeriod}, nil
+}
+
+// publishRecords ensures every record in the dnsrecord's spec and deletes any
+// previously published record that is no longer in spec, and then updates the
+// dnsrecord's status with the outcome. If the DNS manager supports batches,
+// the changes are committed together at the end. Returns true if some of the
+// records in spec have yet to propagate.
+func (r *reconciler) publishRecords(record *iov1.DNSRecord) (bool, error) {
+	batch, commit := r.newBatch()
+	dnsManager := r.dnsManager(record, batch, record.Spec.Records)
+	errs := []error{}
+	zoneErrs := map[string][]error{}
+	zones := map[string]configv1.DNSZone{}
+	published := []iov1.Record{}
+	// deleted and ensured are the records whose changes succeeded or were
+	// queued, and repaired are the ensured records that had drifted.
+	deleted := []iov1.Record{}
+	ensured := []iov1.Record{}
+	repaired := []iov1.Record{}
+
+	for _, rec := range record.Status.PublishedRecords {
+		key := zoneKey(rec.Zone)
+		zones[key] = rec.Zone
+		if containsRecord(record.Spec.Records, rec) {
+			continue
+		}
+		if err := dnsManager.Delete(recordFromAPI(rec)); err != nil {
+			if dns.IsOwnershipConflict(err) {
+				// The record is no longer ours, so there is nothing
+				// to delete.
+				r.recorder.Eventf(record, "Warning", "OwnershipConflict", "Did not delete DNS record %s in zone %s: %v", rec.Domain, formatZone(rec.Zone), err)
+				continue
+			}
+			errs = append(errs, fmt.Errorf("failed to delete DNS record %v: %v", recordFromAPI(rec), err))
+			zoneErrs[key] = append(zoneErrs[key], err)
+			// The record may still exist, so keep track of it.
+			published = append(published, rec)
+		} else {
+			deleted = append(deleted, rec)
+		}
+	}
+
+	for _, rec := range record.Spec.Records {
+		key := zoneKey(rec.Zone)
+		zones[key] = rec.Zone
+		if containsRecord(record.Status.PublishedRecords, rec) {
+			current, err := dnsManager.Get(recordFromAPI(rec))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to get DNS record %v: %v", recordFromAPI(rec), err))
+				zoneErrs[key] = append(zoneErrs[key], err)
+				published = append(published, rec)
+				continue
+			}
+			if !recordMatches(rec, current) {
+				log.Info("detected DNS record drift", "namespace", record.Namespace, "name", record.Name, "desired", recordFromAPI(rec), "current", current)
+				repaired = append(repaired, rec)
+			}
+		}
+		if err := dnsManager.Ensure(recordFromAPI(rec)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to ensure DNS record %v: %v", recordFromAPI(rec), err))
+			zoneErrs[key] = append(zoneErrs[key], err)
+			if containsRecord(record.Status.PublishedRecords, rec) {
+				published = append(published, rec)
+			}
+			continue
+		}
+		ensured = append(ensured, rec)
+	}
+
+	failedZones := map[string]dns.ZoneError{}
+	for _, zoneErr := range commit() {
+		key := zoneKey(zoneErr.Zone)
+		failedZones[key] = zoneErr
+		errs = append(errs, fmt.Errorf("failed to publish DNS records in zone %s: %v", formatZone(zoneErr.Zone), zoneErr.Err))
+		zoneErrs[key] = append(zoneErrs[key], zoneErr.Err)
+	}
+	for _, rec := range deleted {
+		if !committed(failedZones, rec) {
+			// The record may still exist, so keep track of it.
+			published = append(published, rec)
+			continue
+		}
+		log.Info("deleted DNS record", "namespace", record.Namespace, "name", record.Name, "record", recordFromAPI(rec))
+	}
+	for _, rec := range ensured {
+		if !committed(failedZones, rec) {
+			if containsRecord(record.Status.PublishedRecords, rec) {
+				published = append(published, rec)
+			}
+			continue
+		}
+		log.Info("ensured DNS record", "namespace", record.Namespace, "name", record.Name, "record", recordFromAPI(rec))
+		if containsRecord(repaired, rec) {
+			r.recorder.Eventf(record, "Warning", "RepairedDNSRecord", "Repaired DNS record %s in zone %s that did not match the desired record", rec.Domain, formatZone(rec.Zone))
+		}
+		published = append(published, rec)
+	}
+
+	pending := map[string]bool{}
+	if tracker, ok := r.config.DNSManager.(dns.PropagationTracker); ok {
+		for _, rec := range published {
+			if !containsRecord(record.Spec.Records, rec) {
+				continue
+			}
+			key := zoneKey(rec.Zone)
+			isPending, err := tracker.Pending(recordFromAPI(rec))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to get propagation status of DNS record %v: %v", recordFromAPI(rec), err))
+				continue
+			}
+			pending[key] = pending[key] || isPending
+		}
+	}
+
+	updated := record.DeepCopy()
+	updated.Status.ObservedGeneration = record.Generation
+	updated.Status.PublishedRecords = published
+	updated.Status.Zones = computeZoneStatuses(record.Status.Zones, zones, zoneErrs, pending)
+	if !dnsRecordStatusesEqual(updated.Status, record.Status) {
+		if err := r.client.Status().Update(context.TODO(), updated); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update status of dnsrecord %s/%s: %v", record.Namespace, record.Name, err))
+		}
+	}
+
+	anyPending := false
+	for _, isPending := range pending {
+		anyPending = anyPending || isPending
+	}
+	return anyPending, utilerrors.NewAggregate(errs)
+}
+
+// finalizeDNSRecord deletes every record that the dnsrecord may have published
+// and then removes the finalizer from the dnsrecord.
+func (r *reconciler) finalizeDNSRecord(record *iov1.DNSRecord) error {
+	if !slice.ContainsString(record.Finalizers, DNSRecordFinalizer) {
+		return nil
+	}
+
+	// Delete the records in spec as well as the published ones, in case a
+	// record was published but the status update failed.
+	records := append([]iov1.Record{}, record.Status.PublishedRecords...)
+	for _, rec := range record.Spec.Records {
+		if !containsRecord(records, rec) {
+			records = append(records, rec)
+		}
+	}
+	batch, commit := r.newBatch()
+	dnsManager := r.dnsManager(record, batch, nil)
+	errs := []error{}
+	deleted := []iov1.Record{}
+	for _, rec := range records {
+		if err := dnsManager.Delete(recordFromAPI(rec)); err != nil {
+			if dns.IsOwnershipConflict(err) {
+				// The record is no longer ours, so there is nothing
+				// to delete.
+				r.recorder.Eventf(record, "Warning", "OwnershipConflict", "Did not delete DNS record %s in zone %s: %v", rec.Domain, formatZone(rec.Zone), err)
+				continue
+			}
+			errs = append(errs, fmt.Errorf("failed to delete DNS record %v: %v", recordFromAPI(rec), err))
+		} else {
+			deleted = append(deleted, rec)
+		}
+	}
+	failedZones := map[string]dns.ZoneError{}
+	for _, zoneErr := range commit() {
+		failedZones[zoneKey(zoneErr.Zone)] = zoneErr
+		errs = append(errs, fmt.Errorf("failed to delete DNS records in zone %s: %v", formatZone(zoneErr.Zone), zoneErr.Err))
+	}
+	for _, rec := range deleted {
+		if committed(failedZones, rec) {
+			log.Info("deleted DNS record", "namespace", record.Namespace, "name", record.Name, "record", recordFromAPI(rec))
+		}
+	}
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		return err
+	}
+
+	updated := record.DeepCopy()
+	updated.Finalizers = slice.RemoveString(updated.Finalizers, DNSRecordFinalizer)
+	if err := r.client.Update(context.TODO(), updated); err != nil {
+		return fmt.Errorf("failed to remove finalizer: %v", err)
+	}
+	log.Info("finalized dnsrecord", "namespace", record.Namespace, "name", record.Name)
+	return nil
+}
+
+// newBatch returns a batch of the DNS manager and a function that commits the
+// batch, if the DNS manager supports batches. Otherwise, it returns the DNS
+// manager itself, which applies every change immediately, and a function that
+// does nothing.
+func (r *reconciler) newBatch() (dns.Manager, func() []dns.ZoneError) {
+	if batchManager, ok := r.config.DNSManager.(dns.BatchManager); ok {
+		batch := batchManager.NewBatch()
+		return batch, batch.Commit
+	}
+	return r.config.DNSManager, func() []dns.ZoneError { return nil }
+}
+
+// committed returns true if the change to the given record was committed, which
+// it was unless its zone is one of the given failed zones and the zone's error
+// doesn't list the record as committed.
+func committed(failedZones map[string]dns.ZoneError, record iov1.Record) bool {
+	zoneErr, failed := failedZones[zoneKey(record.Zone)]
+	if !failed {
+		return true
+	}
+	rec := recordFromAPI(record)
+	for _, c := range zoneErr.Committed {
+		if cmp.Equal(c, rec, cmpopts.EquateEmpty()) {
+			return true
+		}
+	}
+	return false
+}
+
+// dnsManager returns a DNS manager for the records of the given dnsrecord that
+// wraps the given manager and publishes ownership records that identify the
+// cluster and the ingresscontroller that owns the dnsrecord. The ownership
+// records of the given retained records are kept when other records are
+// deleted.
+func (r *reconciler) dnsManager(record *iov1.DNSRecord, manager dns.Manager, retained []iov1.Record) dns.Manager {
+	ingressController, ok := record.Labels[manifests.OwningIngressControllerLabel]
+	if !ok {
+		ingressController = record.Name
+	}
+	records := []*dns.Record{}
+	for _, rec := range retained {
+		records = append(records, recordFromAPI(rec))
+	}
+	return dns.NewOwnershipManager(manager, dns.Owner{
+		ClusterID:         r.config.InfrastructureName,
+		IngressController: ingressController,
+	}, records...)
+}
+
+// computeZoneStatuses returns a status for every one of the given zones,
+// reporting a failure for each zone that has errors. If every error for a zone
+// indicates that the DNS provider does not support a record's type, the
+// failure has the reason "UnsupportedRecordType", and if every error indicates
+// that a record is owned by someone else, the failure has the reason
+// "OwnershipConflict". Each zone in pending also gets a condition that reports
+// whether the records in the zone have yet to propagate.
+func computeZoneStatuses(oldStatuses []iov1.DNSZoneStatus, zones map[string]configv1.DNSZone, zoneErrs map[string][]error, pending map[string]bool) []iov1.DNSZoneStatus {
+	keys := []string{}
+	for key := range zones {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	statuses := []iov1.DNSZoneStatus{}
+	for _, key := range keys {
+		condition := iov1.DNSZoneCondition{
+			Type:    iov1.DNSRecordFailedConditionType,
+			Status:  string(operatorv1.ConditionFalse),
+			Reason:  "Published",
+			Message: "The DNS provider published the records",
+		}
+		if err := utilerrors.NewAggregate(zoneErrs[key]); err != nil {
+			condition.Status = string(operatorv1.ConditionTrue)
+			condition.Reason = "ProviderError"
+			condition.Message = fmt.Sprintf("The DNS provider failed to publish the records: %v", err)
+			switch {
+			case allErrors(zoneErrs[key], dns.IsUnsupportedRecordType):
+				condition.Reason = "UnsupportedRecordType"
+				condition.Message = fmt.Sprintf("The DNS provider does not support the records: %v", err)
+			case allErrors(zoneErrs[key], dns.IsOwnershipConflict):
+				condition.Reason = "OwnershipConflict"
+				condition.Message = fmt.Sprintf("The records are owned by someone else: %v", err)
+			}
+		}
+		conditions := []iov1.DNSZoneCondition{condition}
+		if isPending, ok := pending[key]; ok {
+			condition := iov1.DNSZoneCondition{
+				Type:    iov1.DNSRecordPendingConditionType,
+				Status:  string(operatorv1.ConditionFalse),
+				Reason:  "InSync",
+				Message: "The records have propagated to the zone's name servers",
+			}
+			if isPending {
+				condition.Status = string(operatorv1.ConditionTrue)
+				condition.Reason = "Pending"
+				condition.Message = "The records are propagating to the zone's name servers"
+			}
+			conditions = append(conditions, condition)
+		}
+		for i := range conditions {
+			conditions[i].LastTransitionTime = lastTransitionTime(oldStatuses, key, conditions[i])
+		}
+		statuses = append(statuses, iov1.DNSZoneStatus{
+			DNSZone:    zones[key],
+			Conditions: conditions,
+		})
+	}
+	return statuses
+}
+
+// lastTransitionTime returns the last transition time of the condition in the
+// old status of the zone with the given key if the condition is unchanged, or
+// the current time otherwise.
+func lastTransitionTime(oldStatuses []iov1.DNSZoneStatus, key string, condition iov1.DNSZoneCondition) metav1.Time {
+	for _, oldStatus := range oldStatuses {
+		if zoneKey(oldStatus.DNSZone) != key {
+			continue
+		}
+		for _, oldCondition := range oldStatus.Conditions {
+			if oldCondition.Type == condition.Type && oldCondition.Status == condition.Status &&
+				oldCondition.Reason == condition.Reason && oldCondition.Message == condition.Message {
+				return oldCondition.LastTransitionTime
+			}
+		}
+	}
+	return metav1.Now()
+}
+
+// allErrors returns true if errs is not empty and every one of errs satisfies
+// the given predicate.
+func allErrors(errs []error, predicate func(error) bool) bool {
+	for _, err := range errs {
+		if !predicate(err) {
+			return false
+		}
+	}
+	return len(errs) > 0
+}
+
+// dnsRecordStatusesEqual compares two DNSRecordStatus values.  Returns true if
+// the provided values should be considered equal for the purpose of
+// determining whether an update is necessary, false otherwise.
+func dnsRecordStatusesEqual(a, b iov1.DNSRecordStatus) bool {
+	return cmp.Equal(a, b, cmpopts.EquateEmpty())
+}
+
+// zoneKey returns a string that uniquely identifies zone.
+func zoneKey(zone configv1.DNSZone) string {
+	// fmt prints maps sorted by key, so the result is deterministic.
+	return fmt.Sprintf("%s%v", zone.ID, zone.Tags)
+}
+
+// containsRecord returns true if records includes record.
+func containsRecord(records []iov1.Record, record iov1.Record) bool {
+	for _, r := range records {
+		if cmp.Equal(r, record, cmpopts.EquateEmpty()) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordMatches returns true if current is a published record with the same
+// type and target as the desired record and, if the DNS manager reports the
+// current record's routing policy, with the same routing policy apart from the
+// health check.
+func recordMatches(desired iov1.Record, current *dns.Record) bool {
+	if current == nil {
+		return false
+	}
+	if desired.RoutingPolicy != nil && current.RoutingPolicy != nil {
+		p := recordFromAPI(desired).RoutingPolicy
+		p.HealthCheck = current.RoutingPolicy.HealthCheck
+		if *p != *current.RoutingPolicy {
+			return false
+		}
+	}
+	switch desired.Type {
+	case iov1.ALIASRecordType:
+		return current.Alias != nil && strings.EqualFold(current.Alias.Target, desired.Target)
+	case iov1.ARecordType:
+		return current.ARecord != nil && current.ARecord.Address == desired.Target
+	case iov1.AAAARecordType:
+		return current.AAAARecord != nil && net.ParseIP(current.AAAARecord.Address).Equal(net.ParseIP(desired.Target))
+	case iov1.CNAMERecordType:
+		return current.CNAMERecord != nil && strings.EqualFold(strings.TrimSuffix(current.CNAMERecord.Target, "."), strings.TrimSuffix(desired.Target, "."))
+	}
+	return false
+}
+
+// formatZone returns a human readable name for zone.
+func formatZone(zone configv1.DNSZone) string {
+	if len(zone.ID) > 0 {
+		return zone.ID
+	}
+	return fmt.Sprintf("%v", zone.Tags)
+}
+
+// recordFromAPI returns the dns.Record for the given API record.
+func recordFromAPI(record iov1.Record) *dns.Record {
+	r := &dns.Record{
+		Zone: record.Zone,
+	}
+	switch record.Type {
+	case iov1.ALIASRecordType:
+		r.Type = dns.ALIASRecord
+		r.Alias = &dns.AliasRecord{Domain: record.Domain, Target: record.Target}
+	case iov1.ARecordType:
+		r.Type = dns.ARecordType
+		r.ARecord = &dns.ARecord{Domain: record.Domain, Address: record.Target}
+	case iov1.AAAARecordType:
+		r.Type = dns.AAAARecordType
+		r.AAAARecord = &dns.AAAARecord{Domain: record.Domain, Address: record.Target}
+	case iov1.CNAMERecordType:
+		r.Type = dns.CNAMERecordType
+		r.CNAMERecord = &dns.CNAMERecord{Domain: record.Domain, Target: record.Target}
+	default:
+		r.Type = dns.RecordType(record.Type)
+	}
+	if p := record.RoutingPolicy; p != nil {
+		r.RoutingPolicy = &dns.RoutingPolicy{
+			Type:          dns.RoutingPolicyType(p.Type),
+			SetIdentifier: p.SetIdentifier,
+			Weight:        p.Weight,
+			Failover:      dns.FailoverRole(p.Failover),
+			Region:        p.Region,
+		}
+		if p.HealthCheck != nil {
+			r.RoutingPolicy.HealthCheck = &dns.HealthCheck{Port: p.HealthCheck.Port, Path: p.HealthCheck.Path}
+		}
+	}
+	return r
+}
@@ -0,0 +1,284 @@
+package dns
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	iov1 "github.com/openshift/cluster-ingress-operator/pkg/api/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestComputeZoneStatuses(t *testing.T) {
+	publicZone := configv1.DNSZone{ID: "public"}
+	privateZone := configv1.DNSZone{Tags: map[string]string{"Name": "private"}}
+	zones := map[string]configv1.DNSZone{
+		zoneKey(publicZone):  publicZone,
+		zoneKey(privateZone): privateZone,
+	}
+	zoneErrs := map[string][]error{
+		zoneKey(privateZone): {errors.New("throttled")},
+	}
+	oldTime := metav1.NewTime(time.Now().Add(-time.Hour))
+	oldStatuses := []iov1.DNSZoneStatus{
+		{
+			DNSZone: publicZone,
+			Conditions: []iov1.DNSZoneCondition{{
+				Type:               iov1.DNSRecordFailedConditionType,
+				Status:             "False",
+				Reason:             "Published",
+				Message:            "The DNS provider published the records",
+				LastTransitionTime: oldTime,
+			}},
+		},
+		{
+			DNSZone: privateZone,
+			Conditions: []iov1.DNSZoneCondition{{
+				Type:               iov1.DNSRecordFailedConditionType,
+				Status:             "False",
+				Reason:             "Published",
+				Message:            "The DNS provider published the records",
+				LastTransitionTime: oldTime,
+			}},
+		},
+	}
+
+	statuses := computeZoneStatuses(oldStatuses, zones, zoneErrs, nil)
+	if len(statuses) != 2 {
+		t.Fatalf("expected 2 zone statuses, got %d", len(statuses))
+	}
+	for _, status := range statuses {
+		if len(status.Conditions) != 1 {
+			t.Fatalf("expected 1 condition for zone %v, got %d", status.DNSZone, len(status.Conditions))
+		}
+		condition := status.Conditions[0]
+		switch zoneKey(status.DNSZone) {
+		case zoneKey(publicZone):
+			if condition.Status != "False" {
+				t.Errorf("expected zone %v not to be failed, got %v", status.DNSZone, condition)
+			}
+			if !condition.LastTransitionTime.Equal(&oldTime) {
+				t.Errorf("expected unchanged condition for zone %v to keep its transition time", status.DNSZone)
+			}
+		case zoneKey(privateZone):
+			if condition.Status != "True" || condition.Reason != "ProviderError" {
+				t.Errorf("expected zone %v to be failed, got %v", status.DNSZone, condition)
+			}
+			if condition.LastTransitionTime.Equal(&oldTime) {
+				t.Errorf("expected changed condition for zone %v to have a new transition time", status.DNSZone)
+			}
+		default:
+			t.Errorf("unexpected zone %v", status.DNSZone)
+		}
+	}
+}
+
+func TestComputeZoneStatusReasons(t *testing.T) {
+	zone := configv1.DNSZone{ID: "public"}
+	zones := map[string]configv1.DNSZone{zoneKey(zone): zone}
+
+	tests := []struct {
+		name   string
+		errs   []error
+		reason string
+	}{
+		{
+			name:   "unsupported record type",
+			errs:   []error{&dns.UnsupportedRecordTypeError{Type: dns.ALIASRecord}},
+			reason: "UnsupportedRecordType",
+		},
+		{
+			name:   "unsupported record type and provider error",
+			errs:   []error{&dns.UnsupportedRecordTypeError{Type: dns.ALIASRecord}, errors.New("throttled")},
+			reason: "ProviderError",
+		},
+		{
+			name:   "ownership conflict",
+			errs:   []error{&dns.OwnershipConflictError{Domain: "*.apps.example.com", Owner: "heritage=openshift-ingress-operator,cluster=other,ingresscontroller=default"}},
+			reason: "OwnershipConflict",
+		},
+		{
+			name:   "ownership conflict and provider error",
+			errs:   []error{&dns.OwnershipConflictError{Domain: "*.apps.example.com"}, errors.New("throttled")},
+			reason: "ProviderError",
+		},
+	}
+
+	for _, test := range tests {
+		statuses := computeZoneStatuses(nil, zones, map[string][]error{zoneKey(zone): test.errs}, nil)
+		if len(statuses) != 1 || len(statuses[0].Conditions) != 1 {
+			t.Fatalf("%s: expected 1 zone status with 1 condition, got %v", test.name, statuses)
+		}
+		if condition := statuses[0].Conditions[0]; condition.Status != "True" || condition.Reason != test.reason {
+			t.Errorf("%s: expected failed condition with reason %s, got %v", test.name, test.reason, condition)
+		}
+	}
+}
+
+func TestComputeZoneStatusesPending(t *testing.T) {
+	publicZone := configv1.DNSZone{ID: "public"}
+	privateZone := configv1.DNSZone{ID: "private"}
+	untrackedZone := configv1.DNSZone{ID: "untracked"}
+	zones := map[string]configv1.DNSZone{
+		zoneKey(publicZone):    publicZone,
+		zoneKey(privateZone):   privateZone,
+		zoneKey(untrackedZone): untrackedZone,
+	}
+	pending := map[string]bool{
+		zoneKey(publicZone):  true,
+		zoneKey(privateZone): false,
+	}
+
+	statuses := computeZoneStatuses(nil, zones, nil, pending)
+	if len(statuses) != 3 {
+		t.Fatalf("expected 3 zone statuses, got %d", len(statuses))
+	}
+	for _, status := range statuses {
+		var condition *iov1.DNSZoneCondition
+		for i := range status.Conditions {
+			if status.Conditions[i].Type == iov1.DNSRecordPendingConditionType {
+				condition = &status.Conditions[i]
+			}
+		}
+		switch zoneKey(status.DNSZone) {
+		case zoneKey(publicZone):
+			if condition == nil || condition.Status != "True" || condition.Reason != "Pending" {
+				t.Errorf("expected zone %v to be pending, got %v", status.DNSZone, condition)
+			}
+		case zoneKey(privateZone):
+			if condition == nil || condition.Status != "False" || condition.Reason != "InSync" {
+				t.Errorf("expected zone %v to be in sync, got %v", status.DNSZone, condition)
+			}
+		case zoneKey(untrackedZone):
+			if condition != nil {
+				t.Errorf("expected no pending condition for zone %v, got %v", status.DNSZone, condition)
+			}
+		}
+	}
+}
+
+func TestContainsRecord(t *testing.T) {
+	record := iov1.Record{
+		Zone:   configv1.DNSZone{ID: "public"},
+		Type:   iov1.ARecordType,
+		Domain: "*.apps.example.com",
+		Target: "192.0.2.1",
+	}
+	other := record
+	other.Target = "192.0.2.2"
+
+	if !containsRecord([]iov1.Record{other, record}, record) {
+		t.Errorf("expected record to be found")
+	}
+	if containsRecord([]iov1.Record{other}, record) {
+		t.Errorf("expected record not to be found")
+	}
+}
+
+func TestCommitted(t *testing.T) {
+	record := iov1.Record{
+		Zone:   configv1.DNSZone{ID: "public"},
+		Type:   iov1.ARecordType,
+		Domain: "*.apps.example.com",
+		Target: "192.0.2.1",
+	}
+	other := record
+	other.Target = "192.0.2.2"
+	private := record
+	private.Zone = configv1.DNSZone{ID: "private"}
+
+	failedZones := map[string]dns.ZoneError{
+		zoneKey(record.Zone): {Zone: record.Zone, Err: errors.New("rejected"), Committed: []*dns.Record{recordFromAPI(record)}},
+	}
+	if !committed(failedZones, record) {
+		t.Errorf("expected a record that the zone's error lists to be committed")
+	}
+	if committed(failedZones, other) {
+		t.Errorf("expected a record that the zone's error doesn't list not to be committed")
+	}
+	if !committed(failedZones, private) {
+		t.Errorf("expected a record in a zone that didn't fail to be committed")
+	}
+}
+
+func TestRecordMatches(t *testing.T) {
+	alias := iov1.Record{
+		Zone:   configv1.DNSZone{ID: "public"},
+		Type:   iov1.ALIASRecordType,
+		Domain: "*.apps.example.com",
+		Target: "lb.example.com",
+	}
+	a := iov1.Record{
+		Zone:   configv1.DNSZone{ID: "public"},
+		Type:   iov1.ARecordType,
+		Domain: "*.apps.example.com",
+		Target: "192.0.2.1",
+	}
+	weighted := alias
+	weighted.RoutingPolicy = &iov1.RoutingPolicy{
+		Type:          iov1.WeightedRoutingPolicy,
+		SetIdentifier: "cluster-1",
+		Weight:        100,
+		HealthCheck:   &iov1.HealthCheck{Port: 443},
+	}
+
+	tests := []struct {
+		name    string
+		desired iov1.Record
+		current *dns.Record
+		expect  bool
+	}{
+		{
+			name:    "missing record",
+			desired: alias,
+			current: nil,
+			expect:  false,
+		},
+		{
+			name:    "matching alias record",
+			desired: alias,
+			current: &dns.Record{Type: dns.ALIASRecord, Alias: &dns.AliasRecord{Domain: alias.Domain, Target: "LB.example.com"}},
+			expect:  true,
+		},
+		{
+			name:    "alias record with different target",
+			desired: alias,
+			current: &dns.Record{Type: dns.ALIASRecord, Alias: &dns.AliasRecord{Domain: alias.Domain, Target: "other.example.com"}},
+			expect:  false,
+		},
+		{
+			name:    "matching A record",
+			desired: a,
+			current: &dns.Record{Type: dns.ARecordType, ARecord: &dns.ARecord{Domain: a.Domain, Address: "192.0.2.1"}},
+			expect:  true,
+		},
+		{
+			name:    "A record with different address",
+			desired: a,
+			current: &dns.Record{Type: dns.ARecordType, ARecord: &dns.ARecord{Domain: a.Domain, Address: "192.0.2.2"}},
+			expect:  false,
+		},
+		{
+			name:    "matching weighted alias record",
+			desired: weighted,
+			current: &dns.Record{Type: dns.ALIASRecord, Alias: &dns.AliasRecord{Domain: alias.Domain, Target: alias.Target}, RoutingPolicy: &dns.RoutingPolicy{Type: dns.WeightedRoutingPolicy, SetIdentifier: "cluster-1", Weight: 100}},
+			expect:  true,
+		},
+		{
+			name:    "weighted alias record with different weight",
+			desired: weighted,
+			current: &dns.Record{Type: dns.ALIASRecord, Alias: &dns.AliasRecord{Domain: alias.Domain, Target: alias.Target}, RoutingPolicy: &dns.RoutingPolicy{Type: dns.WeightedRoutingPolicy, SetIdentifier: "cluster-1", Weight: 0}},
+			expect:  false,
+		},
+	}
+
+	for _, test := range tests {
+		if actual := recordMatches(test.desired, test.current); actual != test.expect {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expect, actual)
+		}
+	}
+}
@@ -3,11 +3,14 @@ package controller
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 
+	configv1 "github.com/openshift/api/config/v1"
 	operatorv1 "github.com/openshift/api/operator/v1"
+	iov1 "github.com/openshift/cluster-ingress-operator/pkg/api/v1"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -15,9 +18,16 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// CanonicalHostnamePublishedIngressConditionType is the type of the
+// ingresscontroller condition that reports the ingresscontroller's canonical
+// hostname in its message and whether the operator has published a DNS record
+// for it. The condition is only reported if the ingresscontroller has a domain.
+const CanonicalHostnamePublishedIngressConditionType = "CanonicalHostnamePublished"
+
 // syncIngressControllerStatus computes the current status of ic and
-// updates status upon any changes since last sync.
-func (r *reconciler) syncIngressControllerStatus(ic *operatorv1.IngressController, deployment *appsv1.Deployment, service *corev1.Service, operandEvents []corev1.Event) error {
+// updates status upon any changes since last sync. If verifyResolution is not
+// nil, DNSReady is only reported once verifyResolution succeeds.
+func (r *reconciler) syncIngressControllerStatus(ic *operatorv1.IngressController, deployment *appsv1.Deployment, service *corev1.Service, operandEvents []corev1.Event, dnsRecord *iov1.DNSRecord, dnsConfig *configv1.DNS, verifyResolution func() error) error {
 	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
 	if err != nil {
 		return fmt.Errorf("deployment has invalid spec.selector: %v", err)
@@ -30,6 +40,9 @@ func (r *reconciler) syncIngressControllerStatus(ic *operatorv1.IngressControlle
 	updated.Status.Conditions = []operatorv1.OperatorCondition{}
 	updated.Status.Conditions = append(updated.Status.Conditions, computeIngressStatusConditions(updated.Status.Conditions, deployment)...)
 	updated.Status.Conditions = append(updated.Status.Conditions, computeLoadBalancerStatus(ic, service, operandEvents)...)
+	dnsConditions := computeDNSStatus(ic, dnsRecord, dnsConfig, deployment.Status.ReadyReplicas, verifyResolution)
+	updated.Status.Conditions = append(updated.Status.Conditions, dnsConditions...)
+	updated.Status.Conditions = append(updated.Status.Conditions, computeCanonicalHostnameStatus(ic, dnsRecord, dnsConditions)...)
 
 	for i := range updated.Status.Conditions {
 		newCondition := &updated.Status.Conditions[i]
@@ -186,6 +199,265 @@ func computeLoadBalancerStatus(ic *operatorv1.IngressController, service *corev1
 	return conditions
 }
 
+// computeDNSStatus returns the complete set of current DNS-prefixed conditions
+// for the given ingress controller from the status of its wildcard DNSRecord.
+// readyReplicas is the number of ready router pods, whose node addresses the
+// record has if the ingresscontroller is published with the HostNetwork
+// strategy. If verifyResolution is not nil, it is called once the record is
+// provisioned in all zones, and DNSReady is false if it fails.
+func computeDNSStatus(ic *operatorv1.IngressController, dnsRecord *iov1.DNSRecord, dnsConfig *configv1.DNS, readyReplicas int32, verifyResolution func() error) []operatorv1.OperatorCondition {
+	if (ic.Status.EndpointPublishingStrategy == nil ||
+		ic.Status.EndpointPublishingStrategy.Type != operatorv1.LoadBalancerServiceStrategyType) && !hasHostNetworkDNS(ic) {
+		return []operatorv1.OperatorCondition{
+			{
+				Type:    operatorv1.DNSManagedIngressConditionType,
+				Status:  operatorv1.ConditionFalse,
+				Reason:  "UnsupportedEndpointPublishingStrategy",
+				Message: "The endpoint publishing strategy doesn't support DNS management.",
+			},
+		}
+	}
+
+	if len(ic.Status.Domain) == 0 {
+		return []operatorv1.OperatorCondition{
+			{
+				Type:    operatorv1.DNSManagedIngressConditionType,
+				Status:  operatorv1.ConditionFalse,
+				Reason:  "NoDomain",
+				Message: "The ingress controller has no domain.",
+			},
+		}
+	}
+
+	privateZone, publicZone, err := dnsZones(ic, dnsConfig)
+	if err != nil {
+		return []operatorv1.OperatorCondition{
+			{
+				Type:    operatorv1.DNSManagedIngressConditionType,
+				Status:  operatorv1.ConditionFalse,
+				Reason:  "InvalidDNSZones",
+				Message: fmt.Sprintf("The ingress controller's DNS zones are invalid: %v", err),
+			},
+		}
+	}
+	if _, err := parseDNSRoutingPolicy(ic); err != nil {
+		return []operatorv1.OperatorCondition{
+			{
+				Type:    operatorv1.DNSManagedIngressConditionType,
+				Status:  operatorv1.ConditionFalse,
+				Reason:  "InvalidDNSRoutingPolicy",
+				Message: fmt.Sprintf("The ingress controller's DNS routing policy is invalid: %v", err),
+			},
+		}
+	}
+	mode, err := dnsRecordModeFor(ic)
+	if err != nil {
+		return []operatorv1.OperatorCondition{
+			{
+				Type:    operatorv1.DNSManagedIngressConditionType,
+				Status:  operatorv1.ConditionFalse,
+				Reason:  "InvalidDNSRecordMode",
+				Message: fmt.Sprintf("The ingress controller's DNS record mode is invalid: %v", err),
+			},
+		}
+	}
+	if privateZone == nil && publicZone == nil {
+		return []operatorv1.OperatorCondition{
+			{
+				Type:    operatorv1.DNSManagedIngressConditionType,
+				Status:  operatorv1.ConditionFalse,
+				Reason:  "NoDNSZones",
+				Message: "No DNS zones are defined in the cluster dns config or the ingress controller's annotations for the ingress controller's DNS scope.",
+			},
+		}
+	}
+
+	conditions := []operatorv1.OperatorCondition{
+		{
+			Type:    operatorv1.DNSManagedIngressConditionType,
+			Status:  operatorv1.ConditionTrue,
+			Reason:  "Normal",
+			Message: "DNS management is supported and zones are specified for the ingress controller.",
+		},
+	}
+
+	switch {
+	case dnsRecord == nil:
+		conditions = append(conditions, operatorv1.OperatorCondition{
+			Type:    operatorv1.DNSReadyIngressConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "RecordNotFound",
+			Message: "The wildcard record resource was not found.",
+		})
+	case hasHostNetworkDNS(ic) && (readyReplicas == 0 || mode == wildcardDNSRecordMode && len(dnsRecord.Spec.Records) == 0):
+		conditions = append(conditions, operatorv1.OperatorCondition{
+			Type:    operatorv1.DNSReadyIngressConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "NoReadyRouterPods",
+			Message: "The ingress controller has no ready router pods whose node addresses can be published, so the last published addresses, if any, are kept.",
+		})
+	case mode == perRouteDNSRecordMode && len(dnsRecord.Spec.Records) == 0:
+		conditions = append(conditions, operatorv1.OperatorCondition{
+			Type:    operatorv1.DNSReadyIngressConditionType,
+			Status:  operatorv1.ConditionTrue,
+			Reason:  "NoRoutes",
+			Message: "The ingress controller has admitted no routes in its domain that need records.",
+		})
+	case dnsRecord.Status.ObservedGeneration != dnsRecord.Generation:
+		conditions = append(conditions, operatorv1.OperatorCondition{
+			Type:    operatorv1.DNSReadyIngressConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "Pending",
+			Message: "The DNS controller has not yet published the latest records.",
+		})
+	case len(dnsRecord.Status.Zones) == 0:
+		conditions = append(conditions, operatorv1.OperatorCondition{
+			Type:    operatorv1.DNSReadyIngressConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "NoZones",
+			Message: "The record isn't present in any zones.",
+		})
+	default:
+		failedZones := []configv1.DNSZone{}
+		pendingZones := []configv1.DNSZone{}
+		unsupported, conflict := true, true
+		for _, zone := range dnsRecord.Status.Zones {
+			for _, cond := range zone.Conditions {
+				if cond.Type == iov1.DNSRecordPendingConditionType && cond.Status == string(operatorv1.ConditionTrue) {
+					pendingZones = append(pendingZones, zone.DNSZone)
+				}
+				if cond.Type == iov1.DNSRecordFailedConditionType && cond.Status == string(operatorv1.ConditionTrue) {
+					failedZones = append(failedZones, zone.DNSZone)
+					if cond.Reason != "UnsupportedRecordType" {
+						unsupported = false
+					}
+					if cond.Reason != "OwnershipConflict" {
+						conflict = false
+					}
+				}
+			}
+		}
+		switch {
+		case len(failedZones) == 0 && len(pendingZones) > 0:
+			conditions = append(conditions, operatorv1.OperatorCondition{
+				Type:    operatorv1.DNSReadyIngressConditionType,
+				Status:  operatorv1.ConditionFalse,
+				Reason:  "Pending",
+				Message: fmt.Sprintf("The record is being propagated to some zones: %s", formatZones(pendingZones)),
+			})
+		case len(failedZones) == 0 && verifyResolution != nil:
+			if err := verifyResolution(); err != nil {
+				conditions = append(conditions, operatorv1.OperatorCondition{
+					Type:    operatorv1.DNSReadyIngressConditionType,
+					Status:  operatorv1.ConditionFalse,
+					Reason:  "ResolutionFailed",
+					Message: fmt.Sprintf("The record is provisioned in all reported zones but does not resolve to the load balancer: %v", err),
+				})
+				break
+			}
+			conditions = append(conditions, operatorv1.OperatorCondition{
+				Type:    operatorv1.DNSReadyIngressConditionType,
+				Status:  operatorv1.ConditionTrue,
+				Reason:  "NoFailedZones",
+				Message: "The record is provisioned in all reported zones and resolves to the load balancer.",
+			})
+		case len(failedZones) == 0:
+			conditions = append(conditions, operatorv1.OperatorCondition{
+				Type:    operatorv1.DNSReadyIngressConditionType,
+				Status:  operatorv1.ConditionTrue,
+				Reason:  "NoFailedZones",
+				Message: "The record is provisioned in all reported zones.",
+			})
+		case unsupported:
+			conditions = append(conditions, operatorv1.OperatorCondition{
+				Type:    operatorv1.DNSReadyIngressConditionType,
+				Status:  operatorv1.ConditionFalse,
+				Reason:  "UnsupportedRecordType",
+				Message: fmt.Sprintf("The DNS provider does not support the record type in some zones: %s", formatZones(failedZones)),
+			})
+		case conflict:
+			conditions = append(conditions, operatorv1.OperatorCondition{
+				Type:    operatorv1.DNSReadyIngressConditionType,
+				Status:  operatorv1.ConditionFalse,
+				Reason:  "OwnershipConflict",
+				Message: fmt.Sprintf("The record is owned by another cluster or ingress controller in some zones: %s", formatZones(failedZones)),
+			})
+		default:
+			conditions = append(conditions, operatorv1.OperatorCondition{
+				Type:    operatorv1.DNSReadyIngressConditionType,
+				Status:  operatorv1.ConditionFalse,
+				Reason:  "FailedZones",
+				Message: fmt.Sprintf("The record failed to provision in some zones: %s", formatZones(failedZones)),
+			})
+		}
+	}
+
+	return conditions
+}
+
+// computeCanonicalHostnameStatus computes the condition that reports the
+// ingresscontroller's canonical hostname, given the DNSRecord and the computed
+// DNS conditions. The canonical hostname is published once the DNSRecord has a
+// record for it and DNS is ready.
+func computeCanonicalHostnameStatus(ic *operatorv1.IngressController, dnsRecord *iov1.DNSRecord, dnsConditions []operatorv1.OperatorCondition) []operatorv1.OperatorCondition {
+	canonicalHostname := CanonicalHostname(ic)
+	if len(canonicalHostname) == 0 {
+		return nil
+	}
+
+	hasRecord := false
+	if dnsRecord != nil {
+		for _, record := range dnsRecord.Spec.Records {
+			if record.Domain == canonicalHostname {
+				hasRecord = true
+				break
+			}
+		}
+	}
+	dnsReady := false
+	for _, cond := range dnsConditions {
+		if cond.Type == operatorv1.DNSReadyIngressConditionType {
+			dnsReady = cond.Status == operatorv1.ConditionTrue
+		}
+	}
+
+	switch {
+	case !hasRecord:
+		return []operatorv1.OperatorCondition{{
+			Type:    CanonicalHostnamePublishedIngressConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "NoRecord",
+			Message: fmt.Sprintf("The canonical hostname is %s, but the operator does not publish a DNS record for it.", canonicalHostname),
+		}}
+	case !dnsReady:
+		return []operatorv1.OperatorCondition{{
+			Type:    CanonicalHostnamePublishedIngressConditionType,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  "DNSNotReady",
+			Message: fmt.Sprintf("The canonical hostname is %s, and its DNS record is not ready.", canonicalHostname),
+		}}
+	}
+	return []operatorv1.OperatorCondition{{
+		Type:    CanonicalHostnamePublishedIngressConditionType,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  "Published",
+		Message: fmt.Sprintf("The canonical hostname is %s, and its DNS record is published.", canonicalHostname),
+	}}
+}
+
+// formatZones returns a human readable list of the given zones.
+func formatZones(zones []configv1.DNSZone) string {
+	names := []string{}
+	for _, zone := range zones {
+		if len(zone.ID) > 0 {
+			names = append(names, zone.ID)
+		} else {
+			names = append(names, fmt.Sprintf("%v", zone.Tags))
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
 func isProvisioned(service *corev1.Service) bool {
 	ingresses := service.Status.LoadBalancer.Ingress
 	return len(ingresses) > 0 && (len(ingresses[0].Hostname) > 0 || len(ingresses[0].IP) > 0)
@@ -2,13 +2,16 @@ package controller
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 
+	iov1 "github.com/openshift/cluster-ingress-operator/pkg/api/v1"
 	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
 
+	configv1 "github.com/openshift/api/config/v1"
 	operatorv1 "github.com/openshift/api/operator/v1"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -190,6 +193,434 @@ func TestComputeLoadBalancerStatus(t *testing.T) {
 	}
 }
 
+func TestComputeDNSStatus(t *testing.T) {
+	zoneStatus := func(zone configv1.DNSZone, failed operatorv1.ConditionStatus, reason string) iov1.DNSZoneStatus {
+		return iov1.DNSZoneStatus{
+			DNSZone: zone,
+			Conditions: []iov1.DNSZoneCondition{{
+				Type:   iov1.DNSRecordFailedConditionType,
+				Status: string(failed),
+				Reason: reason,
+			}},
+		}
+	}
+	withPending := func(status iov1.DNSZoneStatus, pending operatorv1.ConditionStatus) iov1.DNSZoneStatus {
+		status.Conditions = append(status.Conditions, iov1.DNSZoneCondition{
+			Type:   iov1.DNSRecordPendingConditionType,
+			Status: string(pending),
+		})
+		return status
+	}
+	dnsRecord := func(zones ...iov1.DNSZoneStatus) *iov1.DNSRecord {
+		return &iov1.DNSRecord{
+			Status: iov1.DNSRecordStatus{Zones: zones},
+		}
+	}
+	withRecords := func(record *iov1.DNSRecord, records ...iov1.Record) *iov1.DNSRecord {
+		record.Spec.Records = records
+		return record
+	}
+	withGeneration := func(record *iov1.DNSRecord, generation, observedGeneration int64) *iov1.DNSRecord {
+		record.Generation = generation
+		record.Status.ObservedGeneration = observedGeneration
+		return record
+	}
+	withDomain := func(ic *operatorv1.IngressController) *operatorv1.IngressController {
+		ic.Status.Domain = "apps.example.com"
+		return ic
+	}
+	withAnnotation := func(ic *operatorv1.IngressController, key, value string) *operatorv1.IngressController {
+		ic.Annotations = map[string]string{key: value}
+		return ic
+	}
+
+	resolves := func() error { return nil }
+	doesNotResolve := func() error { return fmt.Errorf("no such host") }
+
+	tests := []struct {
+		name             string
+		controller       *operatorv1.IngressController
+		record           *iov1.DNSRecord
+		dnsConfig        *configv1.DNS
+		readyReplicas    int32
+		verifyResolution func() error
+		expect           []operatorv1.OperatorCondition
+	}{
+		{
+			name:       "unsupported endpoint publishing strategy",
+			controller: withDomain(ingressController("default", operatorv1.HostNetworkStrategyType)),
+			dnsConfig:  globalConfig,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionFalse, "UnsupportedEndpointPublishingStrategy"),
+			},
+		},
+		{
+			name: "host network without router pods",
+			controller: withAnnotation(withDomain(ingressController("default", operatorv1.HostNetworkStrategyType)),
+				HostNetworkDNSAnnotation, "true"),
+			record:    dnsRecord(),
+			dnsConfig: globalConfig,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionTrue, "Normal"),
+				cond(operatorv1.DNSReadyIngressConditionType, operatorv1.ConditionFalse, "NoReadyRouterPods"),
+			},
+		},
+		{
+			name: "host network with router pods",
+			controller: withAnnotation(withDomain(ingressController("default", operatorv1.HostNetworkStrategyType)),
+				HostNetworkDNSAnnotation, "true"),
+			record: withRecords(dnsRecord(
+				zoneStatus(privateZone, operatorv1.ConditionFalse, "Published"),
+				zoneStatus(publicZone, operatorv1.ConditionFalse, "Published"),
+			), iov1.Record{Zone: publicZone, Type: iov1.ARecordType, Domain: "*.apps.example.com", Target: "192.0.2.1"}),
+			dnsConfig:     globalConfig,
+			readyReplicas: 1,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionTrue, "Normal"),
+				cond(operatorv1.DNSReadyIngressConditionType, operatorv1.ConditionTrue, "NoFailedZones"),
+			},
+		},
+		{
+			name: "host network with the last published addresses and no ready router pods",
+			controller: withAnnotation(withDomain(ingressController("default", operatorv1.HostNetworkStrategyType)),
+				HostNetworkDNSAnnotation, "true"),
+			record: withRecords(dnsRecord(
+				zoneStatus(privateZone, operatorv1.ConditionFalse, "Published"),
+				zoneStatus(publicZone, operatorv1.ConditionFalse, "Published"),
+			), iov1.Record{Zone: publicZone, Type: iov1.ARecordType, Domain: "*.apps.example.com", Target: "192.0.2.1"}),
+			dnsConfig: globalConfig,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionTrue, "Normal"),
+				cond(operatorv1.DNSReadyIngressConditionType, operatorv1.ConditionFalse, "NoReadyRouterPods"),
+			},
+		},
+		{
+			name:       "dnsrecord generation not yet observed",
+			controller: withDomain(ingressController("default", operatorv1.LoadBalancerServiceStrategyType)),
+			record: withGeneration(dnsRecord(
+				zoneStatus(privateZone, operatorv1.ConditionFalse, "Published"),
+				zoneStatus(publicZone, operatorv1.ConditionFalse, "Published"),
+			), 2, 1),
+			dnsConfig: globalConfig,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionTrue, "Normal"),
+				cond(operatorv1.DNSReadyIngressConditionType, operatorv1.ConditionFalse, "Pending"),
+			},
+		},
+		{
+			name:       "no domain",
+			controller: ingressController("default", operatorv1.LoadBalancerServiceStrategyType),
+			dnsConfig:  globalConfig,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionFalse, "NoDomain"),
+			},
+		},
+		{
+			name:       "no zones in dns config",
+			controller: withDomain(ingressController("default", operatorv1.LoadBalancerServiceStrategyType)),
+			dnsConfig:  &configv1.DNS{},
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionFalse, "NoDNSZones"),
+			},
+		},
+		{
+			name: "invalid dns scope annotation",
+			controller: withAnnotation(withDomain(ingressController("default", operatorv1.LoadBalancerServiceStrategyType)),
+				DNSScopeAnnotation, "Internal"),
+			dnsConfig: globalConfig,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionFalse, "InvalidDNSZones"),
+			},
+		},
+		{
+			name: "invalid dns routing policy annotation",
+			controller: withAnnotation(withDomain(ingressController("default", operatorv1.LoadBalancerServiceStrategyType)),
+				DNSRoutingPolicyAnnotation, "Geolocation"),
+			dnsConfig: globalConfig,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionFalse, "InvalidDNSRoutingPolicy"),
+			},
+		},
+		{
+			name: "invalid dns records annotation",
+			controller: withAnnotation(withDomain(ingressController("default", operatorv1.LoadBalancerServiceStrategyType)),
+				DNSRecordsAnnotation, "PerHost"),
+			dnsConfig: globalConfig,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionFalse, "InvalidDNSRecordMode"),
+			},
+		},
+		{
+			name: "no zones in dns scope",
+			controller: withAnnotation(withDomain(ingressController("default", operatorv1.LoadBalancerServiceStrategyType)),
+				DNSScopeAnnotation, "Public"),
+			dnsConfig: privateConfig,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionFalse, "NoDNSZones"),
+			},
+		},
+		{
+			name:       "dnsrecord missing",
+			controller: withDomain(ingressController("default", operatorv1.LoadBalancerServiceStrategyType)),
+			dnsConfig:  globalConfig,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionTrue, "Normal"),
+				cond(operatorv1.DNSReadyIngressConditionType, operatorv1.ConditionFalse, "RecordNotFound"),
+			},
+		},
+		{
+			name:       "dnsrecord not published to any zones",
+			controller: withDomain(ingressController("default", operatorv1.LoadBalancerServiceStrategyType)),
+			record:     dnsRecord(),
+			dnsConfig:  globalConfig,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionTrue, "Normal"),
+				cond(operatorv1.DNSReadyIngressConditionType, operatorv1.ConditionFalse, "NoZones"),
+			},
+		},
+		{
+			name: "dnsrecord without routes",
+			controller: withAnnotation(withDomain(ingressController("default", operatorv1.LoadBalancerServiceStrategyType)),
+				DNSRecordsAnnotation, "PerRoute"),
+			record:    dnsRecord(),
+			dnsConfig: globalConfig,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionTrue, "Normal"),
+				cond(operatorv1.DNSReadyIngressConditionType, operatorv1.ConditionTrue, "NoRoutes"),
+			},
+		},
+		{
+			name:       "dnsrecord published to all zones",
+			controller: withDomain(ingressController("default", operatorv1.LoadBalancerServiceStrategyType)),
+			record: dnsRecord(
+				zoneStatus(privateZone, operatorv1.ConditionFalse, "Published"),
+				zoneStatus(publicZone, operatorv1.ConditionFalse, "Published"),
+			),
+			dnsConfig: globalConfig,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionTrue, "Normal"),
+				cond(operatorv1.DNSReadyIngressConditionType, operatorv1.ConditionTrue, "NoFailedZones"),
+			},
+		},
+		{
+			name:       "dnsrecord published to all zones and resolves",
+			controller: withDomain(ingressController("default", operatorv1.LoadBalancerServiceStrategyType)),
+			record: dnsRecord(
+				zoneStatus(privateZone, operatorv1.ConditionFalse, "Published"),
+				zoneStatus(publicZone, operatorv1.ConditionFalse, "Published"),
+			),
+			dnsConfig:        globalConfig,
+			verifyResolution: resolves,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionTrue, "Normal"),
+				cond(operatorv1.DNSReadyIngressConditionType, operatorv1.ConditionTrue, "NoFailedZones"),
+			},
+		},
+		{
+			name:       "dnsrecord published to all zones but does not resolve",
+			controller: withDomain(ingressController("default", operatorv1.LoadBalancerServiceStrategyType)),
+			record: dnsRecord(
+				zoneStatus(privateZone, operatorv1.ConditionFalse, "Published"),
+				zoneStatus(publicZone, operatorv1.ConditionFalse, "Published"),
+			),
+			dnsConfig:        globalConfig,
+			verifyResolution: doesNotResolve,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionTrue, "Normal"),
+				cond(operatorv1.DNSReadyIngressConditionType, operatorv1.ConditionFalse, "ResolutionFailed"),
+			},
+		},
+		{
+			name:       "dnsrecord pending in one zone is not resolved",
+			controller: withDomain(ingressController("default", operatorv1.LoadBalancerServiceStrategyType)),
+			record: dnsRecord(
+				zoneStatus(privateZone, operatorv1.ConditionFalse, "Published"),
+				withPending(zoneStatus(publicZone, operatorv1.ConditionFalse, "Published"), operatorv1.ConditionTrue),
+			),
+			dnsConfig:        globalConfig,
+			verifyResolution: resolves,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionTrue, "Normal"),
+				cond(operatorv1.DNSReadyIngressConditionType, operatorv1.ConditionFalse, "Pending"),
+			},
+		},
+		{
+			name:       "dnsrecord pending in one zone",
+			controller: withDomain(ingressController("default", operatorv1.LoadBalancerServiceStrategyType)),
+			record: dnsRecord(
+				zoneStatus(privateZone, operatorv1.ConditionFalse, "Published"),
+				withPending(zoneStatus(publicZone, operatorv1.ConditionFalse, "Published"), operatorv1.ConditionTrue),
+			),
+			dnsConfig: globalConfig,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionTrue, "Normal"),
+				cond(operatorv1.DNSReadyIngressConditionType, operatorv1.ConditionFalse, "Pending"),
+			},
+		},
+		{
+			name:       "dnsrecord propagated to all zones",
+			controller: withDomain(ingressController("default", operatorv1.LoadBalancerServiceStrategyType)),
+			record: dnsRecord(
+				withPending(zoneStatus(privateZone, operatorv1.ConditionFalse, "Published"), operatorv1.ConditionFalse),
+				withPending(zoneStatus(publicZone, operatorv1.ConditionFalse, "Published"), operatorv1.ConditionFalse),
+			),
+			dnsConfig: globalConfig,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionTrue, "Normal"),
+				cond(operatorv1.DNSReadyIngressConditionType, operatorv1.ConditionTrue, "NoFailedZones"),
+			},
+		},
+		{
+			name:       "dnsrecord failed in one zone and pending in another",
+			controller: withDomain(ingressController("default", operatorv1.LoadBalancerServiceStrategyType)),
+			record: dnsRecord(
+				withPending(zoneStatus(privateZone, operatorv1.ConditionFalse, "Published"), operatorv1.ConditionTrue),
+				zoneStatus(publicZone, operatorv1.ConditionTrue, "ProviderError"),
+			),
+			dnsConfig: globalConfig,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionTrue, "Normal"),
+				cond(operatorv1.DNSReadyIngressConditionType, operatorv1.ConditionFalse, "FailedZones"),
+			},
+		},
+		{
+			name:       "dnsrecord failed in one zone",
+			controller: withDomain(ingressController("default", operatorv1.LoadBalancerServiceStrategyType)),
+			record: dnsRecord(
+				zoneStatus(privateZone, operatorv1.ConditionFalse, "Published"),
+				zoneStatus(publicZone, operatorv1.ConditionTrue, "ProviderError"),
+			),
+			dnsConfig: globalConfig,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionTrue, "Normal"),
+				cond(operatorv1.DNSReadyIngressConditionType, operatorv1.ConditionFalse, "FailedZones"),
+			},
+		},
+		{
+			name:       "dnsrecord has unsupported record type",
+			controller: withDomain(ingressController("default", operatorv1.LoadBalancerServiceStrategyType)),
+			record: dnsRecord(
+				zoneStatus(privateZone, operatorv1.ConditionTrue, "UnsupportedRecordType"),
+				zoneStatus(publicZone, operatorv1.ConditionTrue, "UnsupportedRecordType"),
+			),
+			dnsConfig: globalConfig,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionTrue, "Normal"),
+				cond(operatorv1.DNSReadyIngressConditionType, operatorv1.ConditionFalse, "UnsupportedRecordType"),
+			},
+		},
+		{
+			name:       "dnsrecord has unsupported record type and provider error",
+			controller: withDomain(ingressController("default", operatorv1.LoadBalancerServiceStrategyType)),
+			record: dnsRecord(
+				zoneStatus(privateZone, operatorv1.ConditionTrue, "UnsupportedRecordType"),
+				zoneStatus(publicZone, operatorv1.ConditionTrue, "ProviderError"),
+			),
+			dnsConfig: globalConfig,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionTrue, "Normal"),
+				cond(operatorv1.DNSReadyIngressConditionType, operatorv1.ConditionFalse, "FailedZones"),
+			},
+		},
+		{
+			name:       "dnsrecord has ownership conflict",
+			controller: withDomain(ingressController("default", operatorv1.LoadBalancerServiceStrategyType)),
+			record: dnsRecord(
+				zoneStatus(privateZone, operatorv1.ConditionFalse, "Published"),
+				zoneStatus(publicZone, operatorv1.ConditionTrue, "OwnershipConflict"),
+			),
+			dnsConfig: globalConfig,
+			expect: []operatorv1.OperatorCondition{
+				cond(operatorv1.DNSManagedIngressConditionType, operatorv1.ConditionTrue, "Normal"),
+				cond(operatorv1.DNSReadyIngressConditionType, operatorv1.ConditionFalse, "OwnershipConflict"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Logf("evaluating test %s", test.name)
+
+		actual := computeDNSStatus(test.controller, test.record, test.dnsConfig, test.readyReplicas, test.verifyResolution)
+
+		conditionsCmpOpts := []cmp.Option{
+			cmpopts.IgnoreFields(operatorv1.OperatorCondition{}, "LastTransitionTime", "Message"),
+			cmpopts.EquateEmpty(),
+			cmpopts.SortSlices(func(a, b operatorv1.OperatorCondition) bool { return a.Type < b.Type }),
+		}
+		if !cmp.Equal(actual, test.expect, conditionsCmpOpts...) {
+			t.Fatalf("expected:\n%#v\ngot:\n%#v", test.expect, actual)
+		}
+	}
+}
+
+func TestComputeCanonicalHostnameStatus(t *testing.T) {
+	ic := &operatorv1.IngressController{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Status:     operatorv1.IngressControllerStatus{Domain: "apps.example.com"},
+	}
+	withRecord := &iov1.DNSRecord{
+		Spec: iov1.DNSRecordSpec{
+			Records: []iov1.Record{
+				{Zone: publicZone, Type: iov1.ARecordType, Domain: "*.apps.example.com", Target: "192.0.2.1"},
+				{Zone: publicZone, Type: iov1.ARecordType, Domain: "router-default.apps.example.com", Target: "192.0.2.1"},
+			},
+		},
+	}
+	withoutRecord := &iov1.DNSRecord{
+		Spec: iov1.DNSRecordSpec{
+			Records: []iov1.Record{
+				{Zone: publicZone, Type: iov1.ARecordType, Domain: "*.apps.example.com", Target: "192.0.2.1"},
+			},
+		},
+	}
+	ready := []operatorv1.OperatorCondition{cond(operatorv1.DNSReadyIngressConditionType, operatorv1.ConditionTrue, "NoFailedZones")}
+	notReady := []operatorv1.OperatorCondition{cond(operatorv1.DNSReadyIngressConditionType, operatorv1.ConditionFalse, "FailedZones")}
+
+	tests := []struct {
+		name          string
+		record        *iov1.DNSRecord
+		dnsConditions []operatorv1.OperatorCondition
+		expect        []operatorv1.OperatorCondition
+	}{
+		{
+			name:   "no dnsrecord",
+			expect: []operatorv1.OperatorCondition{cond(CanonicalHostnamePublishedIngressConditionType, operatorv1.ConditionFalse, "NoRecord")},
+		},
+		{
+			name:          "dnsrecord without the canonical hostname",
+			record:        withoutRecord,
+			dnsConditions: ready,
+			expect:        []operatorv1.OperatorCondition{cond(CanonicalHostnamePublishedIngressConditionType, operatorv1.ConditionFalse, "NoRecord")},
+		},
+		{
+			name:          "dns not ready",
+			record:        withRecord,
+			dnsConditions: notReady,
+			expect:        []operatorv1.OperatorCondition{cond(CanonicalHostnamePublishedIngressConditionType, operatorv1.ConditionFalse, "DNSNotReady")},
+		},
+		{
+			name:          "published",
+			record:        withRecord,
+			dnsConditions: ready,
+			expect:        []operatorv1.OperatorCondition{cond(CanonicalHostnamePublishedIngressConditionType, operatorv1.ConditionTrue, "Published")},
+		},
+	}
+
+	for _, test := range tests {
+		actual := computeCanonicalHostnameStatus(ic, test.record, test.dnsConditions)
+		if !cmp.Equal(actual, test.expect, cmpopts.IgnoreFields(operatorv1.OperatorCondition{}, "Message")) {
+			t.Errorf("%s: expected:\n%#v\ngot:\n%#v", test.name, test.expect, actual)
+		}
+		if len(actual) > 0 && !strings.Contains(actual[0].Message, "router-default.apps.example.com") {
+			t.Errorf("%s: expected the message to name the canonical hostname, got %q", test.name, actual[0].Message)
+		}
+	}
+
+	if actual := computeCanonicalHostnameStatus(&operatorv1.IngressController{}, nil, nil); len(actual) != 0 {
+		t.Errorf("expected no condition without a domain, got %v", actual)
+	}
+}
+
 func TestComputeIngressStatusConditions(t *testing.T) {
 	testCases := []struct {
 		description     string
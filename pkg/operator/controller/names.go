@@ -113,3 +113,37 @@ func IngressControllerServiceMonitorName(ic *operatorv1.IngressController) types
 func LoadBalancerServiceName(ic *operatorv1.IngressController) types.NamespacedName {
 	return types.NamespacedName{Namespace: "openshift-ingress", Name: "router-" + ic.Name}
 }
+
+// InternalLoadBalancerServiceName returns the namespaced name for the internal
+// LB service of the ingresscontroller. The name is derived from the
+// ingresscontroller's UID rather than its name, as any name that is derived
+// from the ingresscontroller's name is also the name of a service of some
+// other ingresscontroller, such as the LB service of one named
+// "<name>-internal-lb".
+func InternalLoadBalancerServiceName(ic *operatorv1.IngressController) types.NamespacedName {
+	return types.NamespacedName{Namespace: "openshift-ingress", Name: "router-internal-lb-" + string(ic.UID)}
+}
+
+// WildcardDNSRecordName returns the namespaced name for the DNSRecord that
+// holds the wildcard DNS records of the ingresscontroller.
+func WildcardDNSRecordName(ic *operatorv1.IngressController) types.NamespacedName {
+	return types.NamespacedName{Namespace: ic.Namespace, Name: ic.Name + "-wildcard"}
+}
+
+// RouteDNSRecordName returns the namespaced name for the DNSRecord that holds
+// the DNS records for the hosts of the ingresscontroller's routes in the given
+// shard.
+func RouteDNSRecordName(ic *operatorv1.IngressController, shard int) types.NamespacedName {
+	return types.NamespacedName{Namespace: ic.Namespace, Name: fmt.Sprintf("%s-routes-%d", ic.Name, shard)}
+}
+
+// CanonicalHostname returns the canonical hostname of the ingresscontroller's
+// router, which is a name in the ingresscontroller's domain that the operator
+// publishes next to the wildcard record and that the router reports in route
+// status. Returns the empty string if the ingresscontroller has no domain.
+func CanonicalHostname(ic *operatorv1.IngressController) string {
+	if len(ic.Status.Domain) == 0 {
+		return ""
+	}
+	return "router-" + ic.Name + "." + ic.Status.Domain
+}
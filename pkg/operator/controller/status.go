@@ -10,6 +10,7 @@ import (
 
 	configv1 "github.com/openshift/api/config/v1"
 	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
 	"github.com/openshift/cluster-ingress-operator/pkg/manifests"
 
 	corev1 "k8s.io/api/core/v1"
@@ -162,7 +163,7 @@ func (r *reconciler) computeOperatorStatusConditions(oldConditions []configv1.Cl
 	}
 
 	conditions := []configv1.ClusterOperatorStatusCondition{
-		computeOperatorDegradedCondition(oldDegradedCondition, ns),
+		computeOperatorDegradedCondition(oldDegradedCondition, ns, r.dnsReloadError()),
 		r.computeOperatorProgressingCondition(oldProgressingCondition, allIngressesAvailable, oldVersions, curVersions),
 		computeOperatorAvailableCondition(oldAvailableCondition, allIngressesAvailable),
 	}
@@ -188,9 +189,18 @@ func checkAllIngressesAvailable(ingresses []operatorv1.IngressController) bool {
 	return (len(ingresses) != 0)
 }
 
+// dnsReloadError returns the error of the most recent reload of the DNS
+// manager, if the DNS manager is reloadable and the reload failed.
+func (r *reconciler) dnsReloadError() error {
+	if reloadable, ok := r.DNSManager.(*dns.ReloadableManager); ok {
+		return reloadable.ReloadError()
+	}
+	return nil
+}
+
 // computeOperatorDegradedCondition computes the operator's current Degraded status state.
 func computeOperatorDegradedCondition(oldCondition *configv1.ClusterOperatorStatusCondition,
-	ns *corev1.Namespace) configv1.ClusterOperatorStatusCondition {
+	ns *corev1.Namespace, dnsReloadErr error) configv1.ClusterOperatorStatusCondition {
 	degradedCondition := configv1.ClusterOperatorStatusCondition{
 		Type: configv1.OperatorDegraded,
 	}
@@ -198,6 +208,10 @@ func computeOperatorDegradedCondition(oldCondition *configv1.ClusterOperatorStat
 		degradedCondition.Status = configv1.ConditionTrue
 		degradedCondition.Reason = "NoNamespace"
 		degradedCondition.Message = "operand namespace does not exist"
+	} else if dnsReloadErr != nil {
+		degradedCondition.Status = configv1.ConditionTrue
+		degradedCondition.Reason = "InvalidDNSConfig"
+		degradedCondition.Message = fmt.Sprintf("The DNS provider configuration or credentials are invalid: %v", dnsReloadErr)
 	} else {
 		degradedCondition.Status = configv1.ConditionFalse
 		degradedCondition.Message = "operand namespace exists"
@@ -1,12 +1,14 @@
 package controller
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 
 	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/cluster-ingress-operator/pkg/dns"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -23,6 +25,7 @@ func TestComputeOperatorStatusConditions(t *testing.T) {
 	testCases := []struct {
 		description           string
 		noNamespace           bool
+		dnsReloadErr          error
 		allIngressesAvailable bool
 		reportedVersions      versions
 		oldVersions           versions
@@ -35,6 +38,12 @@ func TestComputeOperatorStatusConditions(t *testing.T) {
 			allIngressesAvailable: true,
 			expectedConditions:    conditions{true, false, true},
 		},
+		{
+			description:           "dns manager reload failed",
+			dnsReloadErr:          errors.New("invalid credentials"),
+			allIngressesAvailable: true,
+			expectedConditions:    conditions{true, false, true},
+		},
 		{
 			description:           "all ingress controllers are available",
 			allIngressesAvailable: true,
@@ -142,6 +151,11 @@ func TestComputeOperatorStatusConditions(t *testing.T) {
 				IngressControllerImage: tc.curVersions.operand,
 			},
 		}
+		if tc.dnsReloadErr != nil {
+			reloadable := dns.NewReloadableManager(&dns.NoopManager{})
+			reloadable.SetReloadError(tc.dnsReloadErr)
+			r.DNSManager = reloadable
+		}
 
 		expectedConditions := []configv1.ClusterOperatorStatusCondition{
 			{
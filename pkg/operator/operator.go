@@ -13,10 +13,14 @@ import (
 	operatorcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller"
 	certcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/certificate"
 	certpublishercontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/certificate-publisher"
+	dnscontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/dns"
+	dnsconfigcontroller "github.com/openshift/cluster-ingress-operator/pkg/operator/controller/dns-config"
 	operatorutil "github.com/openshift/cluster-ingress-operator/pkg/util"
 
 	"k8s.io/client-go/rest"
 
+	corev1 "k8s.io/api/core/v1"
+
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -26,6 +30,7 @@ import (
 
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
@@ -56,8 +61,25 @@ type Operator struct {
 	namespace string
 }
 
+// DNSConfig describes how the operator builds the DNS manager, which it
+// rebuilds whenever the resources from which the DNS manager is built change.
+type DNSConfig struct {
+	// NewManager builds a DNS manager from the current configuration.
+	NewManager func() (dns.Manager, error)
+	// SecretNames are the names of the secrets in the operator namespace
+	// that NewManager reads.
+	SecretNames []string
+	// ConfigMapNames are the names of the configmaps in the operator
+	// namespace that NewManager reads.
+	ConfigMapNames []string
+	// SecretNamesFromConfigMap returns the names of the further secrets in
+	// the operator namespace that NewManager reads because the given
+	// configmap refers to them.
+	SecretNamesFromConfigMap func(configMap *corev1.ConfigMap) []string
+}
+
 // New creates (but does not start) a new operator from configuration.
-func New(config operatorconfig.Config, dnsManager dns.Manager, kubeConfig *rest.Config) (*Operator, error) {
+func New(config operatorconfig.Config, dnsConfig DNSConfig, kubeConfig *rest.Config) (*Operator, error) {
 	scheme := operatorclient.GetScheme()
 	// Set up an operator manager for the operator namespace.
 	mgr, err := manager.New(kubeConfig, manager.Options{
@@ -84,16 +106,74 @@ func New(config operatorconfig.Config, dnsManager dns.Manager, kubeConfig *rest.
 		return nil, fmt.Errorf("failed to create operator manager: %v", err)
 	}
 
+	// Set up a cache for the cluster-scoped config resources, which the
+	// operator manager's namespaced cache cannot watch.
+	clusterCache, err := cache.New(kubeConfig, cache.Options{Scheme: scheme, Mapper: mgr.GetRESTMapper()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cluster cache: %v", err)
+	}
+	if err := mgr.Add(clusterCache); err != nil {
+		return nil, fmt.Errorf("failed to add cluster cache to operator manager: %v", err)
+	}
+
+	// Build the initial DNS manager. The version of the resources from
+	// which it is built is read first, so that the DNS config controller
+	// rebuilds it only if they change.
+	dnsConfigControllerConfig := dnsconfigcontroller.Config{
+		Namespace:                config.Namespace,
+		SecretNames:              dnsConfig.SecretNames,
+		ConfigMapNames:           dnsConfig.ConfigMapNames,
+		SecretNamesFromConfigMap: dnsConfig.SecretNamesFromConfigMap,
+		NewDNSManager:            dnsConfig.NewManager,
+	}
+	initialDNSConfigVersion, err := dnsconfigcontroller.ConfigVersion(mgr.GetClient(), dnsConfigControllerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DNS config version: %v", err)
+	}
+	initialDNSManager, err := dnsConfig.NewManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create DNS manager: %v", err)
+	}
+	dnsManager := dns.NewReloadableManager(initialDNSManager)
+	dnsConfigControllerConfig.DNSManager = dnsManager
+	dnsConfigControllerConfig.InitialVersion = initialDNSConfigVersion
+	dnsManagerReloads := make(chan event.GenericEvent, 1)
+
+	// Verifying resolution makes live lookups on every status sync, which
+	// fail wherever the operator's pod can't see the cluster's zones, so it
+	// is opt-in.
+	var dnsResolver dns.Resolver
+	if config.VerifyDNSResolution || len(config.DNSResolver) != 0 {
+		dnsResolver = dns.NewResolver(config.DNSResolver)
+	}
+
 	// Create and register the operator controller with the operator manager.
-	if _, err := operatorcontroller.New(mgr, operatorcontroller.Config{
+	if _, err := operatorcontroller.New(mgr, clusterCache, operatorcontroller.Config{
 		Namespace:              config.Namespace,
-		DNSManager:             dnsManager,
 		IngressControllerImage: config.IngressControllerImage,
 		OperatorReleaseVersion: config.OperatorReleaseVersion,
+		DNSManager:             dnsManager,
+		DNSManagerReloads:      dnsManagerReloads,
+		DNSResolver:            dnsResolver,
 	}); err != nil {
 		return nil, fmt.Errorf("failed to create operator controller: %v", err)
 	}
 
+	// Set up the DNS config controller
+	dnsConfigControllerConfig.Reloads = dnsManagerReloads
+	if _, err := dnsconfigcontroller.New(mgr, clusterCache, dnsConfigControllerConfig); err != nil {
+		return nil, fmt.Errorf("failed to create dns config controller: %v", err)
+	}
+
+	// Set up the DNS controller
+	if _, err := dnscontroller.New(mgr, dnscontroller.Config{
+		Namespace:          config.Namespace,
+		DNSManager:         dnsManager,
+		InfrastructureName: config.InfrastructureName,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create dns controller: %v", err)
+	}
+
 	// Set up the certificate controller
 	if _, err := certcontroller.New(mgr, config.Namespace); err != nil {
 		return nil, fmt.Errorf("failed to create cacert controller: %v", err)